@@ -0,0 +1,16 @@
+// Package events holds the schemas of the events on the transaction topics:
+// the JSON Schemas of the envelope and of every payload version, named
+// <event_type>.v<schema_version>.schema.json, and the Avro and Protobuf
+// schemas of the current envelope registered for the binary encodings.
+package events
+
+import "embed"
+
+//go:embed *.schema.json *.avsc *.proto
+var Schemas embed.FS
+
+// Binary encodings of the current envelope.
+const (
+	AvroSchema     = "transaction.v2.avsc"
+	ProtobufSchema = "transaction.v2.proto"
+)
@@ -2,20 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	_ "github.com/serikdev/CashFlow/docs"
 
 	"github.com/serikdev/CashFlow/internal/adapter/repository"
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/codec"
 	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/health"
 	"github.com/serikdev/CashFlow/internal/kafka"
+	"github.com/serikdev/CashFlow/internal/ledger"
+	"github.com/serikdev/CashFlow/internal/lifecycle"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/port/grpcserver"
 	"github.com/serikdev/CashFlow/internal/port/rest"
 	"github.com/serikdev/CashFlow/internal/port/rest/handler"
+	"github.com/serikdev/CashFlow/internal/schemaregistry"
+	"github.com/serikdev/CashFlow/internal/tracing"
 	"github.com/serikdev/CashFlow/internal/usecase"
+	"github.com/serikdev/CashFlow/internal/webhook"
 	"github.com/serikdev/CashFlow/pkg/database"
 	"github.com/serikdev/CashFlow/pkg/logger"
 )
@@ -39,24 +50,109 @@ func main() {
 		"log_level": cfg.LoggerConfig.LogLevel,
 	}).Info("Starting server with config")
 
+	authenticator, err := auth.NewAPIKeyAuthenticator(cfg.AuthConfig.APIKeys)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to parse API keys")
+	}
+
+	var ledgerSigner *ledger.Signer
+	if cfg.LedgerConfig.SigningKey != "" {
+		if ledgerSigner, err = ledger.NewSigner(cfg.LedgerConfig.SigningKey); err != nil {
+			log.WithError(err).Fatal("Failed to load ledger signing key")
+		}
+	} else {
+		log.Warn("LEDGER_SIGNING_KEY is not set, ledger checkpoints are disabled")
+	}
+
+	registry, err := schemaregistry.Open(cfg.KafkaConfig.SchemaRegistryFile)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to open schema registry")
+	}
+	codecs, err := codec.NewSet(cfg.KafkaConfig, registry)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to configure codecs")
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracingConfig, log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to set up tracing")
+	}
+
 	db, err := database.NewPool(ctx, cfg.DBConfig, cfg, log)
 	if err != nil {
 		log.WithError(err).Fatal("Failed to connect database")
 	}
-	defer db.Close()
+	metrics.RegisterPool(db)
 
-	producer := kafka.NewProducerImpl(cfg.KafkaConfig.Brokers, log)
-	defer producer.Close()
+	// Message Bus
+	topics := cfg.KafkaConfig.Topics
+	subscriptions, err := bus.Subscriptions(cfg.ConsumerConfig, topics)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to configure consumer topics")
+	}
+
+	transactionTopics := usecase.TransactionTopics{
+		Deposit:  topics.Name(topics.Deposit),
+		Withdraw: topics.Name(topics.Withdraw),
+		Transfer: topics.Name(topics.Transfer),
+	}
+
+	const groupID = "cashflow-group"
+	var messageBus bus.MessageBus
+	switch cfg.BusConfig.Driver {
+	case bus.DriverKafka:
+		provisionCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		err := kafka.NewProvisioner(cfg.KafkaConfig.Brokers, topics, log).
+			Provision(provisionCtx, append(topics.Transactions(), bus.Topics(subscriptions)...))
+		cancel()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to provision Kafka topics")
+		}
+		messageBus = kafka.NewBus(cfg.KafkaConfig, groupID, codecs, log)
+	case bus.DriverPostgres:
+		messageBus = bus.NewPostgresBus(repository.NewQueueRepository(db, log), codecs, cfg.BusConfig, log)
+	case bus.DriverMemory:
+		messageBus = bus.NewMemoryBus(codecs, log)
+	default:
+		log.Fatalf("Unknown message bus %q", cfg.BusConfig.Driver)
+	}
+	log.WithField("driver", cfg.BusConfig.Driver).Info("Message bus configured")
 
 	accountRepo := repository.NewAccountRepository(db, log)
 	transactionRepo := repository.NewTransactionRepository(db, log)
+	accessRepo := repository.NewAccessRepository(db, log)
+	webhookRepo := repository.NewWebhookRepository(db, log)
+	auditRepo := repository.NewAuditRepository(db, log)
+	ledgerRepo := repository.NewLedgerRepository(db, log)
+	reconciliationRepo := repository.NewReconciliationRepository(db, log)
+	resultListener := repository.NewResultListener(db, log)
 
-	accountService := usecase.NewAccountService(accountRepo, log)
+	accessPolicy := usecase.NewAccessPolicy(accessRepo, log)
+	auditService := usecase.NewAuditService(auditRepo, log)
+	webhookService := usecase.NewWebhookService(webhookRepo, auditService, log)
+	activityService := usecase.NewActivityService(transactionRepo, accountRepo, accessPolicy, log)
+	activityListener := repository.NewActivityListener(db, activityService, log)
+	notifiers := usecase.Notifiers{webhookService, auditService}
+	healthService := health.NewService(cfg.HealthConfig.CheckTimeout)
+	ledgerService := usecase.NewLedgerService(ledgerRepo, ledgerSigner, log)
+	reconciliationService := usecase.NewReconciliationService(reconciliationRepo, auditService, log)
+	accountService := usecase.NewAccountService(accountRepo, accessPolicy, webhookService, auditService, log)
+
+	transactionApplier := usecase.NewTransactionApplier(transactionRepo, notifiers, log)
+	if mode := cfg.TransactionMode; mode != usecase.ModeAsync && mode != usecase.ModeSync {
+		log.Fatalf("Unknown transaction mode %q", mode)
+	}
 
 	transactionService := usecase.NewTransactionService(usecase.TransactionServiceDeps{
 		TransactionRepo: transactionRepo,
 		AccountRepo:     accountRepo,
-		Producer:        producer,
+		Producer:        messageBus,
+		Topics:          transactionTopics,
+		Applier:         transactionApplier,
+		Mode:            cfg.TransactionMode,
+		Results:         resultListener,
+		Access:          accessPolicy,
+		Audit:           auditService,
 		Logger:          log,
 	})
 
@@ -66,33 +162,61 @@ func main() {
 
 	accountHandler := handler.NewAccountHandler(&baseHandler, accountService, log)
 	transactionHandler := handler.NewTransactionHandler(&baseHandler, transactionService, log)
+	webhookHandler := handler.NewWebhookHandler(&baseHandler, webhookService, log)
+	activityHandler := handler.NewActivityHandler(&baseHandler, activityService, cfg.SSEConfig.HeartbeatInterval, log)
+	healthHandler := handler.NewHealthHandler(&baseHandler, healthService, log)
+	auditHandler := handler.NewAuditHandler(&baseHandler, auditService, log)
+	ledgerHandler := handler.NewLedgerHandler(&baseHandler, ledgerService, log)
+	reconciliationHandler := handler.NewReconciliationHandler(&baseHandler, reconciliationService, log)
 
 	handlers := rest.Handlers{
-		AccountHandler:     accountHandler,
-		TransactionHandler: transactionHandler,
+		BaseHandler:           &baseHandler,
+		Authenticator:         authenticator,
+		AccountHandler:        accountHandler,
+		TransactionHandler:    transactionHandler,
+		WebhookHandler:        webhookHandler,
+		ActivityHandler:       activityHandler,
+		HealthHandler:         healthHandler,
+		AuditHandler:          auditHandler,
+		LedgerHandler:         ledgerHandler,
+		ReconciliationHandler: reconciliationHandler,
 	}
 
 	router := rest.NewRouter(&handlers)
 
-	// Start Kafka Consumers
-	go func() {
-		depositConsumer := kafka.NewConsumerImpl(cfg.KafkaConfig.Brokers, "account-deposit", "cashflow-group", transactionRepo, log)
-		if err := depositConsumer.Run(ctx); err != nil {
-			log.WithError(err).Fatal("Deposit consumer failed")
-		}
-	}()
-	go func() {
-		withdrawConsumer := kafka.NewConsumerImpl(cfg.KafkaConfig.Brokers, "account-withdraw", "cashflow-group", transactionRepo, log)
-		if err := withdrawConsumer.Run(ctx); err != nil {
-			log.WithError(err).Fatal("Withdraw consumer failed")
-		}
-	}()
-	go func() {
-		transferConsumer := kafka.NewConsumerImpl(cfg.KafkaConfig.Brokers, "account-transfer", "cashflow-group", transactionRepo, log)
-		if err := transferConsumer.Run(ctx); err != nil {
-			log.WithError(err).Fatal("Transfer consumer failed")
-		}
-	}()
+	lc := lifecycle.New(cfg.ShutdownConfig.Timeout, log)
+
+	// Start Consumer
+	eventHandlers := bus.NewRegistry(codecs, log)
+	usecase.NewTransactionConsumer(transactionApplier, log).Register(eventHandlers)
+	log.WithField("topics", bus.Topics(subscriptions)).WithField("event_types", eventHandlers.EventTypes()).Info("Consumer configured")
+	lc.Go("transaction-consumer", func(ctx context.Context) error {
+		return messageBus.Consume(ctx, subscriptions, eventHandlers.Handle)
+	})
+	lc.Go("result-listener", resultListener.Run)
+	lc.Go("activity-listener", activityListener.Run)
+
+	healthService.AddCheck("postgres", db.Ping)
+	if kafkaBus, ok := messageBus.(*kafka.Bus); ok {
+		healthService.AddCheck("kafka", kafka.BrokerCheck(cfg.KafkaConfig.Brokers))
+		healthService.AddCheck("consumer_group", kafka.GroupMembershipCheck(cfg.KafkaConfig.Brokers, groupID, kafkaBus.ClientIDs))
+	}
+
+	// Webhook Dispatcher
+	dispatcher := webhook.NewDispatcher(webhookRepo, nil, cfg.WebhookConfig, log)
+	lc.Go("webhook-dispatcher", dispatcher.Run)
+
+	// Ledger Checkpoints
+	if ledgerSigner != nil {
+		checkpointer := ledger.NewCheckpointer(ledgerService, authenticator.Tenants(), cfg.LedgerConfig.CheckpointInterval, log)
+		lc.Go("ledger-checkpointer", checkpointer.Run)
+	}
+
+	// Balance Reconciliation
+	if cfg.LedgerConfig.ReconcileInterval > 0 {
+		reconciler := ledger.NewReconciler(reconciliationService, authenticator.Tenants(), cfg.LedgerConfig.ReconcileInterval, log)
+		lc.Go("ledger-reconciler", reconciler.Run)
+	}
 
 	// HTTP Server
 	server := &http.Server{
@@ -102,28 +226,98 @@ func main() {
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	server.RegisterOnShutdown(activityService.CloseSubscribers)
 
-	// Graceful Shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	// Metrics Server
+	metricsServer := &http.Server{
+		Addr:              cfg.MetricsConfig.Addr,
+		Handler:           rest.NewMetricsRouter(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
 
-	go func() {
+	// gRPC Server
+	grpcServer := grpcserver.NewServer(grpcserver.Deps{
+		AccountService:     accountService,
+		TransactionService: transactionService,
+		Authenticator:      authenticator,
+		Logger:             log,
+	})
+
+	lc.Go("http-server", func(context.Context) error {
 		log.Info("Server starting on :8080")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.WithError(err).Fatal("Server failed to start")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
 		}
-	}()
+		return nil
+	})
 
-	<-stop
-	log.Info("Shutting down server...")
+	lc.Go("metrics-server", func(context.Context) error {
+		log.Infof("Metrics server starting on %s", cfg.MetricsConfig.Addr)
+		if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
 
-	ctxShutdown, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancelShutdown()
-	if err := server.Shutdown(ctxShutdown); err != nil {
+	lc.Go("grpc-server", func(context.Context) error {
+		lis, err := net.Listen("tcp", cfg.GRPCConfig.Addr)
+		if err != nil {
+			return err
+		}
+		log.Infof("gRPC server starting on %s", cfg.GRPCConfig.Addr)
+		return grpcServer.Serve(lis)
+	})
+
+	// Graceful Shutdown: fail readiness and wait for load balancers to
+	// notice, stop taking requests, drain the consumers, then release what
+	// they and the handlers were using.
+	lc.OnStop("readiness", func(context.Context) error {
+		healthService.SetShuttingDown()
+		return nil
+	})
+	lc.OnStop("drain-delay", func(ctx context.Context) error {
+		select {
+		case <-time.After(cfg.ShutdownConfig.DrainDelay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	lc.OnStop("http-server", server.Shutdown)
+	lc.OnStop("grpc-server", func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+	lc.OnStop("workers", lc.StopWorkers)
+	lc.OnStop("message-bus", func(context.Context) error {
+		return messageBus.Close()
+	})
+	// Metrics stay scrapeable until the consumers have drained.
+	lc.OnStop("metrics-server", metricsServer.Shutdown)
+	lc.OnStop("tracing", shutdownTracing)
+	lc.OnStop("database", func(context.Context) error {
+		db.Close()
+		return nil
+	})
+
+	waitErr := lc.Wait()
+	log.Info("Shutting down server...")
+	if err := lc.Shutdown(); err != nil {
 		log.WithError(err).Error("Server forced to shutdown")
-	} else {
-		log.Info("Server exited gracefully")
+		os.Exit(1)
+	}
+	if waitErr != nil {
+		os.Exit(1)
 	}
-
 	log.Info("Server stopped")
 }
@@ -0,0 +1,60 @@
+// Command webhook-sink is a local stand-in for a webhook subscriber. It logs
+// every delivery, verifies its signature and answers with a configurable status
+// so retries can be exercised:
+//
+//	WEBHOOK_SECRET=<secret> SINK_STATUS=500 go run ./cmd/webhook-sink
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/serikdev/CashFlow/internal/webhook"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	log := logger.NewLogger()
+
+	addr := getEnv("SINK_ADDR", ":9090")
+	secret := os.Getenv("WEBHOOK_SECRET")
+	status, err := strconv.Atoi(getEnv("SINK_STATUS", "200"))
+	if err != nil {
+		log.WithError(err).Fatal("Invalid SINK_STATUS")
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		signature := r.Header.Get(webhook.HeaderSignature)
+
+		log.WithFields(logrus.Fields{
+			"event":     r.Header.Get(webhook.HeaderEvent),
+			"delivery":  r.Header.Get(webhook.HeaderDelivery),
+			"signed_ok": secret == "" || webhook.Verify(secret, timestamp, body, signature),
+			"body":      string(body),
+		}).Info("Webhook received")
+
+		w.WriteHeader(status)
+	})
+
+	log.Infof("Webhook sink listening on %s", addr)
+	if err := http.ListenAndServe(addr, nil); err != nil {
+		log.WithError(err).Fatal("Webhook sink failed")
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
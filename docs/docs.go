@@ -462,14 +462,50 @@ const docTemplate = `{
         "handler.ErrorResponse": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer"
+                "detail": {
+                    "type": "string"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "VALIDATION_FAILED"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validator.FieldError"
+                    }
                 },
-                "error": {
+                "instance": {
                     "type": "string"
                 },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Bad Request"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/validation-failed"
+                }
+            }
+        },
+        "validator.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "amount"
+                },
                 "message": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "must be greater than 0"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "gt"
                 }
             }
         }
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type AccessRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewAccessRepository(db *pgxpool.Pool, logger *logrus.Entry) *AccessRepo {
+	return &AccessRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	getRelationQuery = `
+		SELECT relation
+		FROM account_access
+		WHERE tenant_id = $1 AND account_id = $2 AND subject = $3
+	`
+	// grantQuery updates an owner row only when $5 is set, so that a
+	// concurrent owner grant is not overwritten: no row is returned then.
+	grantQuery = `
+		INSERT INTO account_access(tenant_id, account_id, subject, relation, created_at)
+		VALUES($1, $2, $3, $4, NOW())
+		ON CONFLICT (account_id, subject) DO UPDATE SET relation = EXCLUDED.relation
+		WHERE account_access.relation <> 'owner' OR $5::boolean
+		RETURNING tenant_id, account_id, subject, relation, created_at
+	`
+)
+
+// GetRelation returns an empty relation when the subject has no access to the account.
+func (r *AccessRepo) GetRelation(ctx context.Context, accountID int64, subject string) (entity.AccountRelation, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var relation entity.AccountRelation
+	err = r.db.QueryRow(ctx, getRelationQuery, tenantID, accountID, subject).Scan(&relation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		r.logger.WithFields(logrus.Fields{
+			"account_id": accountID,
+			"subject":    subject,
+			"error":      err,
+		}).Error("Failed to fetch account relation")
+		return "", fmt.Errorf("error to fetch account relation: %w", err)
+	}
+	return relation, nil
+}
+
+func (r *AccessRepo) Grant(ctx context.Context, accountID int64, subject string, relation entity.AccountRelation, replaceOwner bool) (*entity.AccountAccess, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var access entity.AccountAccess
+	err = r.db.QueryRow(ctx, grantQuery, tenantID, accountID, subject, relation, replaceOwner).Scan(
+		&access.TenantID,
+		&access.AccountID,
+		&access.Subject,
+		&access.Relation,
+		&access.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errs.Conflict("subject %s is an owner of account %d", subject, accountID)
+	}
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"account_id": accountID,
+			"subject":    subject,
+			"relation":   relation,
+			"error":      err,
+		}).Error("Failed to grant account access")
+		return nil, mapPgError(err, "error to grant account access")
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"account_id": accountID,
+		"subject":    subject,
+		"relation":   relation,
+	}).Info("Account access granted")
+	return &access, nil
+}
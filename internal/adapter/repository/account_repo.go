@@ -7,6 +7,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tenant"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,35 +26,58 @@ func NewAccountRepository(db *pgxpool.Pool, logger *logrus.Entry) *AccountRepo {
 
 const (
 	createQuery = `
-		INSERT INTO accounts(balance, currency, is_locked, created_at, deleted_at)
-		VALUES($1, $2, $3, $4, $5)
-		RETURNING id, balance, currency, is_locked, created_at, deleted_at
+		INSERT INTO accounts(tenant_id, balance, opening_balance, currency, is_locked, created_at, deleted_at)
+		VALUES($1, $2, $2, $3, $4, $5, $6)
+		RETURNING id, tenant_id, balance, currency, is_locked, created_at, deleted_at
 	`
 
 	getByIDQuery = `
-		SELECT id, balance, currency, is_locked, created_at, deleted_at
+		SELECT id, tenant_id, balance, currency, is_locked, created_at, deleted_at
 		FROM accounts
-		WHERE id=$1
+		WHERE id=$1 AND tenant_id=$2
 	`
 
-	deleteQuery = `DELETE FROM accounts WHERE id=$1`
+	deleteQuery = `DELETE FROM accounts WHERE id=$1 AND tenant_id=$2`
+
+	setLockedQuery = `
+		UPDATE accounts SET is_locked = $3
+		WHERE id = $1 AND tenant_id = $2
+		RETURNING id, tenant_id, balance, currency, is_locked, created_at, deleted_at
+	`
 
 	listQuery = `
-		SELECT id, balance, currency, is_locked, created_at, deleted_at
+		SELECT id, tenant_id, balance, currency, is_locked, created_at, deleted_at
 		FROM accounts
+		WHERE tenant_id = $1
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
-	countQuery = `SELECT COUNT(*) FROM accounts`
+	countQuery = `SELECT COUNT(*) FROM accounts WHERE tenant_id = $1`
+
+	listBySubjectQuery = `
+		SELECT a.id, a.tenant_id, a.balance, a.currency, a.is_locked, a.created_at, a.deleted_at
+		FROM accounts a
+		JOIN account_access aa ON aa.account_id = a.id AND aa.tenant_id = a.tenant_id
+		WHERE aa.tenant_id = $1 AND aa.subject = $2
+		ORDER BY a.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	countBySubjectQuery = `SELECT COUNT(*) FROM account_access WHERE tenant_id = $1 AND subject = $2`
 )
 
 func (r *AccountRepo) Create(ctx context.Context, account *entity.Account) (*entity.Account, error) {
 	r.logger.WithField("account_balance", account.Balance).Debug("Creating account")
 
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var createAccount entity.Account
-	err := r.db.QueryRow(
+	err = r.db.QueryRow(
 		ctx,
 		createQuery,
+		tenantID,
 		account.Balance,
 		account.Currency,
 		account.IsLocked,
@@ -60,6 +85,7 @@ func (r *AccountRepo) Create(ctx context.Context, account *entity.Account) (*ent
 		account.DeletedAt,
 	).Scan(
 		&createAccount.ID,
+		&createAccount.TenantID,
 		&createAccount.Balance,
 		&createAccount.Currency,
 		&createAccount.IsLocked,
@@ -69,7 +95,7 @@ func (r *AccountRepo) Create(ctx context.Context, account *entity.Account) (*ent
 
 	if err != nil {
 		r.logger.WithError(err).Error("Failed to create account in DB")
-		return nil, fmt.Errorf("error to create account in DB: %w", err)
+		return nil, mapPgError(err, "error to create account in DB")
 	}
 
 	r.logger.WithField("account_id", createAccount.ID).Info("Successfully created account in DB")
@@ -79,10 +105,16 @@ func (r *AccountRepo) Create(ctx context.Context, account *entity.Account) (*ent
 func (r *AccountRepo) GetByID(ctx context.Context, id int64) (*entity.Account, error) {
 	r.logger.WithField("account_id", id).Debug("Fetching account ID")
 
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var account entity.Account
 
-	err := r.db.QueryRow(ctx, getByIDQuery, id).Scan(
+	err = r.db.QueryRow(ctx, getByIDQuery, id, tenantID).Scan(
 		&account.ID,
+		&account.TenantID,
 		&account.Balance,
 		&account.Currency,
 		&account.IsLocked,
@@ -93,7 +125,7 @@ func (r *AccountRepo) GetByID(ctx context.Context, id int64) (*entity.Account, e
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			r.logger.WithField("account_id", account.ID).Warn("Account not found")
-			return nil, fmt.Errorf("account with %d not found", id)
+			return nil, errs.NotFound("account with %d not found", id)
 		}
 		r.logger.WithFields(logrus.Fields{
 			"account_id": id,
@@ -108,38 +140,79 @@ func (r *AccountRepo) GetByID(ctx context.Context, id int64) (*entity.Account, e
 func (r *AccountRepo) Delete(ctx context.Context, id int64) error {
 	r.logger.WithField("account_id", id).Debug("Removing account")
 
-	cmdTag, err := r.db.Exec(ctx, deleteQuery, id)
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	cmdTag, err := r.db.Exec(ctx, deleteQuery, id, tenantID)
 	if err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"account_id": id,
 			"error":      err,
 		}).Error("Failed to delete account in DB")
-		return fmt.Errorf("error  delete account in DB: %w", err)
+		return mapPgError(err, "error  delete account in DB")
 	}
 
 	if cmdTag.RowsAffected() == 0 {
 		r.logger.WithField("account_id", id).Warn("No rows were deleted")
-		return fmt.Errorf("no rows account found with: %d", id)
+		return errs.NotFound("no rows account found with: %d", id)
 	}
 
 	r.logger.WithField("acount_id", id).Info("Account deleted successfully from database")
 	return nil
 }
 
+func (r *AccountRepo) SetLocked(ctx context.Context, id int64, locked bool) (*entity.Account, error) {
+	r.logger.WithFields(logrus.Fields{
+		"account_id": id,
+		"locked":     locked,
+	}).Debug("Updating account lock")
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var account entity.Account
+	err = r.db.QueryRow(ctx, setLockedQuery, id, tenantID, locked).Scan(
+		&account.ID,
+		&account.TenantID,
+		&account.Balance,
+		&account.Currency,
+		&account.IsLocked,
+		&account.CreatedAt,
+		&account.DeletedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errs.NotFound("account with %d not found", id)
+		}
+		r.logger.WithError(err).WithField("account_id", id).Error("Failed to update account lock in DB")
+		return nil, fmt.Errorf("error to update account lock: %w", err)
+	}
+	return &account, nil
+}
+
 func (r *AccountRepo) List(ctx context.Context, offset, limit int) ([]entity.Account, int, error) {
 	r.logger.WithFields(logrus.Fields{
 		"offset": offset,
 		"limit":  limit,
 	}).Debug("Fetching account list")
 
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var totalCount int
-	err := r.db.QueryRow(ctx, countQuery).Scan(&totalCount)
+	err = r.db.QueryRow(ctx, countQuery, tenantID).Scan(&totalCount)
 	if err != nil {
 		r.logger.WithError(err).Error("Failed to count account")
 		return nil, 0, fmt.Errorf("failed to count account: %w", err)
 	}
 
-	rows, err := r.db.Query(ctx, listQuery, limit, offset)
+	rows, err := r.db.Query(ctx, listQuery, tenantID, limit, offset)
 	if err != nil {
 		r.logger.WithFields(logrus.Fields{
 			"offset": offset,
@@ -148,6 +221,57 @@ func (r *AccountRepo) List(ctx context.Context, offset, limit int) ([]entity.Acc
 		}).Error("Failed to fetch account from database")
 		return nil, 0, fmt.Errorf("failed to fetch account from db: %w", err)
 	}
+
+	accounts, err := r.scanAccounts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.logger.WithFields(logrus.Fields{
+		"offset":      offset,
+		"limit":       limit,
+		"found_count": len(accounts),
+		"total_count": totalCount,
+	}).Info("Account list fetched successfully from database")
+	return accounts, totalCount, nil
+}
+
+// ListBySubject returns only the accounts the subject holds a relation to.
+func (r *AccountRepo) ListBySubject(ctx context.Context, subject string, offset, limit int) ([]entity.Account, int, error) {
+	r.logger.WithFields(logrus.Fields{
+		"subject": subject,
+		"offset":  offset,
+		"limit":   limit,
+	}).Debug("Fetching account list for subject")
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var totalCount int
+	if err := r.db.QueryRow(ctx, countBySubjectQuery, tenantID, subject).Scan(&totalCount); err != nil {
+		r.logger.WithError(err).Error("Failed to count subject accounts")
+		return nil, 0, fmt.Errorf("failed to count subject accounts: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, listBySubjectQuery, tenantID, subject, limit, offset)
+	if err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"subject": subject,
+			"error":   err,
+		}).Error("Failed to fetch subject accounts from database")
+		return nil, 0, fmt.Errorf("failed to fetch subject accounts from db: %w", err)
+	}
+
+	accounts, err := r.scanAccounts(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return accounts, totalCount, nil
+}
+
+func (r *AccountRepo) scanAccounts(rows pgx.Rows) ([]entity.Account, error) {
 	defer rows.Close()
 
 	var accounts []entity.Account
@@ -155,6 +279,7 @@ func (r *AccountRepo) List(ctx context.Context, offset, limit int) ([]entity.Acc
 		var account entity.Account
 		err := rows.Scan(
 			&account.ID,
+			&account.TenantID,
 			&account.Balance,
 			&account.Currency,
 			&account.IsLocked,
@@ -163,21 +288,14 @@ func (r *AccountRepo) List(ctx context.Context, offset, limit int) ([]entity.Acc
 		)
 		if err != nil {
 			r.logger.WithError(err).Error("Failed to scan account row")
-			return nil, 0, fmt.Errorf("failed to scan account row: %w", err)
+			return nil, fmt.Errorf("failed to scan account row: %w", err)
 		}
 		accounts = append(accounts, account)
+	}
 
-		if err = rows.Err(); err != nil {
-			r.logger.WithError(err).Error("Error ocured during rows iteration")
-			return nil, 0, fmt.Errorf("error ocured during rows iteration: %w", err)
-		}
-
-		r.logger.WithFields(logrus.Fields{
-			"offset":      offset,
-			"limit":       limit,
-			"found_count": len(accounts),
-			"total_count": totalCount,
-		}).Info("News post list fetched Successfully from database")
+	if err := rows.Err(); err != nil {
+		r.logger.WithError(err).Error("Error ocured during rows iteration")
+		return nil, fmt.Errorf("error ocured during rows iteration: %w", err)
 	}
-	return accounts, totalCount, nil
+	return accounts, nil
 }
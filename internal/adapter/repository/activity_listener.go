@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// ActivityChannel is the channel applied transactions are announced on,
+// with the transaction as JSON payload.
+const ActivityChannel = "account_activity"
+
+// ActivitySink receives the transactions announced on ActivityChannel.
+type ActivitySink interface {
+	Applied(tx *entity.Transaction)
+	// Missed is called after a reconnect, when announcements may have
+	// been lost.
+	Missed()
+}
+
+// ActivityListener LISTENs on ActivityChannel, so that every instance sees
+// the transactions applied by any consumer or request.
+type ActivityListener struct {
+	db     *pgxpool.Pool
+	sink   ActivitySink
+	logger *logrus.Entry
+}
+
+func NewActivityListener(db *pgxpool.Pool, sink ActivitySink, logger *logrus.Entry) *ActivityListener {
+	return &ActivityListener{
+		db:     db,
+		sink:   sink,
+		logger: logger.WithField("component", "activity-listener"),
+	}
+}
+
+// Run listens until ctx is cancelled, reconnecting after errors.
+func (l *ActivityListener) Run(ctx context.Context) error {
+	return listen(ctx, l.db, ActivityChannel, l.logger, l.sink.Missed, func(payload string) {
+		var tx entity.Transaction
+		if err := json.Unmarshal([]byte(payload), &tx); err != nil {
+			l.logger.WithError(err).Error("Failed to decode transaction activity")
+			return
+		}
+		l.sink.Applied(&tx)
+	})
+}
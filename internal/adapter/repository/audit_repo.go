@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewAuditRepository(db *pgxpool.Pool, logger *logrus.Entry) *AuditRepo {
+	return &AuditRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	appendAuditQuery = `
+		INSERT INTO audit_log(tenant_id, actor_subject, actor_role, action, account_id, transaction_id,
+			before, after, request_id, source_ip)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`
+	listAuditColumns = `
+		id, tenant_id, actor_subject, actor_role, action, account_id, transaction_id,
+		before, after, request_id, source_ip, created_at
+	`
+)
+
+func (r *AuditRepo) Append(ctx context.Context, entry *entity.AuditEntry) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRow(ctx, appendAuditQuery,
+		tenantID,
+		entry.ActorSubject,
+		entry.ActorRole,
+		entry.Action,
+		entry.AccountID,
+		entry.TransactionID,
+		nullJSON(entry.Before),
+		nullJSON(entry.After),
+		entry.RequestID,
+		entry.SourceIP,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		r.logger.WithError(err).WithField("action", entry.Action).Error("Failed to append audit entry")
+		return fmt.Errorf("error to append audit entry: %w", err)
+	}
+	entry.TenantID = tenantID
+	return nil
+}
+
+// List returns the entries of the context tenant matching filter, newest
+// first, together with the total number of matches.
+func (r *AuditRepo) List(ctx context.Context, filter entity.AuditFilter, offset, limit int) ([]entity.AuditEntry, int, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conds := []string{"tenant_id = $1"}
+	args := []interface{}{tenantID}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.ActorSubject != "" {
+		where("actor_subject = $%d", filter.ActorSubject)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.AccountID > 0 {
+		where("account_id = $%d", filter.AccountID)
+	}
+	if filter.TransactionID > 0 {
+		where("transaction_id = $%d", filter.TransactionID)
+	}
+	if filter.RequestID != "" {
+		where("request_id = $%d", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+	whereClause := strings.Join(conds, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM audit_log WHERE "+whereClause, args...).Scan(&total); err != nil {
+		r.logger.WithError(err).Error("Failed to count audit entries")
+		return nil, 0, fmt.Errorf("error to count audit entries: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM audit_log WHERE %s ORDER BY id DESC LIMIT $%d OFFSET $%d",
+		listAuditColumns, whereClause, len(args)+1, len(args)+2)
+	rows, err := r.db.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list audit entries")
+		return nil, 0, fmt.Errorf("error to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []entity.AuditEntry
+	for rows.Next() {
+		var e entity.AuditEntry
+		if err := rows.Scan(
+			&e.ID,
+			&e.TenantID,
+			&e.ActorSubject,
+			&e.ActorRole,
+			&e.Action,
+			&e.AccountID,
+			&e.TransactionID,
+			&e.Before,
+			&e.After,
+			&e.RequestID,
+			&e.SourceIP,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("error to scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+// nullJSON stores an absent snapshot as SQL NULL rather than a JSON null.
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
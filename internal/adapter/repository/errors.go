@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/serikdev/CashFlow/internal/errs"
+)
+
+// Postgres SQLSTATE codes translated into domain errors.
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+// mapPgError wraps constraint violations in the matching domain error kind
+// and leaves every other error as a plain wrapped error.
+func mapPgError(err error, msg string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgForeignKeyViolation, pgUniqueViolation:
+			return errs.Wrap(errs.ErrConflict, err, "%s", msg)
+		case pgCheckViolation:
+			return errs.Wrap(errs.ErrValidation, err, "%s", msg)
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
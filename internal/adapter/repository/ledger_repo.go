@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/ledger"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type LedgerRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewLedgerRepository(db *pgxpool.Pool, logger *logrus.Entry) *LedgerRepo {
+	return &LedgerRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	chainAccountsQuery = `
+		SELECT DISTINCT account_id FROM transactions
+		WHERE tenant_id = $1
+		ORDER BY account_id
+	`
+	chainLinksQuery = `
+		SELECT id, tenant_id, account_id, related_account_id, amount::text, transaction_type, created_at,
+			request_id, trace_id, initiated_by, prev_hash, hash
+		FROM transactions
+		WHERE tenant_id = $1 AND account_id = $2 AND id > $3
+		ORDER BY id
+		LIMIT $4
+	`
+	chainHeadsQuery = `
+		SELECT DISTINCT ON (account_id) account_id, id, hash
+		FROM transactions
+		WHERE tenant_id = $1 AND hash <> ''
+		ORDER BY account_id, id DESC
+	`
+	saveCheckpointQuery = `
+		INSERT INTO ledger_checkpoints(tenant_id, heads, root, key_id, signature, created_at)
+		VALUES($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	listCheckpointsQuery = `
+		SELECT id, tenant_id, heads, root, key_id, signature, created_at
+		FROM ledger_checkpoints
+		WHERE tenant_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+)
+
+// ChainAccounts returns the IDs of the accounts that own transactions.
+func (r *LedgerRepo) ChainAccounts(ctx context.Context) ([]int64, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, chainAccountsQuery, tenantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list chain accounts")
+		return nil, fmt.Errorf("error to list chain accounts: %w", err)
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// ListChain returns up to limit transactions of the account with an ID
+// greater than afterID, in chain order.
+func (r *LedgerRepo) ListChain(ctx context.Context, accountID, afterID int64, limit int) ([]ledger.Link, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, chainLinksQuery, tenantID, accountID, afterID, limit)
+	if err != nil {
+		r.logger.WithError(err).WithField("account_id", accountID).Error("Failed to list transaction chain")
+		return nil, fmt.Errorf("error to list transaction chain: %w", err)
+	}
+	defer rows.Close()
+
+	var links []ledger.Link
+	for rows.Next() {
+		var l ledger.Link
+		if err := rows.Scan(
+			&l.ID,
+			&l.TenantID,
+			&l.AccountID,
+			&l.RelatedAccount,
+			&l.Amount,
+			&l.TransactionType,
+			&l.CreatedAt,
+			&l.RequestID,
+			&l.TraceID,
+			&l.InitiatedBy,
+			&l.PrevHash,
+			&l.Hash,
+		); err != nil {
+			return nil, fmt.Errorf("error to scan transaction chain: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// ChainHeads returns the last hashed transaction of every account.
+func (r *LedgerRepo) ChainHeads(ctx context.Context) ([]entity.ChainHead, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, chainHeadsQuery, tenantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list chain heads")
+		return nil, fmt.Errorf("error to list chain heads: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ChainHead, error) {
+		var h entity.ChainHead
+		err := row.Scan(&h.AccountID, &h.TransactionID, &h.Hash)
+		return h, err
+	})
+}
+
+func (r *LedgerRepo) SaveCheckpoint(ctx context.Context, cp *entity.LedgerCheckpoint) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+	cp.TenantID = tenantID
+
+	err = r.db.QueryRow(ctx, saveCheckpointQuery,
+		cp.TenantID,
+		cp.Heads,
+		cp.Root,
+		cp.KeyID,
+		cp.Signature,
+		cp.CreatedAt,
+	).Scan(&cp.ID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to save ledger checkpoint")
+		return fmt.Errorf("error to save ledger checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ListCheckpoints returns the latest checkpoints of the tenant, newest first.
+func (r *LedgerRepo) ListCheckpoints(ctx context.Context, limit int) ([]entity.LedgerCheckpoint, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, listCheckpointsQuery, tenantID, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list ledger checkpoints")
+		return nil, fmt.Errorf("error to list ledger checkpoints: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.LedgerCheckpoint, error) {
+		var cp entity.LedgerCheckpoint
+		err := row.Scan(&cp.ID, &cp.TenantID, &cp.Heads, &cp.Root, &cp.KeyID, &cp.Signature, &cp.CreatedAt)
+		return cp, err
+	})
+}
+
+// LatestCheckpoint returns the newest checkpoint of the tenant.
+func (r *LedgerRepo) LatestCheckpoint(ctx context.Context) (*entity.LedgerCheckpoint, error) {
+	checkpoints, err := r.ListCheckpoints(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, errs.NotFound("no ledger checkpoint")
+	}
+	return &checkpoints[0], nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// listen LISTENs on channel over a dedicated connection and passes every
+// payload to handle until ctx is cancelled, reconnecting after errors.
+// connected runs after each LISTEN, since notifications sent while no
+// connection was listening are missed.
+func listen(ctx context.Context, db *pgxpool.Pool, channel string, logger *logrus.Entry, connected func(), handle func(payload string)) error {
+	logger.Info("Listener started")
+	for {
+		err := listenOnce(ctx, db, channel, connected, handle)
+		if ctx.Err() != nil {
+			logger.Info("Listener stopped")
+			return nil
+		}
+		logger.WithError(err).Error("Listener disconnected, will retry...")
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Listener stopped")
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func listenOnce(ctx context.Context, db *pgxpool.Pool, channel string, connected func(), handle func(payload string)) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The connection goes back to the pool, so it must stop listening.
+		_, _ = conn.Exec(context.Background(), "UNLISTEN *")
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		n, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(n.Payload)
+	}
+}
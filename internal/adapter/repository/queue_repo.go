@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/sirupsen/logrus"
+)
+
+// QueueRepo stores the messages of the Postgres message bus.
+type QueueRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewQueueRepository(db *pgxpool.Pool, logger *logrus.Entry) *QueueRepo {
+	return &QueueRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	enqueueMessageQuery = `
+		INSERT INTO bus_messages(topic, key, value, headers, ordering_keys)
+		VALUES($1, $2, $3, $4, $5)
+	`
+	// claimMessagesQuery leases the messages none of whose ordering keys
+	// belongs to an earlier message of any topic, so the messages of a key
+	// are processed one at a time and in order even by concurrent consumers.
+	claimMessagesQuery = `
+		UPDATE bus_messages
+		SET locked_until = NOW() + $3::interval, attempts = attempts + 1
+		WHERE id IN (
+			SELECT m.id FROM bus_messages m
+			WHERE m.topic = $1 AND m.locked_until <= NOW()
+				AND NOT EXISTS (
+					SELECT 1 FROM bus_messages p
+					WHERE p.ordering_keys && m.ordering_keys AND p.id < m.id
+				)
+			ORDER BY m.id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, key, value, headers, created_at
+	`
+	ackMessageQuery = `
+		DELETE FROM bus_messages WHERE id = $1
+	`
+)
+
+func (r *QueueRepo) Enqueue(ctx context.Context, m bus.Message) error {
+	if _, err := r.db.Exec(ctx, enqueueMessageQuery, m.Topic, m.Key, m.Value, m.Headers, bus.OrderingKeys(m)); err != nil {
+		r.logger.WithError(err).WithField("topic", m.Topic).Error("Failed to enqueue message")
+		return fmt.Errorf("error to enqueue message: %w", err)
+	}
+	return nil
+}
+
+func (r *QueueRepo) Claim(ctx context.Context, topic string, limit int, lease time.Duration) ([]bus.Message, error) {
+	rows, err := r.db.Query(ctx, claimMessagesQuery, topic, limit, lease.String())
+	if err != nil {
+		return nil, fmt.Errorf("error to claim messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []bus.Message
+	for rows.Next() {
+		var m bus.Message
+		if err := rows.Scan(&m.Offset, &m.Topic, &m.Key, &m.Value, &m.Headers, &m.Time); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Offset < messages[j].Offset })
+	return messages, nil
+}
+
+func (r *QueueRepo) Ack(ctx context.Context, offset int64) error {
+	if _, err := r.db.Exec(ctx, ackMessageQuery, offset); err != nil {
+		return fmt.Errorf("error to acknowledge message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type ReconciliationRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewReconciliationRepository(db *pgxpool.Pool, logger *logrus.Entry) *ReconciliationRepo {
+	return &ReconciliationRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	// mismatchesQuery recomputes balances in a single statement so that every
+	// account is read from one snapshot. Transfers count against the source
+	// account and, through related_account_id, for the target account.
+	mismatchesQuery = `
+		WITH balances AS (
+			SELECT a.id, a.currency, a.balance, a.opening_balance,
+				COALESCE(SUM(t.amount) FILTER (WHERE t.account_id = a.id AND t.transaction_type = 'deposit'), 0) AS deposits,
+				COALESCE(SUM(t.amount) FILTER (WHERE t.account_id = a.id AND t.transaction_type = 'withdrawal'), 0) AS withdrawals,
+				COALESCE(SUM(t.amount) FILTER (WHERE t.account_id = a.id AND t.transaction_type = 'transfer'), 0) AS transfers_out,
+				COALESCE(SUM(t.amount) FILTER (WHERE t.related_account_id = a.id AND t.transaction_type = 'transfer'), 0) AS transfers_in,
+				COALESCE(SUM(t.amount) FILTER (WHERE t.account_id = a.id AND t.transaction_type = 'correction'), 0) AS corrections,
+				COUNT(t.id) AS transactions
+			FROM accounts a
+			LEFT JOIN transactions t ON t.tenant_id = a.tenant_id
+				AND (t.account_id = a.id OR t.related_account_id = a.id)
+				AND t.deleted_at IS NULL
+			WHERE a.tenant_id = $1 AND a.deleted_at IS NULL AND ($2::bigint = 0 OR a.id = $2)
+			GROUP BY a.id
+		), expected AS (
+			SELECT *, opening_balance + deposits - withdrawals - transfers_out + transfers_in + corrections AS expected_balance
+			FROM balances
+		)
+		SELECT id, currency, balance, expected_balance, balance - expected_balance,
+			opening_balance, deposits, withdrawals, transfers_out, transfers_in, corrections, transactions
+		FROM expected
+		WHERE balance <> expected_balance
+		ORDER BY id
+	`
+	countReconciledQuery = `
+		SELECT COUNT(*) FROM accounts
+		WHERE tenant_id = $1 AND deleted_at IS NULL AND ($2::bigint = 0 OR id = $2)
+	`
+
+	correctionColumns = `
+		id, tenant_id, account_id, amount, balance, expected_balance, status, requested_by,
+		decided_by, decided_at, transaction_id, created_at
+	`
+	openCorrectionQuery = `
+		INSERT INTO balance_corrections(tenant_id, account_id, amount, balance, expected_balance, requested_by)
+		VALUES($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, account_id) WHERE status = 'pending' DO NOTHING
+		RETURNING ` + correctionColumns
+	pendingCorrectionQuery = `
+		SELECT ` + correctionColumns + `
+		FROM balance_corrections
+		WHERE tenant_id = $1 AND account_id = $2 AND status = 'pending'
+	`
+	getCorrectionQuery = `
+		SELECT ` + correctionColumns + `
+		FROM balance_corrections
+		WHERE id = $1 AND tenant_id = $2
+	`
+	lockCorrectionQuery  = getCorrectionQuery + ` FOR UPDATE`
+	listCorrectionsQuery = `
+		SELECT ` + correctionColumns + `
+		FROM balance_corrections
+		WHERE tenant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+	decideCorrectionQuery = `
+		UPDATE balance_corrections
+		SET status = $2, decided_by = $3, decided_at = NOW(), transaction_id = $4
+		WHERE id = $1
+		RETURNING ` + correctionColumns
+)
+
+// ListMismatches recomputes the balance of one account, or of every account
+// when accountID is zero. It returns the number of accounts checked and
+// those whose stored balance differs.
+func (r *ReconciliationRepo) ListMismatches(ctx context.Context, accountID int64) (int, []entity.BalanceMismatch, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var checked int
+	if err := r.db.QueryRow(ctx, countReconciledQuery, tenantID, accountID).Scan(&checked); err != nil {
+		r.logger.WithError(err).Error("Failed to count accounts for reconciliation")
+		return 0, nil, fmt.Errorf("error to count accounts: %w", err)
+	}
+
+	mismatches, err := queryMismatches(ctx, r.db, tenantID, accountID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to reconcile balances")
+		return 0, nil, err
+	}
+	return checked, mismatches, nil
+}
+
+func queryMismatches(ctx context.Context, q interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}, tenantID string, accountID int64) ([]entity.BalanceMismatch, error) {
+	rows, err := q.Query(ctx, mismatchesQuery, tenantID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("error to reconcile balances: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.BalanceMismatch, error) {
+		var m entity.BalanceMismatch
+		err := row.Scan(
+			&m.AccountID,
+			&m.Currency,
+			&m.Balance,
+			&m.Expected,
+			&m.Difference,
+			&m.OpeningBalance,
+			&m.Deposits,
+			&m.Withdrawals,
+			&m.TransfersOut,
+			&m.TransfersIn,
+			&m.Corrections,
+			&m.Transactions,
+		)
+		return m, err
+	})
+}
+
+// OpenCorrection records a pending correction unless the account already has
+// one, in which case the existing correction is returned with created false.
+func (r *ReconciliationRepo) OpenCorrection(ctx context.Context, c *entity.BalanceCorrection) (*entity.BalanceCorrection, bool, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created, err := scanCorrection(r.db.QueryRow(ctx, openCorrectionQuery,
+		tenantID, c.AccountID, c.Amount, c.Balance, c.ExpectedBalance, c.RequestedBy))
+	if err == nil {
+		return created, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		r.logger.WithError(err).WithField("account_id", c.AccountID).Error("Failed to open balance correction")
+		return nil, false, fmt.Errorf("error to open balance correction: %w", err)
+	}
+
+	existing, err := scanCorrection(r.db.QueryRow(ctx, pendingCorrectionQuery, tenantID, c.AccountID))
+	if err != nil {
+		return nil, false, fmt.Errorf("error to load pending correction: %w", err)
+	}
+	return existing, false, nil
+}
+
+func (r *ReconciliationRepo) GetCorrection(ctx context.Context, id int64) (*entity.BalanceCorrection, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := scanCorrection(r.db.QueryRow(ctx, getCorrectionQuery, id, tenantID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errs.NotFound("correction %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error to fetch correction: %w", err)
+	}
+	return c, nil
+}
+
+func (r *ReconciliationRepo) ListCorrections(ctx context.Context, status string, limit int) ([]entity.BalanceCorrection, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, listCorrectionsQuery, tenantID, status, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list balance corrections")
+		return nil, fmt.Errorf("error to list balance corrections: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.BalanceCorrection, error) {
+		c, err := scanCorrection(row)
+		if err != nil {
+			return entity.BalanceCorrection{}, err
+		}
+		return *c, nil
+	})
+}
+
+// DecideCorrection approves or rejects a pending correction. Approval logs
+// the correction amount as a chained 'correction' transaction, provided the
+// account's mismatch is still exactly that amount.
+func (r *ReconciliationRepo) DecideCorrection(ctx context.Context, id int64, approve bool, txn *entity.Transaction) (*entity.BalanceCorrection, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	c, err := scanCorrection(tx.QueryRow(ctx, lockCorrectionQuery, id, tenantID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errs.NotFound("correction %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error to lock correction: %w", err)
+	}
+	if c.Status != entity.CorrectionPending {
+		return nil, errs.Conflict("correction %d is already %s", id, c.Status)
+	}
+
+	status := entity.CorrectionRejected
+	var transactionID *int64
+	if approve {
+		mismatches, err := queryMismatches(ctx, tx, tenantID, c.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if len(mismatches) != 1 || mismatches[0].Difference != c.Amount {
+			return nil, errs.Conflict("account %d changed since correction %d was opened, reconcile again", c.AccountID, id)
+		}
+
+		txn.TenantID = tenantID
+		txn.AccountID = int(c.AccountID)
+		txn.Amount = c.Amount
+		txn.TransactionType = entity.TransactionTypeCorrection
+		if err := appendTransaction(ctx, tx, txn); err != nil {
+			return nil, err
+		}
+		txID := int64(txn.ID)
+		transactionID = &txID
+		status = entity.CorrectionApproved
+	}
+
+	decided, err := scanCorrection(tx.QueryRow(ctx, decideCorrectionQuery, id, status, txn.InitiatedBy, transactionID))
+	if err != nil {
+		return nil, fmt.Errorf("error to update correction: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit correction failed: %w", err)
+	}
+	return decided, nil
+}
+
+func scanCorrection(row pgx.Row) (*entity.BalanceCorrection, error) {
+	var c entity.BalanceCorrection
+	err := row.Scan(
+		&c.ID,
+		&c.TenantID,
+		&c.AccountID,
+		&c.Amount,
+		&c.Balance,
+		&c.ExpectedBalance,
+		&c.Status,
+		&c.RequestedBy,
+		&c.DecidedBy,
+		&c.DecidedAt,
+		&c.TransactionID,
+		&c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplayRepo reads the live tables a replay starts from and compares with,
+// and writes the replayed state into its own schema.
+type ReplayRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewReplayRepository(db *pgxpool.Pool, logger *logrus.Entry) *ReplayRepo {
+	return &ReplayRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	// replayBaselineQuery includes deleted accounts, so that events before
+	// the deletion still apply. Corrections count towards the baseline like
+	// any other logged transaction.
+	replayBaselineQuery = `
+		SELECT a.id, a.currency, a.balance, a.created_at, a.deleted_at,
+			a.opening_balance + COALESCE(SUM(CASE
+				WHEN t.transaction_type IN ('deposit', 'correction') THEN t.amount
+				WHEN t.transaction_type = 'withdrawal' THEN -t.amount
+				WHEN t.transaction_type = 'transfer' AND t.account_id = a.id THEN -t.amount
+				WHEN t.transaction_type = 'transfer' THEN t.amount
+			END), 0)
+		FROM accounts a
+		LEFT JOIN transactions t ON t.tenant_id = a.tenant_id
+			AND (t.account_id = a.id OR t.related_account_id = a.id)
+			AND t.deleted_at IS NULL
+			AND $2::timestamptz IS NOT NULL AND t.created_at < $2
+		WHERE a.tenant_id = $1
+		GROUP BY a.id
+		ORDER BY a.id
+	`
+	replayLiveTransactionsQuery = `
+		SELECT id, account_id, related_account_id, amount, transaction_type, created_at, request_id, initiated_by
+		FROM transactions
+		WHERE tenant_id = $1 AND deleted_at IS NULL AND transaction_type <> 'correction'
+			AND ($2::timestamptz IS NULL OR created_at >= $2) AND created_at <= $3
+		ORDER BY id
+	`
+	replaySchemaDDL = `
+		CREATE SCHEMA %[1]s;
+		CREATE TABLE %[1]s.accounts (
+			tenant_id VARCHAR(64) NOT NULL,
+			id INTEGER NOT NULL,
+			currency VARCHAR(3) NOT NULL,
+			baseline NUMERIC(15, 2) NOT NULL,
+			balance NUMERIC(15, 2) NOT NULL,
+			PRIMARY KEY (tenant_id, id)
+		);
+		CREATE TABLE %[1]s.transactions (
+			id BIGINT PRIMARY KEY,
+			tenant_id VARCHAR(64) NOT NULL,
+			account_id INTEGER NOT NULL,
+			related_account_id INTEGER NULL,
+			amount NUMERIC(15, 2) NOT NULL,
+			transaction_type VARCHAR(20) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			request_id TEXT NOT NULL,
+			initiated_by TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			kafka_partition INTEGER NOT NULL,
+			kafka_offset BIGINT NOT NULL
+		);
+		CREATE INDEX ON %[1]s.transactions(tenant_id, account_id);
+		CREATE TABLE %[1]s.rejected_events (
+			topic TEXT NOT NULL,
+			kafka_partition INTEGER NOT NULL,
+			kafka_offset BIGINT NOT NULL,
+			tenant_id VARCHAR(64) NOT NULL,
+			account_id INTEGER NOT NULL,
+			transaction_type VARCHAR(20) NOT NULL,
+			amount NUMERIC(15, 2) NOT NULL,
+			reason TEXT NOT NULL,
+			PRIMARY KEY (topic, kafka_partition, kafka_offset)
+		);
+	`
+)
+
+// Baseline returns every account of the context tenant with its balance
+// before cutoff. Without a cutoff the baseline is the opening balance.
+func (r *ReplayRepo) Baseline(ctx context.Context, cutoff *time.Time) ([]entity.ReplayAccount, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, replayBaselineQuery, tenantID, cutoff)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to load replay baseline")
+		return nil, fmt.Errorf("error to load replay baseline: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ReplayAccount, error) {
+		a := entity.ReplayAccount{TenantID: tenantID}
+		err := row.Scan(&a.ID, &a.Currency, &a.Balance, &a.CreatedAt, &a.DeletedAt, &a.Baseline)
+		return a, err
+	})
+}
+
+// LiveTransactions returns the logged transactions of the context tenant
+// created in [from, to], excluding corrections, which have no event.
+func (r *ReplayRepo) LiveTransactions(ctx context.Context, from *time.Time, to time.Time) ([]entity.ReplayTransaction, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, replayLiveTransactionsQuery, tenantID, from, to)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to load live transactions")
+		return nil, fmt.Errorf("error to load live transactions: %w", err)
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (entity.ReplayTransaction, error) {
+		t := entity.ReplayTransaction{TenantID: tenantID}
+		var related *int64
+		err := row.Scan(&t.ID, &t.AccountID, &related, &t.Amount, &t.TransactionType, &t.CreatedAt, &t.RequestID, &t.InitiatedBy)
+		t.RelatedAccount = related
+		return t, err
+	})
+}
+
+// WriteProjection creates schema, which must not exist yet, and copies the
+// replayed state into it in a single database transaction.
+func (r *ReplayRepo) WriteProjection(ctx context.Context, schema string, accounts []entity.ReplayAccount, txns []entity.ReplayTransaction, rejected []entity.ReplayRejection) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(replaySchemaDDL, pgx.Identifier{schema}.Sanitize())); err != nil {
+		return fmt.Errorf("error to create replay schema %s: %w", schema, err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{schema, "accounts"},
+		[]string{"tenant_id", "id", "currency", "baseline", "balance"},
+		pgx.CopyFromSlice(len(accounts), func(i int) ([]any, error) {
+			a := accounts[i]
+			return []any{a.TenantID, a.ID, a.Currency, a.Baseline, a.Balance}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("error to copy replayed accounts: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{schema, "transactions"},
+		[]string{"id", "tenant_id", "account_id", "related_account_id", "amount", "transaction_type", "created_at",
+			"request_id", "initiated_by", "topic", "kafka_partition", "kafka_offset"},
+		pgx.CopyFromSlice(len(txns), func(i int) ([]any, error) {
+			t := txns[i]
+			return []any{t.ID, t.TenantID, t.AccountID, t.RelatedAccount, t.Amount, t.TransactionType, t.CreatedAt,
+				t.RequestID, t.InitiatedBy, t.Topic, t.Partition, t.Offset}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("error to copy replayed transactions: %w", err)
+	}
+
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{schema, "rejected_events"},
+		[]string{"topic", "kafka_partition", "kafka_offset", "tenant_id", "account_id", "transaction_type", "amount", "reason"},
+		pgx.CopyFromSlice(len(rejected), func(i int) ([]any, error) {
+			e := rejected[i]
+			return []any{e.Topic, e.Partition, e.Offset, e.TenantID, e.AccountID, e.TransactionType, e.Amount, e.Reason}, nil
+		}))
+	if err != nil {
+		return fmt.Errorf("error to copy rejected events: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit replay projection failed: %w", err)
+	}
+	r.logger.WithFields(logrus.Fields{
+		"schema":       schema,
+		"accounts":     len(accounts),
+		"transactions": len(txns),
+		"rejected":     len(rejected),
+	}).Info("Replay projection written")
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// ResultsChannel is the channel transaction results are announced on, with
+// the event ID as payload.
+const ResultsChannel = "transaction_results"
+
+// ResultListener LISTENs on ResultsChannel over a dedicated connection and
+// wakes the requests waiting for the announced events.
+type ResultListener struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+func NewResultListener(db *pgxpool.Pool, logger *logrus.Entry) *ResultListener {
+	return &ResultListener{
+		db:      db,
+		logger:  logger.WithField("component", "result-listener"),
+		waiters: make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// Subscribe returns a channel that receives when a result for eventID is
+// announced, and a func to stop listening. The channel may also receive
+// after a reconnect, when notifications could have been missed, so the
+// receiver should look the result up rather than assume it exists.
+func (l *ResultListener) Subscribe(eventID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	l.mu.Lock()
+	if l.waiters[eventID] == nil {
+		l.waiters[eventID] = make(map[chan struct{}]struct{})
+	}
+	l.waiters[eventID][ch] = struct{}{}
+	l.mu.Unlock()
+
+	return ch, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.waiters[eventID], ch)
+		if len(l.waiters[eventID]) == 0 {
+			delete(l.waiters, eventID)
+		}
+	}
+}
+
+// Run listens until ctx is cancelled, reconnecting after errors. Results
+// announced while no connection was listening are missed, so every waiter
+// is woken after each reconnect to look its result up.
+func (l *ResultListener) Run(ctx context.Context) error {
+	return listen(ctx, l.db, ResultsChannel, l.logger, l.wakeAll, l.wake)
+}
+
+func (l *ResultListener) wake(eventID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ch := range l.waiters[eventID] {
+		notify(ch)
+	}
+}
+
+func (l *ResultListener) wakeAll() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, waiters := range l.waiters {
+		for ch := range waiters {
+			notify(ch)
+		}
+	}
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -2,11 +2,17 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/ledger"
+	"github.com/serikdev/CashFlow/internal/tenant"
 	"github.com/sirupsen/logrus"
 )
 
@@ -26,140 +32,462 @@ const (
 	queryWithdraw = `
 		UPDATE accounts 
 		SET balance = balance - $1
-		WHERE id = $2 AND deleted_at IS NULL AND is_locked = FALSE AND balance >= $1
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL AND is_locked = FALSE AND balance >= $1
 	`
 	queryDeposit = `
 		UPDATE accounts 
 		SET balance = balance + $1
-		WHERE id = $2 AND deleted_at IS NULL AND is_locked = FALSE
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL AND is_locked = FALSE
+	`
+	// queryLockAccounts locks the accounts of a transfer in ID order, so
+	// that opposite transfers between two accounts cannot deadlock.
+	queryLockAccounts = `
+		SELECT id FROM accounts
+		WHERE tenant_id = $1 AND id = ANY($2)
+		ORDER BY id
+		FOR UPDATE
+	`
+	queryAccountState = `
+		SELECT balance, is_locked, deleted_at
+		FROM accounts
+		WHERE id = $1 AND tenant_id = $2
 	`
 	querySave = `
-		INSERT INTO transactions (account_id, amount, transaction_type, created_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
+		INSERT INTO transactions (tenant_id, account_id, related_account_id, amount, transaction_type, created_at, request_id, trace_id, initiated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, amount::text, created_at
 	`
-	queryList = `
-		SELECT id, account_id, amount, transaction_type, created_at, deleted_at
+	// queryLockChain serializes appends to one account's hash chain.
+	queryLockChain = `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`
+	queryChainHead = `
+		SELECT hash FROM transactions
+		WHERE tenant_id = $1 AND account_id = $2 AND hash <> ''
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	querySeal = `UPDATE transactions SET prev_hash = $2, hash = $3 WHERE id = $1`
+	// queryNotifyActivity announces a transaction on ActivityChannel when
+	// its database transaction commits.
+	queryNotifyActivity = `SELECT pg_notify('` + ActivityChannel + `', $1)`
+	queryList           = `
+		SELECT id, tenant_id, account_id, related_account_id, amount, transaction_type, created_at, deleted_at,
+			request_id, trace_id, initiated_by, prev_hash, hash
 		FROM transactions
-		WHERE account_id = $1 AND deleted_at IS NULL
+		WHERE account_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT 100
 	`
+	queryByID = `
+		SELECT id, tenant_id, account_id, related_account_id, amount, transaction_type, created_at, deleted_at,
+			request_id, trace_id, initiated_by, prev_hash, hash
+		FROM transactions
+		WHERE id = $1 AND tenant_id = $2
+	`
+	// queryLockEvent serializes the deliveries of one event, so that a
+	// redelivery sees the result of the first one.
+	queryLockEvent = `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`
+	// querySaveResult records the outcome of an event and announces it on
+	// ResultsChannel; within a transaction the notification is sent on commit.
+	// A result is final: an event that already has one keeps it. It returns
+	// the number of results stored, 0 or 1.
+	querySaveResult = `
+		WITH saved AS (
+			INSERT INTO transaction_results (event_id, tenant_id, account_id, status, transaction_id, error_code, error)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (event_id) DO NOTHING
+			RETURNING event_id
+		)
+		SELECT count(*) FROM (SELECT pg_notify('` + ResultsChannel + `', event_id) FROM saved) notified
+	`
+	queryResult = `
+		SELECT event_id, account_id, status, transaction_id, error_code, error, created_at
+		FROM transaction_results
+		WHERE event_id = $1 AND tenant_id = $2
+	`
+	// queryListAfter includes inbound transfer legs, where the account is the related account.
+	queryListAfter = `
+		SELECT id, tenant_id, account_id, related_account_id, amount, transaction_type, created_at, deleted_at,
+			request_id, trace_id, initiated_by, prev_hash, hash
+		FROM transactions
+		WHERE tenant_id = $1 AND (account_id = $2 OR related_account_id = $2) AND id > $3 AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT $4
+	`
 )
 
-func (r *TransactionRepository) Deposit(accountID int64, amount float64) error {
-	r.logger.WithField("update_deposit", accountID).Debug("Prossesing deposit...")
+// ApplyTransaction moves the balances of a deposit, withdrawal or transfer
+// and records txn, chained to its account's log, in one database
+// transaction. Nothing is written when a balance update is rejected. A txn
+// with an EventID also records its completed result, and is applied at most
+// once: when its event already has a result, nothing is written and that
+// result is returned instead. The result is nil when txn was applied.
+func (r *TransactionRepository) ApplyTransaction(ctx context.Context, txn *entity.Transaction) (*entity.TransactionResult, error) {
+	r.logger.WithFields(logrus.Fields{
+		"account_id":       txn.AccountID,
+		"transaction_type": txn.TransactionType,
+	}).Debug("Prossesing transaction...")
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txn.TenantID = tenantID
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	ct, err := r.db.Exec(ctx, queryDeposit, amount, accountID)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed begin tx failed")
+		return nil, fmt.Errorf("begin tx failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if txn.EventID != "" {
+		stored, err := lockEvent(ctx, tx, txn.EventID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			r.logger.WithField("event_id", txn.EventID).Info("Event already processed, skipping")
+			return stored, nil
+		}
+	}
+
+	accountID := int64(txn.AccountID)
+	switch txn.TransactionType {
+	case entity.TransactionTypeDeposit:
+		err = r.credit(ctx, tx, accountID, tenantID, txn.Amount)
+	case entity.TransactionTypeWithdrawal:
+		err = r.debit(ctx, tx, accountID, tenantID, txn.Amount)
+	case entity.TransactionTypeTransfer:
+		if txn.RelatedAccount == nil {
+			return nil, errs.Validation("transfer has no target account")
+		}
+		if _, err := tx.Exec(ctx, queryLockAccounts, tenantID, []int64{accountID, int64(*txn.RelatedAccount)}); err != nil {
+			return nil, fmt.Errorf("lock transfer accounts failed: %w", err)
+		}
+		if err = r.debit(ctx, tx, accountID, tenantID, txn.Amount); err != nil {
+			err = fmt.Errorf("transfer failed: %w", err)
+		} else if err = r.credit(ctx, tx, int64(*txn.RelatedAccount), tenantID, txn.Amount); err != nil {
+			err = fmt.Errorf("transfer failed: target %w", err)
+		}
+	default:
+		return nil, errs.Validation("unknown transaction type %s", txn.TransactionType)
+	}
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to apply transaction: account rejected update")
+		return nil, err
+	}
+
+	if err := appendTransaction(ctx, tx, txn); err != nil {
+		r.logger.WithError(err).Error("Failed save transaction")
+		return nil, err
+	}
+	if txn.EventID != "" {
+		id := txn.ID
+		err := saveResult(ctx, tx, &entity.TransactionResult{
+			EventID:   txn.EventID,
+			AccountID: accountID,
+			Status:    entity.ResultCompleted,
+		}, tenantID, &id)
+		if err != nil {
+			// Rolled back, so the balances move only with a stored result.
+			r.logger.WithError(err).Error("Failed save transaction result")
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.WithError(err).Error("Failed to commit transaction")
+		return nil, fmt.Errorf("commit transaction failed: %w", err)
+	}
+	r.logger.WithField("transaction_id", txn.ID).Info("Successfully applied transaction")
+	return nil, nil
+}
+
+func (r *TransactionRepository) credit(ctx context.Context, tx pgx.Tx, accountID int64, tenantID string, amount float64) error {
+	ct, err := tx.Exec(ctx, queryDeposit, amount, accountID, tenantID)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed deposit")
 		return fmt.Errorf("deposit failed: %w", err)
 	}
 	if ct.RowsAffected() == 0 {
-		r.logger.WithError(err).Error("Failed not found account or locked")
-		return fmt.Errorf("account %d not found or locked", accountID)
+		return r.rejectReason(ctx, tx, accountID, tenantID, 0)
 	}
-	r.logger.Info("Successfully deposit")
 	return nil
 }
 
-func (r *TransactionRepository) Withdraw(accountID int64, amount float64) error {
-	r.logger.WithField("update_withdraw", accountID).Debug("Prossesing withdraw...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	ct, err := r.db.Exec(ctx, queryWithdraw, amount, accountID)
+func (r *TransactionRepository) debit(ctx context.Context, tx pgx.Tx, accountID int64, tenantID string, amount float64) error {
+	ct, err := tx.Exec(ctx, queryWithdraw, amount, accountID, tenantID)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed withdraw")
 		return fmt.Errorf("withdraw failed: %w", err)
 	}
 	if ct.RowsAffected() == 0 {
-		r.logger.WithError(err).Error("Failed withdraw: insufficient funds or account locked")
-		return fmt.Errorf("withdraw failed: insufficient funds or account locked")
+		return r.rejectReason(ctx, tx, accountID, tenantID, amount)
 	}
-
-	r.logger.Info("Successfully withdraw")
 	return nil
 }
 
-func (r *TransactionRepository) Transfer(fromAccountID, toAccountID int64, amount float64) error {
-	r.logger.WithField("transfering", fromAccountID).Debug("Prossesing transfer...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// RecordFailure records the failed result of the event res.EventID, in a
+// transaction holding the event lock of ApplyTransaction. When the event
+// already has a result, nothing is written and that result is returned
+// instead; the result is nil when res was recorded.
+func (r *TransactionRepository) RecordFailure(ctx context.Context, res *entity.TransactionResult) (*entity.TransactionResult, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.Status = entity.ResultFailed
 
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed begin tx failed")
-		return fmt.Errorf("begin tx failed: %w", err)
+		return nil, fmt.Errorf("begin tx failed: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	ct, err := tx.Exec(ctx, queryWithdraw, amount, fromAccountID)
-	if err != nil {
-		r.logger.WithError(err).Error("Failed withdraw transfer")
-		return fmt.Errorf("withdraw in transfer failed: %w", err)
+	stored, err := lockEvent(ctx, tx, res.EventID, tenantID)
+	if err != nil || stored != nil {
+		return stored, err
 	}
-	if ct.RowsAffected() == 0 {
-		r.logger.WithError(err).Error("Failed to transfer: insufficient funds or account locked")
-		return fmt.Errorf("transfer failed: insufficient funds or account locked")
+	if err := saveResult(ctx, tx, res, tenantID, nil); err != nil {
+		r.logger.WithError(err).WithField("event_id", res.EventID).Error("Failed save transaction result")
+		return nil, err
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit transaction result failed: %w", err)
+	}
+	return nil, nil
+}
+
+// lockEvent takes the lock serializing the deliveries of an event for the
+// rest of tx and returns the event's result, nil when it has none yet.
+func lockEvent(ctx context.Context, tx pgx.Tx, eventID, tenantID string) (*entity.TransactionResult, error) {
+	if _, err := tx.Exec(ctx, queryLockEvent, tenantID+":"+eventID); err != nil {
+		return nil, fmt.Errorf("lock event failed: %w", err)
+	}
+	stored, err := loadResult(ctx, tx, eventID, tenantID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, nil
+	}
+	return stored, err
+}
 
-	ct, err = tx.Exec(ctx, queryDeposit, amount, toAccountID)
+// GetResult returns the result of the event eventID, with its transaction
+// when it completed.
+func (r *TransactionRepository) GetResult(ctx context.Context, eventID string) (*entity.TransactionResult, error) {
+	tenantID, err := tenant.Require(ctx)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed to deposit in transfer")
-		return fmt.Errorf("deposit in transfer failed: %w", err)
+		return nil, err
 	}
-	if ct.RowsAffected() == 0 {
-		r.logger.WithError(err).Error("Failed to transfer: target account not found or locked")
-		return fmt.Errorf("transfer failed: target account not found or locked")
+	return loadResult(ctx, r.db, eventID, tenantID)
+}
+
+type querier interface {
+	rowQuerier
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func loadResult(ctx context.Context, q querier, eventID, tenantID string) (*entity.TransactionResult, error) {
+	var (
+		res           entity.TransactionResult
+		transactionID *int
+		createdAt     time.Time
+	)
+	err := q.QueryRow(ctx, queryResult, eventID, tenantID).Scan(
+		&res.EventID,
+		&res.AccountID,
+		&res.Status,
+		&transactionID,
+		&res.ErrorCode,
+		&res.Error,
+		&createdAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errs.NotFound("no result for event %s", eventID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get transaction result failed: %w", err)
+	}
+	res.CreatedAt = &createdAt
+	if transactionID == nil {
+		return &res, nil
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		r.logger.WithError(err).Error("Failed to commit transfer")
-		return fmt.Errorf("commit transfer failed: %w", err)
+	rows, err := q.Query(ctx, queryByID, *transactionID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("get transaction failed: %w", err)
+	}
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
 	}
+	if len(transactions) == 0 {
+		return nil, errs.NotFound("transaction %d not found", *transactionID)
+	}
+	res.Transaction = &transactions[0]
+	res.Transaction.EventID = res.EventID
+	return &res, nil
+}
 
-	r.logger.Info("Successfully transfer")
+// saveResult stores res within tx, whose event lock must be held. It fails
+// when the event has a result already, which the lock rules out unless the
+// result was written without it.
+func saveResult(ctx context.Context, tx pgx.Tx, res *entity.TransactionResult, tenantID string, transactionID *int) error {
+	var saved int
+	err := tx.QueryRow(ctx, querySaveResult,
+		res.EventID,
+		tenantID,
+		res.AccountID,
+		res.Status,
+		transactionID,
+		res.ErrorCode,
+		res.Error,
+	).Scan(&saved)
+	if err != nil {
+		return fmt.Errorf("save transaction result failed: %w", err)
+	}
+	if saved == 0 {
+		return fmt.Errorf("save transaction result failed: event %s already has a result", res.EventID)
+	}
 	return nil
 }
 
-func (r *TransactionRepository) SaveTransaction(txn *entity.Transaction) error {
-	r.logger.WithField("Saving transaction...", txn).Debug("Prossesing save transaction...")
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// rejectReason explains why a guarded balance update matched no rows.
+// debit is the amount being withdrawn, zero for credits.
+func (r *TransactionRepository) rejectReason(ctx context.Context, q rowQuerier, accountID int64, tenantID string, debit float64) error {
+	var (
+		balance   float64
+		isLocked  bool
+		deletedAt *time.Time
+	)
+	err := q.QueryRow(ctx, queryAccountState, accountID, tenantID).Scan(&balance, &isLocked, &deletedAt)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return errs.NotFound("account %d not found", accountID)
+	case err != nil:
+		return fmt.Errorf("failed to load account state: %w", err)
+	case deletedAt != nil:
+		return errs.NotFound("account %d is deleted", accountID)
+	case isLocked:
+		return errs.Locked("account %d is locked", accountID)
+	case balance < debit:
+		return errs.InsufficientFunds("account %d has insufficient funds", accountID)
+	}
+	return errs.Conflict("account %d changed concurrently", accountID)
+}
+
+// appendTransaction inserts txn, whose TenantID must be set, chains it to
+// the last hashed transaction of its account and announces it on
+// ActivityChannel, within tx.
+func appendTransaction(ctx context.Context, tx pgx.Tx, txn *entity.Transaction) error {
+	if _, err := tx.Exec(ctx, queryLockChain, fmt.Sprintf("%s:%d", txn.TenantID, txn.AccountID)); err != nil {
+		return fmt.Errorf("lock transaction chain failed: %w", err)
+	}
+
+	var prevHash string
+	err := tx.QueryRow(ctx, queryChainHead, txn.TenantID, txn.AccountID).Scan(&prevHash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("load transaction chain head failed: %w", err)
+	}
 
-	err := r.db.QueryRow(ctx, querySave,
+	var amount string
+	err = tx.QueryRow(ctx, querySave,
+		txn.TenantID,
 		txn.AccountID,
+		txn.RelatedAccount,
 		txn.Amount,
 		txn.TransactionType,
 		txn.CreatedAt,
-	).Scan(&txn.ID)
-
+		txn.RequestID,
+		txn.TraceID,
+		txn.InitiatedBy,
+	).Scan(&txn.ID, &amount, &txn.CreatedAt)
 	if err != nil {
-		r.logger.WithError(err).Error("Failed save transaction")
-		return fmt.Errorf("save transaction failed: %w", err)
+		return mapPgError(err, "save transaction failed")
+	}
+
+	// The hash covers the values as stored, so it is computed after the insert.
+	txn.PrevHash = prevHash
+	txn.Hash = ledger.Hash(prevHash, ledgerRecord(txn, amount))
+	if _, err := tx.Exec(ctx, querySeal, txn.ID, txn.PrevHash, txn.Hash); err != nil {
+		return fmt.Errorf("seal transaction failed: %w", err)
 	}
 
+	payload, err := json.Marshal(txn)
+	if err != nil {
+		return fmt.Errorf("encode transaction activity failed: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryNotifyActivity, string(payload)); err != nil {
+		return fmt.Errorf("notify transaction activity failed: %w", err)
+	}
 	return nil
 }
 
-func (r *TransactionRepository) ListTransactions(accountID int64) ([]entity.Transaction, error) {
+func ledgerRecord(txn *entity.Transaction, amount string) ledger.Record {
+	rec := ledger.Record{
+		TenantID:        txn.TenantID,
+		ID:              int64(txn.ID),
+		AccountID:       int64(txn.AccountID),
+		Amount:          amount,
+		TransactionType: txn.TransactionType,
+		CreatedAt:       txn.CreatedAt,
+		RequestID:       txn.RequestID,
+		TraceID:         txn.TraceID,
+		InitiatedBy:     txn.InitiatedBy,
+	}
+	if txn.RelatedAccount != nil {
+		related := int64(*txn.RelatedAccount)
+		rec.RelatedAccount = &related
+	}
+	return rec
+}
+
+func (r *TransactionRepository) ListTransactions(ctx context.Context, accountID int64) ([]entity.Transaction, error) {
 	r.logger.WithField("Listing transactions...", accountID).Debug("Prossesing list transactions...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	rows, err := r.db.Query(ctx, queryList, accountID)
+	rows, err := r.db.Query(ctx, queryList, accountID, tenantID)
 	if err != nil {
 		r.logger.WithError(err).Error("Failed list transactions")
 		return nil, fmt.Errorf("list transactions failed: %w", err)
 	}
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("Successfully fetched list transactions")
+	return transactions, nil
+}
+
+// ListTransactionsAfter returns up to limit transactions touching the account with an ID greater than afterID, oldest first.
+func (r *TransactionRepository) ListTransactionsAfter(ctx context.Context, accountID, afterID int64, limit int) ([]entity.Transaction, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, queryListAfter, tenantID, accountID, afterID, limit)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed list transactions after id")
+		return nil, fmt.Errorf("list transactions after id failed: %w", err)
+	}
+	return scanTransactions(rows)
+}
+
+func scanTransactions(rows pgx.Rows) ([]entity.Transaction, error) {
 	defer rows.Close()
 
 	var transactions []entity.Transaction
@@ -167,17 +495,22 @@ func (r *TransactionRepository) ListTransactions(accountID int64) ([]entity.Tran
 		var t entity.Transaction
 		if err := rows.Scan(
 			&t.ID,
+			&t.TenantID,
 			&t.AccountID,
+			&t.RelatedAccount,
 			&t.Amount,
 			&t.TransactionType,
 			&t.CreatedAt,
 			&t.DeletedAt,
+			&t.RequestID,
+			&t.TraceID,
+			&t.InitiatedBy,
+			&t.PrevHash,
+			&t.Hash,
 		); err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, t)
 	}
-
-	r.logger.Info("Successfully fetched list transactions")
-	return transactions, nil
+	return transactions, rows.Err()
 }
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookRepo struct {
+	db     *pgxpool.Pool
+	logger *logrus.Entry
+}
+
+func NewWebhookRepository(db *pgxpool.Pool, logger *logrus.Entry) *WebhookRepo {
+	return &WebhookRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const (
+	createSubscriptionQuery = `
+		INSERT INTO webhook_subscriptions(tenant_id, url, event_types, secret, active, created_at)
+		VALUES($1, $2, $3, $4, TRUE, NOW())
+		RETURNING id, tenant_id, url, event_types, secret, active, created_at
+	`
+	listSubscriptionsQuery = `
+		SELECT id, tenant_id, url, event_types, active, created_at
+		FROM webhook_subscriptions
+		WHERE tenant_id = $1
+		ORDER BY id
+	`
+	deleteSubscriptionQuery = `DELETE FROM webhook_subscriptions WHERE id = $1 AND tenant_id = $2`
+
+	matchingSubscriptionsQuery = `
+		SELECT id, tenant_id, url, event_types, active, created_at
+		FROM webhook_subscriptions
+		WHERE tenant_id = $1 AND active AND $2 = ANY(event_types)
+	`
+	createDeliveryQuery = `
+		INSERT INTO webhook_deliveries(tenant_id, subscription_id, event_type, payload)
+		VALUES($1, $2, $3, $4)
+	`
+	listDeliveriesQuery = `
+		SELECT id, tenant_id, subscription_id, event_type, payload, status, attempts,
+			response_status, last_error, next_attempt_at, created_at, delivered_at
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND tenant_id = $2
+		ORDER BY created_at DESC
+		LIMIT 100
+	`
+	// claimDeliveriesQuery leases due deliveries so that concurrent dispatchers
+	// do not send the same delivery twice.
+	claimDeliveriesQuery = `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2::interval
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.tenant_id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts,
+			d.response_status, d.last_error, d.next_attempt_at, d.created_at, d.delivered_at,
+			s.url, s.secret
+	`
+	recordAttemptQuery = `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4,
+			next_attempt_at = $5, delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE NULL END
+		WHERE id = $1
+	`
+	redeliverQuery = `
+		UPDATE webhook_deliveries
+		SET status = 'pending', next_attempt_at = NOW()
+		WHERE id = $1 AND tenant_id = $2
+		RETURNING id, tenant_id, subscription_id, event_type, payload, status, attempts,
+			response_status, last_error, next_attempt_at, created_at, delivered_at
+	`
+)
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, sub *entity.WebhookSubscription) (*entity.WebhookSubscription, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var created entity.WebhookSubscription
+	err = r.db.QueryRow(ctx, createSubscriptionQuery, tenantID, sub.URL, sub.EventTypes, sub.Secret).Scan(
+		&created.ID,
+		&created.TenantID,
+		&created.URL,
+		&created.EventTypes,
+		&created.Secret,
+		&created.Active,
+		&created.CreatedAt,
+	)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to create webhook subscription")
+		return nil, fmt.Errorf("error to create webhook subscription: %w", err)
+	}
+
+	r.logger.WithField("subscription_id", created.ID).Info("Webhook subscription created")
+	return &created, nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, listSubscriptionsQuery, tenantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list webhook subscriptions")
+		return nil, fmt.Errorf("error to list webhook subscriptions: %w", err)
+	}
+	return r.scanSubscriptions(rows)
+}
+
+// MatchingSubscriptions returns the active subscriptions of the context tenant for the event type.
+func (r *WebhookRepo) MatchingSubscriptions(ctx context.Context, eventType string) ([]entity.WebhookSubscription, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, matchingSubscriptionsQuery, tenantID, eventType)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to fetch matching webhook subscriptions")
+		return nil, fmt.Errorf("error to fetch matching webhook subscriptions: %w", err)
+	}
+	return r.scanSubscriptions(rows)
+}
+
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, id int64) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	ct, err := r.db.Exec(ctx, deleteSubscriptionQuery, id, tenantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to delete webhook subscription")
+		return fmt.Errorf("error to delete webhook subscription: %w", err)
+	}
+	if ct.RowsAffected() == 0 {
+		return errs.NotFound("webhook subscription %d not found", id)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) CreateDelivery(ctx context.Context, subscriptionID int, eventType string, payload []byte) error {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(ctx, createDeliveryQuery, tenantID, subscriptionID, eventType, payload); err != nil {
+		r.logger.WithError(err).Error("Failed to enqueue webhook delivery")
+		return fmt.Errorf("error to enqueue webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, subscriptionID int64) ([]entity.WebhookDelivery, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, listDeliveriesQuery, subscriptionID, tenantID)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to list webhook deliveries")
+		return nil, fmt.Errorf("error to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []entity.WebhookDelivery
+	for rows.Next() {
+		var d entity.WebhookDelivery
+		if err := rows.Scan(deliveryFields(&d)...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries across all tenants.
+func (r *WebhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	rows, err := r.db.Query(ctx, claimDeliveriesQuery, limit, lease.String())
+	if err != nil {
+		r.logger.WithError(err).Error("Failed to claim webhook deliveries")
+		return nil, fmt.Errorf("error to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []entity.WebhookDelivery
+	for rows.Next() {
+		var d entity.WebhookDelivery
+		if err := rows.Scan(append(deliveryFields(&d), &d.URL, &d.Secret)...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepo) RecordAttempt(ctx context.Context, id int, status string, responseStatus *int, lastError *string, nextAttemptAt time.Time) error {
+	if _, err := r.db.Exec(ctx, recordAttemptQuery, id, status, responseStatus, lastError, nextAttemptAt); err != nil {
+		r.logger.WithError(err).WithField("delivery_id", id).Error("Failed to record webhook attempt")
+		return fmt.Errorf("error to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepo) Redeliver(ctx context.Context, id int64) (*entity.WebhookDelivery, error) {
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var d entity.WebhookDelivery
+	if err := r.db.QueryRow(ctx, redeliverQuery, id, tenantID).Scan(deliveryFields(&d)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NotFound("webhook delivery %d not found", id)
+		}
+		r.logger.WithError(err).WithField("delivery_id", id).Error("Failed to schedule redelivery")
+		return nil, fmt.Errorf("error to schedule redelivery: %w", err)
+	}
+	return &d, nil
+}
+
+func (r *WebhookRepo) scanSubscriptions(rows pgx.Rows) ([]entity.WebhookSubscription, error) {
+	defer rows.Close()
+
+	var subs []entity.WebhookSubscription
+	for rows.Next() {
+		var s entity.WebhookSubscription
+		if err := rows.Scan(&s.ID, &s.TenantID, &s.URL, &s.EventTypes, &s.Active, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+func deliveryFields(d *entity.WebhookDelivery) []any {
+	return []any{
+		&d.ID,
+		&d.TenantID,
+		&d.SubscriptionID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.ResponseStatus,
+		&d.LastError,
+		&d.NextAttemptAt,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/serikdev/CashFlow/internal/tenant"
+)
+
+// APIKeyAuthenticator resolves callers from a static set of API keys.
+type APIKeyAuthenticator struct {
+	keys map[string]Principal
+}
+
+// NewAPIKeyAuthenticator parses keys in the form "key:subject:role[:tenant]",
+// separated by commas. Keys without a tenant belong to tenant.Default.
+func NewAPIKeyAuthenticator(spec string) (*APIKeyAuthenticator, error) {
+	keys := make(map[string]Principal)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid api key entry %q, expected key:subject:role[:tenant]", entry)
+		}
+		tenantID := tenant.Default
+		if len(parts) == 4 && parts[3] != "" {
+			tenantID = parts[3]
+		}
+		role := Role(parts[2])
+		if !role.Valid() {
+			return nil, fmt.Errorf("unknown role %q for subject %s", parts[2], parts[1])
+		}
+		keys[parts[0]] = Principal{Subject: parts[1], Role: role, TenantID: tenantID}
+	}
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(_ context.Context, credential string) (*Principal, error) {
+	for key, p := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(credential)) == 1 {
+			principal := p
+			return &principal, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: invalid api key", ErrUnauthenticated)
+}
+
+// Tenants returns the distinct tenants of the configured keys.
+func (a *APIKeyAuthenticator) Tenants() []string {
+	seen := make(map[string]bool)
+	var tenants []string
+	for _, p := range a.keys {
+		if !seen[p.TenantID] {
+			seen[p.TenantID] = true
+			tenants = append(tenants, p.TenantID)
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
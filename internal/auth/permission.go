@@ -0,0 +1,92 @@
+package auth
+
+type Permission string
+
+const (
+	PermAccountCreate Permission = "account:create"
+	PermAccountRead   Permission = "account:read"
+	PermAccountList   Permission = "account:list"
+	PermAccountDelete Permission = "account:delete"
+	PermAccountShare  Permission = "account:share"
+	PermAccountLock   Permission = "account:lock"
+	// PermAccountGrantOwner allows sharing an account with the owner
+	// relation, which shares the account in turn.
+	PermAccountGrantOwner Permission = "account:grant-owner"
+
+	PermTransactionDeposit  Permission = "transaction:deposit"
+	PermTransactionWithdraw Permission = "transaction:withdraw"
+	PermTransactionTransfer Permission = "transaction:transfer"
+	PermTransactionList     Permission = "transaction:list"
+
+	PermWebhookManage Permission = "webhook:manage"
+
+	PermAuditRead        Permission = "audit:read"
+	PermLedgerCheckpoint Permission = "ledger:checkpoint"
+	PermLedgerReconcile  Permission = "ledger:reconcile"
+	PermCorrectionDecide Permission = "correction:decide"
+)
+
+// rolePermissions is the permission matrix over every handler method.
+var rolePermissions = map[Role]map[Permission]bool{
+	RoleAdmin: {
+		PermAccountCreate:       true,
+		PermAccountRead:         true,
+		PermAccountList:         true,
+		PermAccountDelete:       true,
+		PermAccountShare:        true,
+		PermAccountGrantOwner:   true,
+		PermAccountLock:         true,
+		PermTransactionDeposit:  true,
+		PermTransactionWithdraw: true,
+		PermTransactionTransfer: true,
+		PermTransactionList:     true,
+		PermWebhookManage:       true,
+		PermAuditRead:           true,
+		PermLedgerCheckpoint:    true,
+		PermLedgerReconcile:     true,
+		PermCorrectionDecide:    true,
+	},
+	RoleOperator: {
+		PermAccountCreate:       true,
+		PermAccountRead:         true,
+		PermAccountList:         true,
+		PermAccountLock:         true,
+		PermTransactionDeposit:  true,
+		PermTransactionWithdraw: true,
+		PermTransactionTransfer: true,
+		PermTransactionList:     true,
+		PermWebhookManage:       true,
+	},
+	RoleCustomer: {
+		PermAccountCreate:       true,
+		PermAccountRead:         true,
+		PermAccountList:         true,
+		PermAccountShare:        true,
+		PermTransactionDeposit:  true,
+		PermTransactionWithdraw: true,
+		PermTransactionTransfer: true,
+		PermTransactionList:     true,
+	},
+	RoleAuditor: {
+		PermAccountRead:     true,
+		PermAccountList:     true,
+		PermTransactionList: true,
+		PermAuditRead:       true,
+	},
+}
+
+func (r Role) Can(perm Permission) bool {
+	return rolePermissions[r][perm]
+}
+
+// SeesAllAccounts reports whether the role works across accounts without
+// an explicit account relation. Auditors are limited to read access.
+func (r Role) SeesAllAccounts(write bool) bool {
+	switch r {
+	case RoleAdmin, RoleOperator:
+		return true
+	case RoleAuditor:
+		return !write
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+// Package bus carries the transaction events from the services that publish
+// them to the consumers that apply them. MessageBus hides whether they
+// travel through Kafka, a Postgres queue or memory; every implementation
+// delivers each message at least once and keeps the messages of a key in
+// order.
+package bus
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Bus drivers.
+const (
+	DriverKafka    = "kafka"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+// Message is a message as delivered to a Handler. Partition and Offset
+// locate it in its topic; buses without partitions leave Partition at zero.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       string
+	Value     []byte
+	Headers   map[string]string
+	Time      time.Time
+}
+
+// Handler processes one message. Its error is logged and counted by the
+// handler itself. An error marked by Retryable makes the bus deliver the
+// message again, before any later message of its ordering keys, until the
+// handler succeeds or fails for good; a message left unfinished at shutdown
+// is delivered again after a restart. Any other error finishes the message.
+type Handler func(ctx context.Context, m Message) error
+
+// MessageBus publishes events and runs consumer loops.
+type MessageBus interface {
+	// Publish encodes env in the encoding selected for topic and sends it
+	// with headers carrying the correlation of ctx. Messages with the same
+	// key are delivered in the order they were published.
+	Publish(ctx context.Context, topic, key string, env *event.Envelope) error
+	// Consume delivers the messages of the subscribed topics to handler,
+	// each topic with the concurrency of its subscription, until ctx is
+	// cancelled. Cancelling ctx only stops fetching: the messages in flight
+	// are processed and acknowledged before Consume returns.
+	Consume(ctx context.Context, subs []Subscription, handler Handler) error
+	Close() error
+}
+
+// Encoder encodes the envelopes published on a topic.
+type Encoder interface {
+	Encode(topic string, env *event.Envelope) ([]byte, error)
+}
+
+// Deliver runs handler on m inside a consumer span that continues the trace
+// of the publisher, and records the consume duration. attrs are added to the
+// span.
+func Deliver(ctx context.Context, system string, m Message, handler Handler, attrs ...attribute.KeyValue) error {
+	start := time.Now()
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(m.Headers))
+	ctx, span := tracing.Tracer().Start(ctx, "process "+m.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String(string(semconv.MessagingSystemKey), system),
+			semconv.MessagingDestinationName(m.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(m.Partition)),
+		),
+		trace.WithAttributes(attrs...),
+	)
+	err := handler(ctx, m)
+	tracing.End(span, err)
+	metrics.ObserveConsume(m.Topic, time.Since(start))
+	return err
+}
+
+// StartPublish starts the producer span of a message. Its context must be
+// used to build the message headers so that consumers continue the trace.
+func StartPublish(ctx context.Context, system, topic string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String(string(semconv.MessagingSystemKey), system),
+			semconv.MessagingDestinationName(topic),
+		),
+	)
+}
@@ -0,0 +1,279 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(_ string, env *event.Envelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+func testLogger() *logrus.Entry {
+	l := logrus.New()
+	l.SetLevel(logrus.WarnLevel)
+	return logrus.NewEntry(l)
+}
+
+// drivers returns a fresh bus of every driver that runs in process. The
+// Postgres bus runs on memQueue, which claims like the bus_messages queries.
+func drivers() map[string]func() MessageBus {
+	return map[string]func() MessageBus{
+		DriverMemory: func() MessageBus {
+			return NewMemoryBus(jsonEncoder{}, testLogger())
+		},
+		DriverPostgres: func() MessageBus {
+			return NewPostgresBus(newMemQueue(), jsonEncoder{}, pollConfig, testLogger())
+		},
+	}
+}
+
+var pollConfig = config.BusConfig{PollInterval: time.Millisecond, BatchSize: 4, Lease: 50 * time.Millisecond}
+
+// delivery is a message as seen by a handler.
+type delivery struct {
+	topic   string
+	key     string
+	eventID string
+	headers map[string]string
+}
+
+// recorder is a handler that records its deliveries and fails when two
+// messages sharing an ordering key run at once.
+type recorder struct {
+	t *testing.T
+	// fail holds, by event ID, the errors of the next deliveries of the
+	// event.
+	fail map[string][]error
+
+	mu         sync.Mutex
+	running    map[string]bool
+	deliveries []delivery
+}
+
+func newRecorder(t *testing.T) *recorder {
+	return &recorder{t: t, fail: make(map[string][]error), running: make(map[string]bool)}
+}
+
+func (r *recorder) handle(_ context.Context, m Message) error {
+	var env event.Envelope
+	if err := json.Unmarshal(m.Value, &env); err != nil {
+		r.t.Errorf("message %s/%d: %v", m.Topic, m.Offset, err)
+		return err
+	}
+	keys := OrderingKeys(m)
+
+	r.mu.Lock()
+	for _, k := range keys {
+		if r.running[k] {
+			r.t.Errorf("event %s ran while another message of key %s was running", env.EventID, k)
+		}
+		r.running[k] = true
+	}
+	r.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range keys {
+		delete(r.running, k)
+	}
+	r.deliveries = append(r.deliveries, delivery{topic: m.Topic, key: m.Key, eventID: env.EventID, headers: m.Headers})
+	if errs := r.fail[env.EventID]; len(errs) > 0 {
+		r.fail[env.EventID] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.deliveries)
+}
+
+// consume runs b until the recorder has seen want deliveries, then for
+// settle longer, and returns them.
+func consume(t *testing.T, b MessageBus, subs []Subscription, r *recorder, want int, settle time.Duration) []delivery {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- b.Consume(ctx, subs, r.handle)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.count() < want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(settle)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.deliveries) < want {
+		t.Fatalf("%d messages delivered, want %d", len(r.deliveries), want)
+	}
+	return slices.Clone(r.deliveries)
+}
+
+func publish(t *testing.T, ctx context.Context, b MessageBus, topic, key, eventID string) {
+	t.Helper()
+	if err := b.Publish(ctx, topic, key, &event.Envelope{EventID: eventID}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func TestPublishConsume(t *testing.T) {
+	for driver, newBus := range drivers() {
+		t.Run(driver, func(t *testing.T) {
+			b := newBus()
+			defer b.Close()
+
+			ctx := requestid.WithID(context.Background(), "req-1")
+			publish(t, ctx, b, "deposit", "t:1", "1")
+			publish(t, ctx, b, "withdraw", "t:2", "2")
+			publish(t, ctx, b, "other", "t:3", "3")
+
+			subs := []Subscription{{Topic: "deposit", Workers: 1}, {Topic: "withdraw", Workers: 2}}
+			got := consume(t, b, subs, newRecorder(t), 2, 10*time.Millisecond)
+
+			slices.SortFunc(got, func(a, b delivery) int { return cmpEventID(a.eventID, b.eventID) })
+			want := []delivery{{topic: "deposit", key: "t:1", eventID: "1"}, {topic: "withdraw", key: "t:2", eventID: "2"}}
+			if len(got) != len(want) {
+				t.Fatalf("delivered %+v, want only the subscribed topics %+v", got, want)
+			}
+			for i := range want {
+				if got[i].topic != want[i].topic || got[i].key != want[i].key || got[i].eventID != want[i].eventID {
+					t.Errorf("delivery %d = %+v, want %+v", i, got[i], want[i])
+				}
+				if id := got[i].headers[HeaderRequestID]; id != "req-1" {
+					t.Errorf("delivery %d request ID header = %q, want req-1", i, id)
+				}
+			}
+		})
+	}
+}
+
+// TestKeysAreOrderedAcrossTopics publishes the messages of two accounts on
+// three topics, transfers being ordered by both accounts, and checks that
+// every key's messages ran one at a time in publish order.
+func TestKeysAreOrderedAcrossTopics(t *testing.T) {
+	for driver, newBus := range drivers() {
+		t.Run(driver, func(t *testing.T) {
+			b := newBus()
+			defer b.Close()
+
+			const n = 30
+			topics := []string{"deposit", "withdraw", "transfer"}
+			for i := 1; i <= n; i++ {
+				ctx, topic, key := context.Background(), topics[i%3], "t:1"
+				switch {
+				case topic == "transfer":
+					ctx = WithOrderingKeys(ctx, "t:2")
+				case i%2 == 0:
+					key = "t:2"
+				}
+				publish(t, ctx, b, topic, key, strconv.Itoa(i))
+			}
+
+			var subs []Subscription
+			for _, topic := range topics {
+				subs = append(subs, Subscription{Topic: topic, Workers: 4, QueueSize: 8})
+			}
+			got := consume(t, b, subs, newRecorder(t), n, 0)
+
+			seen := map[string]string{}
+			for _, d := range got {
+				keys := []string{d.key}
+				if d.topic == "transfer" {
+					keys = append(keys, "t:2")
+				}
+				for _, k := range keys {
+					if prev, ok := seen[k]; ok && cmpEventID(prev, d.eventID) > 0 {
+						t.Fatalf("event %s of key %s ran after event %s", d.eventID, k, prev)
+					}
+					seen[k] = d.eventID
+				}
+			}
+		})
+	}
+}
+
+// TestTransientFailuresAreRedelivered checks that a message whose handler
+// failed with a retryable error is delivered again until it is applied,
+// before the later messages of its key.
+func TestTransientFailuresAreRedelivered(t *testing.T) {
+	for driver, newBus := range drivers() {
+		t.Run(driver, func(t *testing.T) {
+			b := newBus()
+			defer b.Close()
+
+			publish(t, context.Background(), b, "deposit", "t:1", "1")
+			publish(t, context.Background(), b, "withdraw", "t:1", "2")
+
+			r := newRecorder(t)
+			transient := Retryable(errors.New("connection reset"))
+			r.fail["1"] = []error{transient, transient}
+			subs := []Subscription{{Topic: "deposit", Workers: 2}, {Topic: "withdraw", Workers: 2}}
+			got := consume(t, b, subs, r, 4, 10*time.Millisecond)
+
+			var ids []string
+			for _, d := range got {
+				ids = append(ids, d.eventID)
+			}
+			if want := []string{"1", "1", "1", "2"}; !slices.Equal(ids, want) {
+				t.Fatalf("delivered events %v, want %v", ids, want)
+			}
+		})
+	}
+}
+
+// TestRejectedMessagesAreFinished checks that a message whose handler failed
+// for good is not delivered again and the next message of its key runs.
+func TestRejectedMessagesAreFinished(t *testing.T) {
+	for driver, newBus := range drivers() {
+		t.Run(driver, func(t *testing.T) {
+			b := newBus()
+			defer b.Close()
+
+			publish(t, context.Background(), b, "deposit", "t:1", "1")
+			publish(t, context.Background(), b, "deposit", "t:1", "2")
+
+			r := newRecorder(t)
+			r.fail["1"] = []error{errors.New("insufficient funds")}
+			got := consume(t, b, []Subscription{{Topic: "deposit", Workers: 2}}, r, 2, 2*pollConfig.Lease)
+
+			if len(got) != 2 || got[0].eventID != "1" || got[1].eventID != "2" {
+				t.Fatalf("delivered %+v, want events 1 and 2 once each", got)
+			}
+		})
+	}
+}
+
+func cmpEventID(a, b string) int {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX != nil || errY != nil {
+		panic(fmt.Sprintf("event IDs %q and %q are not numbers", a, b))
+	}
+	return x - y
+}
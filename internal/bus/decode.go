@@ -0,0 +1,40 @@
+package bus
+
+import (
+	"fmt"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/event"
+)
+
+// Decoder reads message values written in any supported encoding.
+type Decoder interface {
+	Decode(data []byte) (*event.Envelope, error)
+}
+
+// DecodeEnvelope reads the envelope of a message. Messages published
+// before the envelope existed get an event ID derived from their position,
+// which stays the same however often they are read.
+func DecodeEnvelope(decoder Decoder, m Message) (*event.Envelope, error) {
+	env, err := decoder.Decode(m.Value)
+	if err != nil {
+		return nil, err
+	}
+	if env.EventID == "" {
+		env.EventID = fmt.Sprintf("%s-%d-%d", m.Topic, m.Partition, m.Offset)
+	}
+	return env, nil
+}
+
+// DecodeTransaction reads the envelope and transaction payload of a message.
+func DecodeTransaction(decoder Decoder, m Message) (*event.Envelope, entity.TransactionEvent, error) {
+	env, err := DecodeEnvelope(decoder, m)
+	if err != nil {
+		return nil, entity.TransactionEvent{}, err
+	}
+	payload, err := env.Transaction()
+	if err != nil {
+		return nil, entity.TransactionEvent{}, err
+	}
+	return env, payload, nil
+}
@@ -0,0 +1,135 @@
+package bus
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/clientip"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Message headers that carry the correlation of the originating request.
+// The trace context travels in the W3C traceparent and tracestate headers.
+const (
+	HeaderRequestID     = requestid.Header
+	HeaderCallerSubject = "X-Caller-Subject"
+	HeaderCallerRole    = "X-Caller-Role"
+	HeaderCallerIP      = "X-Caller-IP"
+	// HeaderOrderingKeys lists, comma separated, the keys a message is
+	// ordered by besides its own key.
+	HeaderOrderingKeys = "X-Ordering-Keys"
+)
+
+// Correlation is what a consumer restores from the headers of a message.
+type Correlation struct {
+	RequestID     string
+	CallerSubject string
+	CallerRole    string
+	CallerIP      string
+}
+
+// HeadersFromContext builds message headers from the request ID, span and
+// principal stored in ctx. Missing values are omitted.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers := make(map[string]string)
+	add := func(key, value string) {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+
+	c := CorrelationFromContext(ctx)
+	add(HeaderRequestID, c.RequestID)
+	add(HeaderCallerSubject, c.CallerSubject)
+	add(HeaderCallerRole, c.CallerRole)
+	add(HeaderCallerIP, c.CallerIP)
+	if keys, ok := ctx.Value(orderingKeysKey{}).([]string); ok {
+		add(HeaderOrderingKeys, strings.Join(keys, ","))
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+// CorrelationFromContext reads the correlation of the request handled in ctx.
+func CorrelationFromContext(ctx context.Context) Correlation {
+	c := Correlation{
+		RequestID: requestid.FromContext(ctx),
+		CallerIP:  clientip.FromContext(ctx),
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		c.CallerSubject = p.Subject
+		c.CallerRole = string(p.Role)
+	}
+	return c
+}
+
+type orderingKeysKey struct{}
+
+// WithOrderingKeys returns a ctx whose published messages are also ordered
+// by keys, e.g. by the target account of a transfer besides the source
+// account that keys its message.
+func WithOrderingKeys(ctx context.Context, keys ...string) context.Context {
+	return context.WithValue(ctx, orderingKeysKey{}, keys)
+}
+
+// OrderingKeys returns the key of m followed by the keys of its
+// HeaderOrderingKeys header, without duplicates.
+func OrderingKeys(m Message) []string {
+	keys := []string{m.Key}
+	if extra := m.Headers[HeaderOrderingKeys]; extra != "" {
+		for _, k := range strings.Split(extra, ",") {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// CorrelationFromHeaders reads what HeadersFromContext wrote.
+func CorrelationFromHeaders(headers map[string]string) Correlation {
+	return Correlation{
+		RequestID:     headers[HeaderRequestID],
+		CallerSubject: headers[HeaderCallerSubject],
+		CallerRole:    headers[HeaderCallerRole],
+		CallerIP:      headers[HeaderCallerIP],
+	}
+}
+
+// WithContext restores the request ID, caller and client IP into ctx so that
+// anything the consumer publishes, persists or audits stays correlated. The
+// caller is only informational here: the consumer performs no access checks.
+func (c Correlation) WithContext(ctx context.Context, tenantID string) context.Context {
+	if c.RequestID != "" {
+		ctx = requestid.WithID(ctx, c.RequestID)
+	}
+	if c.CallerSubject != "" {
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{
+			Subject:  c.CallerSubject,
+			Role:     auth.Role(c.CallerRole),
+			TenantID: tenantID,
+		})
+	}
+	if c.CallerIP != "" {
+		ctx = clientip.WithIP(ctx, c.CallerIP)
+	}
+	return tenant.WithID(ctx, tenantID)
+}
+
+func (c Correlation) Fields() logrus.Fields {
+	fields := logrus.Fields{}
+	if c.RequestID != "" {
+		fields["request_id"] = c.RequestID
+	}
+	if c.CallerSubject != "" {
+		fields["caller_subject"] = c.CallerSubject
+		fields["caller_role"] = c.CallerRole
+	}
+	return fields
+}
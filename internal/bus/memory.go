@@ -0,0 +1,178 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryBus keeps the messages of every topic in process memory. It is
+// meant for tests and single process runs: messages are lost when the
+// process exits and only consumers of the same process see them. Consumers
+// of one topic, including the workers of a subscription, share its
+// messages; each message goes to one of them.
+type MemoryBus struct {
+	encoder Encoder
+	logger  *logrus.Entry
+
+	mu sync.Mutex
+	// queue holds the messages not taken by a consumer yet, of every topic,
+	// in the order they were published.
+	queue   []Message
+	offsets map[string]int64
+	pending chan struct{}
+	// busy holds the ordering keys of the messages consumers are
+	// processing, so that the next message of a key waits for the previous
+	// one whatever its topic.
+	busy map[string]bool
+}
+
+func NewMemoryBus(encoder Encoder, logger *logrus.Entry) *MemoryBus {
+	return &MemoryBus{
+		encoder: encoder,
+		logger:  logger.WithField("component", "memory-bus"),
+		offsets: make(map[string]int64),
+		pending: make(chan struct{}),
+		busy:    make(map[string]bool),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, topic, key string, env *event.Envelope) error {
+	ctx, span := StartPublish(ctx, DriverMemory, topic)
+	start := time.Now()
+	value, err := b.encoder.Encode(topic, env)
+	if err == nil {
+		headers := HeadersFromContext(ctx)
+		b.mu.Lock()
+		b.offsets[topic]++
+		b.queue = append(b.queue, Message{
+			Topic:   topic,
+			Offset:  b.offsets[topic],
+			Key:     key,
+			Value:   value,
+			Headers: headers,
+			Time:    time.Now(),
+		})
+		b.wake()
+		b.mu.Unlock()
+	}
+	metrics.ObservePublish(topic, time.Since(start), err)
+	tracing.End(span, err)
+	if err != nil {
+		logger.FromContext(ctx, b.logger).WithError(err).Errorf("failed to publish message to topic=%s", topic)
+		return err
+	}
+	return nil
+}
+
+// Consume runs Workers consumers of each subscribed topic.
+func (b *MemoryBus) Consume(ctx context.Context, subs []Subscription, handler Handler) error {
+	consumeEach(ctx, subs, func(ctx context.Context, topic string) {
+		b.consume(ctx, topic, handler)
+	})
+	return nil
+}
+
+func (b *MemoryBus) consume(ctx context.Context, topic string, handler Handler) {
+	log := b.logger.WithField("topic", topic)
+	log.Info("Consumer started")
+	for {
+		m, ok := b.next(ctx, topic)
+		if !ok {
+			log.Info("Consumer context cancelled, shutting down gracefully")
+			return
+		}
+		err := DeliverRetrying(ctx, context.WithoutCancel(ctx), DriverMemory, m, handler)
+		b.done(m, IsRetryable(err))
+	}
+}
+
+// next waits for the first message of topic none of whose ordering keys is
+// being processed or held by an earlier message of any topic, and takes it
+// off the queue.
+func (b *MemoryBus) next(ctx context.Context, topic string) (Message, bool) {
+	for ctx.Err() == nil {
+		b.mu.Lock()
+		blocked := make(map[string]bool)
+		for i, m := range b.queue {
+			keys := OrderingKeys(m)
+			if m.Topic == topic && !b.held(keys, blocked) {
+				b.queue = append(b.queue[:i], b.queue[i+1:]...)
+				for _, k := range keys {
+					b.busy[k] = true
+				}
+				b.mu.Unlock()
+				return m, true
+			}
+			for _, k := range keys {
+				blocked[k] = true
+			}
+		}
+		pending := b.pending
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-pending:
+		}
+	}
+	return Message{}, false
+}
+
+func (b *MemoryBus) held(keys []string, blocked map[string]bool) bool {
+	for _, k := range keys {
+		if b.busy[k] || blocked[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// done releases the ordering keys of m. A message left to retry goes back to
+// the front of the queue, ahead of the later messages of its keys.
+func (b *MemoryBus) done(m Message, retry bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if retry {
+		b.queue = append([]Message{m}, b.queue...)
+	}
+	for _, k := range OrderingKeys(m) {
+		delete(b.busy, k)
+	}
+	b.wake()
+}
+
+// wake releases every waiting consumer.
+func (b *MemoryBus) wake() {
+	close(b.pending)
+	b.pending = make(chan struct{})
+}
+
+// Len reports how many messages of topic have not been taken by a consumer.
+func (b *MemoryBus) Len(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, m := range b.queue {
+		if m.Topic == topic {
+			n++
+		}
+	}
+	return n
+}
+
+// Close reports the messages that were never consumed; they are lost.
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if dropped := len(b.queue); dropped > 0 {
+		b.logger.WithField("messages", dropped).Warn("Memory bus closed with unconsumed messages")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestMemoryBusKeepsRetryingMessagesAtShutdown checks that a message still
+// failing transiently when consuming stops goes back to the queue.
+func TestMemoryBusKeepsRetryingMessagesAtShutdown(t *testing.T) {
+	b := NewMemoryBus(jsonEncoder{}, testLogger())
+	publish(t, context.Background(), b, "deposit", "t:1", "1")
+	publish(t, context.Background(), b, "deposit", "t:1", "2")
+
+	r := newRecorder(t)
+	r.fail["1"] = []error{Retryable(errors.New("connection reset")), Retryable(errors.New("connection reset"))}
+	got := consume(t, b, []Subscription{{Topic: "deposit", Workers: 1}}, r, 1, 0)
+
+	if len(got) != 1 || b.Len("deposit") != 2 {
+		t.Fatalf("delivered %+v and left %d messages, want event 1 once and both left", got, b.Len("deposit"))
+	}
+	if b.queue[0].Offset != 1 {
+		t.Fatalf("queue starts with offset %d, want the retried message", b.queue[0].Offset)
+	}
+}
@@ -0,0 +1,144 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// OrderedPool runs a handler on the messages of several topics, each topic
+// with the workers and queue size of its subscription. Messages that share
+// an ordering key (see OrderingKeys) run one at a time, in the order they
+// were submitted, whatever their topics; the others run concurrently.
+type OrderedPool struct {
+	handle func(Message)
+	// depth is called with the number of messages of a topic waiting for a
+	// worker whenever it changes.
+	depth  func(topic string, n int)
+	topics map[string]*poolTopic
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	waiting []pooledMessage
+	busy    map[string]bool
+}
+
+type poolTopic struct {
+	workers int
+	running int
+	// depth is the number of waiting messages last reported.
+	depth int
+	slots chan struct{}
+}
+
+type pooledMessage struct {
+	m    Message
+	keys []string
+}
+
+// NewOrderedPool returns a pool that runs up to Workers messages of each
+// subscribed topic at a time and holds at most QueueSize of them submitted
+// but unfinished.
+func NewOrderedPool(subs []Subscription, handle func(Message), depth func(topic string, n int)) *OrderedPool {
+	topics := make(map[string]*poolTopic, len(subs))
+	for _, sub := range subs {
+		workers, size := max(sub.Workers, 1), sub.QueueSize
+		if size < workers {
+			size = workers
+		}
+		topics[sub.Topic] = &poolTopic{workers: workers, slots: make(chan struct{}, size)}
+	}
+	return &OrderedPool{
+		handle: handle,
+		depth:  depth,
+		topics: topics,
+		busy:   make(map[string]bool),
+	}
+}
+
+// Submit queues m, waiting while its topic is full. It returns ctx's error
+// if ctx is done first, in which case m is not run.
+func (p *OrderedPool) Submit(ctx context.Context, m Message) error {
+	t, ok := p.topics[m.Topic]
+	if !ok {
+		return fmt.Errorf("bus: topic %s is not subscribed", m.Topic)
+	}
+	select {
+	case t.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	p.wg.Add(1)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.waiting = append(p.waiting, pooledMessage{m: m, keys: OrderingKeys(m)})
+	p.dispatch()
+	return nil
+}
+
+// Wait blocks until every submitted message has been handled.
+func (p *OrderedPool) Wait() {
+	p.wg.Wait()
+}
+
+// dispatch starts the waiting messages that may run now. A message may run
+// when a worker of its topic is free and none of its keys belongs to a
+// running message or to a message submitted before it that is still
+// waiting.
+func (p *OrderedPool) dispatch() {
+	blocked := make(map[string]bool)
+	depths := make(map[string]int, len(p.topics))
+	waiting := p.waiting[:0]
+	for _, pm := range p.waiting {
+		t := p.topics[pm.m.Topic]
+		if t.running < t.workers && !p.held(pm.keys, blocked) {
+			for _, k := range pm.keys {
+				p.busy[k] = true
+			}
+			t.running++
+			go p.run(pm)
+			continue
+		}
+		for _, k := range pm.keys {
+			blocked[k] = true
+		}
+		depths[pm.m.Topic]++
+		waiting = append(waiting, pm)
+	}
+	p.waiting = waiting
+
+	for topic, t := range p.topics {
+		if n := depths[topic]; n != t.depth {
+			t.depth = n
+			if p.depth != nil {
+				p.depth(topic, n)
+			}
+		}
+	}
+}
+
+func (p *OrderedPool) held(keys []string, blocked map[string]bool) bool {
+	for _, k := range keys {
+		if p.busy[k] || blocked[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *OrderedPool) run(pm pooledMessage) {
+	p.handle(pm.m)
+
+	t := p.topics[pm.m.Topic]
+	p.mu.Lock()
+	for _, k := range pm.keys {
+		delete(p.busy, k)
+	}
+	t.running--
+	p.dispatch()
+	p.mu.Unlock()
+
+	<-t.slots
+	p.wg.Done()
+}
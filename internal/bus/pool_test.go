@@ -0,0 +1,137 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOrderedPoolOrdersKeyAcrossTopics(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running = make(map[string]bool)
+		order   []int64
+	)
+	pool := NewOrderedPool([]Subscription{
+		{Topic: "deposit", Workers: 4, QueueSize: 10},
+		{Topic: "withdraw", Workers: 4, QueueSize: 10},
+	}, func(m Message) {
+		mu.Lock()
+		if running[m.Key] {
+			t.Errorf("message %d of key %s ran while another one of the key was running", m.Offset, m.Key)
+		}
+		running[m.Key] = true
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running[m.Key] = false
+		if m.Key == "t:1" {
+			order = append(order, m.Offset)
+		}
+		mu.Unlock()
+	}, nil)
+
+	topics := []string{"deposit", "withdraw"}
+	for i := int64(0); i < 20; i++ {
+		key := "t:1"
+		if i%3 == 0 {
+			key = "t:2"
+		}
+		m := Message{Topic: topics[i%2], Offset: i, Key: key}
+		if err := pool.Submit(context.Background(), m); err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	pool.Wait()
+
+	for i := 1; i < len(order); i++ {
+		if order[i] < order[i-1] {
+			t.Fatalf("messages of key t:1 ran out of order: %v", order)
+		}
+	}
+}
+
+func TestOrderedPoolHoldsBackTransferTarget(t *testing.T) {
+	release := make(chan struct{})
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	pool := NewOrderedPool([]Subscription{
+		{Topic: "deposit", Workers: 2, QueueSize: 10},
+		{Topic: "transfer", Workers: 2, QueueSize: 10},
+	}, func(m Message) {
+		if m.Key == "t:2" && m.Topic == "deposit" {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, m.Topic)
+		mu.Unlock()
+	}, nil)
+
+	ctx := context.Background()
+	if err := pool.Submit(ctx, Message{Topic: "deposit", Key: "t:2"}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	transfer := Message{Topic: "transfer", Key: "t:1", Headers: map[string]string{HeaderOrderingKeys: "t:2"}}
+	if err := pool.Submit(ctx, transfer); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	pool.Wait()
+
+	if len(order) != 2 || order[0] != "deposit" || order[1] != "transfer" {
+		t.Fatalf("order = %v, want the transfer after the deposit of its target", order)
+	}
+}
+
+func TestOrderedPoolLimitsWorkersPerTopic(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		busy = make(map[string]int)
+		peak = make(map[string]int)
+	)
+	pool := NewOrderedPool([]Subscription{
+		{Topic: "deposit", Workers: 1, QueueSize: 10},
+		{Topic: "transfer", Workers: 3, QueueSize: 10},
+	}, func(m Message) {
+		mu.Lock()
+		busy[m.Topic]++
+		peak[m.Topic] = max(peak[m.Topic], busy[m.Topic])
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		busy[m.Topic]--
+		mu.Unlock()
+	}, nil)
+
+	for i := 0; i < 6; i++ {
+		for _, topic := range []string{"deposit", "transfer"} {
+			m := Message{Topic: topic, Key: topic + string(rune('a'+i))}
+			if err := pool.Submit(context.Background(), m); err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+		}
+	}
+	pool.Wait()
+
+	if peak["deposit"] != 1 {
+		t.Errorf("deposit ran %d messages at once, want 1", peak["deposit"])
+	}
+	if peak["transfer"] > 3 {
+		t.Errorf("transfer ran %d messages at once, want at most 3", peak["transfer"])
+	}
+}
+
+func TestOrderedPoolRejectsUnsubscribedTopic(t *testing.T) {
+	pool := NewOrderedPool([]Subscription{{Topic: "deposit", Workers: 1, QueueSize: 1}}, func(Message) {}, nil)
+	if err := pool.Submit(context.Background(), Message{Topic: "transfer"}); err == nil {
+		t.Fatal("Submit of an unsubscribed topic succeeded")
+	}
+}
@@ -0,0 +1,129 @@
+package bus
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// Queue stores the messages of the Postgres bus.
+type Queue interface {
+	Enqueue(ctx context.Context, m Message) error
+	// Claim leases up to limit messages of topic whose ordering keys (see
+	// OrderingKeys) have no earlier message left on any topic. A message
+	// whose lease expires before it is acknowledged is claimed again.
+	Claim(ctx context.Context, topic string, limit int, lease time.Duration) ([]Message, error)
+	Ack(ctx context.Context, offset int64) error
+}
+
+// PostgresBus polls a queue table with FOR UPDATE SKIP LOCKED, so that a
+// small deployment can run without Kafka. Any number of processes may
+// consume a topic; a key's messages are still processed one at a time, in
+// order.
+type PostgresBus struct {
+	queue   Queue
+	encoder Encoder
+	cfg     config.BusConfig
+	logger  *logrus.Entry
+}
+
+func NewPostgresBus(queue Queue, encoder Encoder, cfg config.BusConfig, logger *logrus.Entry) *PostgresBus {
+	return &PostgresBus{
+		queue:   queue,
+		encoder: encoder,
+		cfg:     cfg,
+		logger:  logger.WithField("component", "postgres-bus"),
+	}
+}
+
+func (b *PostgresBus) Publish(ctx context.Context, topic, key string, env *event.Envelope) error {
+	ctx, span := StartPublish(ctx, DriverPostgres, topic)
+	start := time.Now()
+	value, err := b.encoder.Encode(topic, env)
+	if err == nil {
+		err = b.queue.Enqueue(context.WithoutCancel(ctx), Message{
+			Topic:   topic,
+			Key:     key,
+			Value:   value,
+			Headers: HeadersFromContext(ctx),
+		})
+	}
+	metrics.ObservePublish(topic, time.Since(start), err)
+	tracing.End(span, err)
+	if err != nil {
+		logger.FromContext(ctx, b.logger).WithError(err).Errorf("failed to publish message to topic=%s", topic)
+		return err
+	}
+	return nil
+}
+
+// Consume runs Workers polling consumers of each subscribed topic.
+func (b *PostgresBus) Consume(ctx context.Context, subs []Subscription, handler Handler) error {
+	consumeEach(ctx, subs, func(ctx context.Context, topic string) {
+		b.consume(ctx, topic, handler)
+	})
+	return nil
+}
+
+func (b *PostgresBus) consume(ctx context.Context, topic string, handler Handler) {
+	log := b.logger.WithField("topic", topic)
+	log.Info("Consumer started")
+
+	ticker := time.NewTicker(b.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch suggests more are waiting, so poll again right away.
+		for b.consumeBatch(ctx, topic, handler, log) == b.cfg.BatchSize && ctx.Err() == nil {
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("Consumer context cancelled, shutting down gracefully")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *PostgresBus) consumeBatch(ctx context.Context, topic string, handler Handler, log *logrus.Entry) int {
+	messages, err := b.queue.Claim(ctx, topic, b.cfg.BatchSize, b.cfg.Lease)
+	if err != nil {
+		if ctx.Err() == nil {
+			metrics.ConsumeError(topic, "read")
+			log.WithError(err).Error("Failed to claim messages, will retry...")
+		}
+		return 0
+	}
+
+	for _, m := range messages {
+		// Claimed messages are finished even when ctx is cancelled.
+		msgCtx := context.WithoutCancel(ctx)
+
+		// Retries stop well within the lease, before another consumer may
+		// claim the message; it is then claimed again once the lease expires.
+		stop, cancel := context.WithTimeout(ctx, b.cfg.Lease/2)
+		err := DeliverRetrying(stop, msgCtx, DriverPostgres, m, handler)
+		cancel()
+		if IsRetryable(err) {
+			log.WithField("offset", m.Offset).Warn("Message left unacknowledged, it is delivered again when its lease expires")
+			continue
+		}
+
+		if err := b.queue.Ack(msgCtx, m.Offset); err != nil {
+			metrics.ConsumeError(topic, "commit")
+			log.WithError(err).WithField("offset", m.Offset).Error("Failed to acknowledge message")
+		}
+	}
+	return len(messages)
+}
+
+func (b *PostgresBus) Close() error {
+	return nil
+}
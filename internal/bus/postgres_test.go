@@ -0,0 +1,124 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memQueue is a Queue in memory that claims like claimMessagesQuery: a
+// message is claimed when its lease has expired and no earlier message of
+// any topic, claimed or not, shares one of its ordering keys.
+type memQueue struct {
+	mu       sync.Mutex
+	lastID   int64
+	messages []*queuedMessage
+	// ackFailures is how many of the next acknowledgements fail.
+	ackFailures int
+}
+
+type queuedMessage struct {
+	m           Message
+	keys        []string
+	lockedUntil time.Time
+}
+
+func newMemQueue() *memQueue {
+	return &memQueue{}
+}
+
+func (q *memQueue) Enqueue(_ context.Context, m Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lastID++
+	m.Offset, m.Time = q.lastID, time.Now()
+	q.messages = append(q.messages, &queuedMessage{m: m, keys: OrderingKeys(m)})
+	return nil
+}
+
+func (q *memQueue) Claim(_ context.Context, topic string, limit int, lease time.Duration) ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	earlier := make(map[string]bool)
+	var claimed []Message
+	for _, qm := range q.messages {
+		free := true
+		for _, k := range qm.keys {
+			free = free && !earlier[k]
+			earlier[k] = true
+		}
+		if free && qm.m.Topic == topic && !qm.lockedUntil.After(now) && len(claimed) < limit {
+			qm.lockedUntil = now.Add(lease)
+			claimed = append(claimed, qm.m)
+		}
+	}
+	return claimed, nil
+}
+
+func (q *memQueue) Ack(_ context.Context, offset int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ackFailures > 0 {
+		q.ackFailures--
+		return errors.New("ack failed")
+	}
+	for i, qm := range q.messages {
+		if qm.m.Offset == offset {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (q *memQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// TestPostgresBusRedeliversUnacknowledgedMessages covers at least once
+// delivery: a message whose acknowledgement is lost, like one of a
+// consumer that died, is delivered again once its lease expires, and the
+// next message of its key waits for it.
+func TestPostgresBusRedeliversUnacknowledgedMessages(t *testing.T) {
+	queue := newMemQueue()
+	queue.ackFailures = 1
+	b := NewPostgresBus(queue, jsonEncoder{}, pollConfig, testLogger())
+
+	publish(t, context.Background(), b, "deposit", "t:1", "1")
+	publish(t, context.Background(), b, "withdraw", "t:1", "2")
+
+	subs := []Subscription{{Topic: "deposit", Workers: 2}, {Topic: "withdraw", Workers: 2}}
+	got := consume(t, b, subs, newRecorder(t), 3, 10*time.Millisecond)
+
+	var ids []string
+	for _, d := range got {
+		ids = append(ids, d.eventID)
+	}
+	if len(ids) != 3 || ids[0] != "1" || ids[1] != "1" || ids[2] != "2" {
+		t.Fatalf("delivered events %v, want [1 1 2]", ids)
+	}
+	if n := queue.len(); n != 0 {
+		t.Fatalf("%d messages left in the queue, want none", n)
+	}
+}
+
+// TestPostgresBusLeavesRetryingMessagesAtShutdown checks that a message
+// still failing transiently at shutdown stays in the queue.
+func TestPostgresBusLeavesRetryingMessagesAtShutdown(t *testing.T) {
+	queue := newMemQueue()
+	b := NewPostgresBus(queue, jsonEncoder{}, pollConfig, testLogger())
+	publish(t, context.Background(), b, "deposit", "t:1", "1")
+
+	r := newRecorder(t)
+	r.fail["1"] = []error{Retryable(errors.New("connection reset")), Retryable(errors.New("connection reset"))}
+	consume(t, b, []Subscription{{Topic: "deposit", Workers: 1}}, r, 1, 0)
+
+	if n := queue.len(); n != 1 {
+		t.Fatalf("%d messages left in the queue, want the retried one", n)
+	}
+}
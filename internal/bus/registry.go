@@ -0,0 +1,72 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// EventHandler processes one decoded event.
+type EventHandler func(ctx context.Context, m Message, env *event.Envelope) error
+
+// Registry decodes messages and dispatches them to the handler registered
+// for their event type, whichever topic carried them. Its Handle is the
+// Handler a bus consumes with.
+type Registry struct {
+	decoder  Decoder
+	handlers map[string]EventHandler
+	logger   *logrus.Entry
+}
+
+func NewRegistry(decoder Decoder, logger *logrus.Entry) *Registry {
+	return &Registry{
+		decoder:  decoder,
+		handlers: make(map[string]EventHandler),
+		logger:   logger,
+	}
+}
+
+// Register routes the events of eventType to handler. Registrations happen
+// before consuming starts; registering a type twice panics.
+func (r *Registry) Register(eventType string, handler EventHandler) {
+	if _, ok := r.handlers[eventType]; ok {
+		panic("bus: handler already registered for " + eventType)
+	}
+	r.handlers[eventType] = handler
+}
+
+// EventTypes lists the registered event types.
+func (r *Registry) EventTypes() []string {
+	types := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Handle decodes m and runs the handler of its event type. Messages that
+// cannot be decoded or have no handler are logged and counted.
+func (r *Registry) Handle(ctx context.Context, m Message) error {
+	log := logger.FromContext(ctx, r.logger).WithField("topic", m.Topic)
+
+	env, err := DecodeEnvelope(r.decoder, m)
+	if err != nil {
+		metrics.ConsumeError(m.Topic, "decode")
+		log.WithError(err).Error("Failed to decode event")
+		return err
+	}
+	handler, ok := r.handlers[env.EventType]
+	if !ok {
+		metrics.ConsumeError(m.Topic, "route")
+		err := fmt.Errorf("no handler for event type %s", env.EventType)
+		log.WithError(err).WithField("event_id", env.EventID).Error("Failed to route event")
+		return err
+	}
+	return handler(ctx, m, env)
+}
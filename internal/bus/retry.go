@@ -0,0 +1,65 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Backoff between the deliveries of a message whose handler failed with a
+// retryable error.
+const (
+	retryInitialBackoff = 100 * time.Millisecond
+	retryMaxBackoff     = 30 * time.Second
+)
+
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// Retryable marks err, returned by a Handler, as transient, such as a lost
+// database connection: the bus delivers the message again rather than
+// finishing it.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+// IsRetryable reports whether err was marked by Retryable.
+func IsRetryable(err error) bool {
+	var r *retryableError
+	return errors.As(err, &r)
+}
+
+// DeliverRetrying runs Deliver and, while the handler fails with a
+// retryable error, delivers m again after a growing backoff. It stops
+// retrying when stop is done and returns the retryable error, in which case
+// the caller must neither commit nor acknowledge m, so that it is delivered
+// again later.
+func DeliverRetrying(stop, ctx context.Context, system string, m Message, handler Handler, attrs ...attribute.KeyValue) error {
+	backoff := retryInitialBackoff
+	for {
+		err := Deliver(ctx, system, m, handler, attrs...)
+		if !IsRetryable(err) || stop.Err() != nil {
+			return err
+		}
+		metrics.ConsumeError(m.Topic, "retry")
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-stop.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, retryMaxBackoff)
+	}
+}
@@ -0,0 +1,104 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/metrics"
+)
+
+// Subscription is a topic to consume and how concurrently to consume it.
+type Subscription struct {
+	Topic string
+	// Workers is how many messages of the topic are processed at once.
+	Workers int
+	// QueueSize bounds the fetched but unfinished messages of the topic,
+	// on buses that fetch ahead.
+	QueueSize int
+}
+
+// Subscriptions builds the subscriptions of the topics in cfg, or of the
+// transaction topics when cfg names none. Topic names are given without the
+// prefix of topics.
+func Subscriptions(cfg config.ConsumerConfig, topics config.TopicsConfig) ([]Subscription, error) {
+	workers := make(map[string]int)
+	for _, entry := range strings.Split(cfg.TopicWorkers, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		topic, value, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || topic == "" || err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid topic workers %q, want topic=workers", entry)
+		}
+		workers[topics.Name(strings.TrimSpace(topic))] = n
+	}
+
+	names := topics.Transactions()
+	if strings.TrimSpace(cfg.Topics) != "" {
+		names = nil
+		for _, topic := range strings.Split(cfg.Topics, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				names = append(names, topics.Name(topic))
+			}
+		}
+	}
+
+	var subs []Subscription
+	seen := make(map[string]bool)
+	for _, topic := range names {
+		if seen[topic] {
+			continue
+		}
+		seen[topic] = true
+		sub := Subscription{Topic: topic, Workers: cfg.Workers, QueueSize: cfg.QueueSize}
+		if n, ok := workers[topic]; ok {
+			sub.Workers = n
+		}
+		if sub.Workers < 1 {
+			sub.Workers = 1
+		}
+		subs = append(subs, sub)
+	}
+	for topic := range workers {
+		if !seen[topic] {
+			return nil, fmt.Errorf("topic workers set for %s, which is not consumed", topic)
+		}
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("no topics to consume")
+	}
+	return subs, nil
+}
+
+// Topics lists the topics of subs.
+func Topics(subs []Subscription) []string {
+	topics := make([]string, len(subs))
+	for i, sub := range subs {
+		topics[i] = sub.Topic
+	}
+	return topics
+}
+
+// consumeEach runs Workers copies of loop for every subscription, for buses
+// whose consumers of a topic already keep each key in order, and waits for
+// all of them to return.
+func consumeEach(ctx context.Context, subs []Subscription, loop func(ctx context.Context, topic string)) {
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		metrics.SetConsumerWorkers(sub.Topic, sub.Workers)
+		for i := 0; i < sub.Workers; i++ {
+			wg.Add(1)
+			go func(topic string) {
+				defer wg.Done()
+				loop(ctx, topic)
+			}(sub.Topic)
+		}
+	}
+	wg.Wait()
+}
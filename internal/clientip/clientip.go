@@ -0,0 +1,29 @@
+// Package clientip carries the network address of the caller that started a
+// request, for the audit log.
+package clientip
+
+import (
+	"context"
+	"net"
+)
+
+type clientIPKey struct{}
+
+func WithIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// FromAddr strips the port from a "host:port" remote address. Addresses
+// without a port are returned unchanged.
+func FromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/schemaregistry"
+)
+
+// avroType is a parsed Avro schema. Only what the event schemas use is
+// supported: records, unions and the primitive types.
+type avroType struct {
+	kind     string
+	logical  string
+	fields   []avroField
+	branches []*avroType
+}
+
+type avroField struct {
+	name string
+	typ  *avroType
+}
+
+// avroCodec writes values in the Avro binary encoding of one registered schema.
+type avroCodec struct {
+	id     int
+	schema *avroType
+}
+
+func newAvroCodec(s schemaregistry.Schema) (*avroCodec, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(s.Schema), &raw); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	t, err := parseAvro(raw, map[string]*avroType{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	if t.kind != "record" {
+		return nil, fmt.Errorf("avro schema must be a record, got %s", t.kind)
+	}
+	return &avroCodec{id: s.ID, schema: t}, nil
+}
+
+func (c *avroCodec) Name() string { return Avro }
+
+func (c *avroCodec) Encode(env *event.Envelope) ([]byte, error) {
+	r, err := toRecord(env)
+	if err != nil {
+		return nil, err
+	}
+	return encodeAvro(writeHeader(c.id), c.schema, r)
+}
+
+func (c *avroCodec) decode(data []byte) (*event.Envelope, error) {
+	v, rest, err := decodeAvro(data, c.schema)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", event.ErrInvalid, err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes after avro value", event.ErrInvalid, len(rest))
+	}
+	return fromRecord(v.(record))
+}
+
+func parseAvro(raw interface{}, named map[string]*avroType) (*avroType, error) {
+	switch v := raw.(type) {
+	case string:
+		switch v {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &avroType{kind: v}, nil
+		}
+		if t, ok := named[v]; ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("unknown type %q", v)
+
+	case []interface{}:
+		t := &avroType{kind: "union"}
+		for _, b := range v {
+			branch, err := parseAvro(b, named)
+			if err != nil {
+				return nil, err
+			}
+			t.branches = append(t.branches, branch)
+		}
+		return t, nil
+
+	case map[string]interface{}:
+		kind, _ := v["type"].(string)
+		if kind != "record" {
+			t, err := parseAvro(v["type"], named)
+			if err != nil {
+				return nil, err
+			}
+			if logical, ok := v["logicalType"].(string); ok {
+				t = &avroType{kind: t.kind, logical: logical}
+			}
+			return t, nil
+		}
+
+		t := &avroType{kind: "record"}
+		if name, ok := v["name"].(string); ok {
+			named[name] = t
+			if ns, ok := v["namespace"].(string); ok {
+				named[ns+"."+name] = t
+			}
+		}
+		fields, _ := v["fields"].([]interface{})
+		for _, f := range fields {
+			field, _ := f.(map[string]interface{})
+			name, _ := field["name"].(string)
+			if name == "" {
+				return nil, fmt.Errorf("record field without a name")
+			}
+			ft, err := parseAvro(field["type"], named)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", name, err)
+			}
+			t.fields = append(t.fields, avroField{name: name, typ: ft})
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("unsupported schema %v", raw)
+}
+
+func encodeAvro(b []byte, t *avroType, v interface{}) ([]byte, error) {
+	switch t.kind {
+	case "null":
+		if v != nil {
+			return nil, fmt.Errorf("want null, got %T", v)
+		}
+		return b, nil
+	case "boolean":
+		x, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("want boolean, got %T", v)
+		}
+		if x {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case "int", "long":
+		switch x := v.(type) {
+		case int64:
+			return appendVarint(b, x), nil
+		case time.Time:
+			switch t.logical {
+			case "timestamp-micros":
+				return appendVarint(b, x.UnixMicro()), nil
+			case "timestamp-millis":
+				return appendVarint(b, x.UnixMilli()), nil
+			}
+		}
+		return nil, fmt.Errorf("want %s, got %T", t.kind, v)
+	case "float":
+		x, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("want float, got %T", v)
+		}
+		return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(x))), nil
+	case "double":
+		x, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("want double, got %T", v)
+		}
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(x)), nil
+	case "string", "bytes":
+		var x []byte
+		switch s := v.(type) {
+		case string:
+			x = []byte(s)
+		case []byte:
+			x = s
+		default:
+			return nil, fmt.Errorf("want %s, got %T", t.kind, v)
+		}
+		return append(appendVarint(b, int64(len(x))), x...), nil
+	case "record":
+		r, ok := v.(record)
+		if !ok {
+			return nil, fmt.Errorf("want record, got %T", v)
+		}
+		var err error
+		for _, f := range t.fields {
+			if b, err = encodeAvro(b, f.typ, r[f.name]); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+		}
+		return b, nil
+	case "union":
+		// The first branch that accepts the value is written.
+		for i, branch := range t.branches {
+			if out, err := encodeAvro(appendVarint(b, int64(i)), branch, v); err == nil {
+				return out, nil
+			}
+		}
+		return nil, fmt.Errorf("no union branch accepts %T", v)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t.kind)
+}
+
+func decodeAvro(data []byte, t *avroType) (interface{}, []byte, error) {
+	switch t.kind {
+	case "null":
+		return nil, data, nil
+	case "boolean":
+		if len(data) < 1 {
+			return nil, nil, fmt.Errorf("short boolean")
+		}
+		return data[0] != 0, data[1:], nil
+	case "int", "long":
+		x, rest, err := readVarint(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch t.logical {
+		case "timestamp-micros":
+			return time.UnixMicro(x).UTC(), rest, nil
+		case "timestamp-millis":
+			return time.UnixMilli(x).UTC(), rest, nil
+		}
+		return x, rest, nil
+	case "float":
+		if len(data) < 4 {
+			return nil, nil, fmt.Errorf("short float")
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data))), data[4:], nil
+	case "double":
+		if len(data) < 8 {
+			return nil, nil, fmt.Errorf("short double")
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), data[8:], nil
+	case "string", "bytes":
+		n, rest, err := readVarint(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n < 0 || int64(len(rest)) < n {
+			return nil, nil, fmt.Errorf("short %s", t.kind)
+		}
+		if t.kind == "string" {
+			return string(rest[:n]), rest[n:], nil
+		}
+		return append([]byte(nil), rest[:n]...), rest[n:], nil
+	case "record":
+		r := make(record, len(t.fields))
+		for _, f := range t.fields {
+			v, rest, err := decodeAvro(data, f.typ)
+			if err != nil {
+				return nil, nil, fmt.Errorf("field %s: %w", f.name, err)
+			}
+			r[f.name], data = v, rest
+		}
+		return r, data, nil
+	case "union":
+		i, rest, err := readVarint(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i < 0 || int(i) >= len(t.branches) {
+			return nil, nil, fmt.Errorf("union branch %d out of range", i)
+		}
+		return decodeAvro(rest, t.branches[i])
+	}
+	return nil, nil, fmt.Errorf("unsupported type %s", t.kind)
+}
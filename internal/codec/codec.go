@@ -0,0 +1,253 @@
+// Package codec encodes event envelopes as message values. JSON values are
+// the envelope as is; Avro and Protobuf values use the Confluent wire format,
+// whose header names the registered writer schema. Decoding recognises every
+// format, so the codec of a topic can change while old messages remain.
+package codec
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/serikdev/CashFlow/api/events"
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/schemaregistry"
+)
+
+// Codec names.
+const (
+	JSON     = "json"
+	Avro     = "avro"
+	Protobuf = "protobuf"
+)
+
+// Codec encodes the envelopes of one topic.
+type Codec interface {
+	Name() string
+	Encode(env *event.Envelope) ([]byte, error)
+}
+
+// decoder reads the values written with one registered schema.
+type decoder interface {
+	decode(data []byte) (*event.Envelope, error)
+}
+
+// Set holds the codec selected for every topic and decodes values written by
+// any of them.
+type Set struct {
+	registry    *schemaregistry.Registry
+	defaultName string
+
+	mu       sync.Mutex
+	byTopic  map[string]Codec
+	decoders map[int]decoder
+}
+
+// NewSet builds the codecs configured for the topics, registering the
+// schemas they write. Topics without a codec of their own use the default
+// one, created when the topic is first written. The topics of
+// cfg.TopicCodecs are named without the topic prefix.
+func NewSet(cfg config.KafkaConfig, registry *schemaregistry.Registry) (*Set, error) {
+	topics, err := parseTopics(cfg.TopicCodecs)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkName(cfg.Codec); err != nil {
+		return nil, err
+	}
+	s := &Set{
+		registry:    registry,
+		defaultName: cfg.Codec,
+		byTopic:     make(map[string]Codec, len(topics)),
+		decoders:    make(map[int]decoder),
+	}
+	// Sorted, so that a new registry assigns the same IDs on every run.
+	for _, topic := range slices.Sorted(maps.Keys(topics)) {
+		name := topics[topic]
+		topic = cfg.Topics.Name(topic)
+		if s.byTopic[topic], err = s.newCodec(name, topic); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func checkName(name string) error {
+	switch strings.ToLower(name) {
+	case "", JSON, Avro, Protobuf:
+		return nil
+	}
+	return fmt.Errorf("unknown codec %q", name)
+}
+
+func (s *Set) newCodec(name, topic string) (Codec, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	schemaType, file := schemaregistry.TypeAvro, events.AvroSchema
+	switch strings.ToLower(name) {
+	case "", JSON:
+		return jsonCodec{}, nil
+	case Protobuf:
+		schemaType, file = schemaregistry.TypeProtobuf, events.ProtobufSchema
+	}
+
+	text, err := events.Schemas.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := s.registry.Register(schemaregistry.Subject(topic), schemaType, string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema of %s: %w", topic, err)
+	}
+
+	if schemaType == schemaregistry.TypeAvro {
+		return newAvroCodec(schema)
+	}
+	return newProtobufCodec(schema)
+}
+
+// Encode encodes env with the codec of topic.
+func (s *Set) Encode(topic string, env *event.Envelope) ([]byte, error) {
+	c, err := s.Codec(topic)
+	if err != nil {
+		return nil, err
+	}
+	return c.Encode(env)
+}
+
+// Codec returns the codec selected for topic.
+func (s *Set) Codec(topic string) (Codec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.byTopic[topic]; ok {
+		return c, nil
+	}
+	c, err := s.newCodec(s.defaultName, topic)
+	if err != nil {
+		return nil, err
+	}
+	s.byTopic[topic] = c
+	return c, nil
+}
+
+// Decode reads a value written by any codec and upcasts its payload to the
+// current schema version.
+func (s *Set) Decode(data []byte) (*event.Envelope, error) {
+	if len(data) == 0 || data[0] != magicByte {
+		return event.Decode(data)
+	}
+
+	id, body, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	d, err := s.decoder(id)
+	if err != nil {
+		return nil, err
+	}
+	return d.decode(body)
+}
+
+func (s *Set) decoder(id int) (decoder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.decoders[id]; ok {
+		return d, nil
+	}
+
+	schema, err := s.registry.ByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", event.ErrInvalid, err)
+	}
+	var d decoder
+	switch schema.SchemaType {
+	case schemaregistry.TypeAvro:
+		d, err = newAvroCodec(schema)
+	case schemaregistry.TypeProtobuf:
+		d, err = newProtobufCodec(schema)
+	default:
+		err = fmt.Errorf("unsupported schema type %s", schema.SchemaType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("schema %d: %w", id, err)
+	}
+	s.decoders[id] = d
+	return d, nil
+}
+
+// parseTopics parses a comma separated list of topic=codec entries.
+func parseTopics(spec string) (map[string]string, error) {
+	topics := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		topic, name, ok := strings.Cut(entry, "=")
+		if !ok || topic == "" || name == "" {
+			return nil, fmt.Errorf("invalid topic codec %q, want topic=codec", entry)
+		}
+		topics[strings.TrimSpace(topic)] = strings.TrimSpace(name)
+	}
+	return topics, nil
+}
+
+// record is the envelope in the field names of the Avro and Protobuf
+// schemas. Both binary codecs go through it and back to JSON, so the decoded
+// envelope passes the same upcasting and checks as a JSON value.
+type record map[string]interface{}
+
+func toRecord(env *event.Envelope) (record, error) {
+	p, err := env.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	payload := record{
+		"tenant_id":          p.TenantID,
+		"account_id":         p.AccountID,
+		"related_account_id": nil,
+		"amount":             p.Amount,
+		"currency":           p.Currency,
+		"transaction_type":   p.TransactionType,
+	}
+	if p.RelatedAccount != nil {
+		payload["related_account_id"] = *p.RelatedAccount
+	}
+	return record{
+		"event_id":       env.EventID,
+		"event_type":     env.EventType,
+		"schema_version": int64(env.SchemaVersion),
+		"occurred_at":    env.OccurredAt,
+		"producer":       env.Producer,
+		"payload":        payload,
+	}, nil
+}
+
+func fromRecord(r record) (*event.Envelope, error) {
+	occurredAt, _ := r["occurred_at"].(time.Time)
+	version, _ := r["schema_version"].(int64)
+	envelope := map[string]interface{}{
+		"event_id":       r["event_id"],
+		"event_type":     r["event_type"],
+		"schema_version": version,
+		"occurred_at":    occurredAt.UTC(),
+		"producer":       r["producer"],
+	}
+
+	payload, _ := r["payload"].(record)
+	fields := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		// Unset optional fields and empty defaults are omitted like in JSON values.
+		if v == nil || v == "" {
+			continue
+		}
+		fields[k] = v
+	}
+	envelope["payload"] = fields
+	return decodeJSON(envelope)
+}
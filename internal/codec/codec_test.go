@@ -0,0 +1,261 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/schemaregistry"
+)
+
+func newSet(t *testing.T, topicCodecs string) *Set {
+	t.Helper()
+	registry, err := schemaregistry.Open(filepath.Join(t.TempDir(), "registry.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s, err := NewSet(config.KafkaConfig{Codec: JSON, TopicCodecs: topicCodecs}, registry)
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	return s
+}
+
+// deposit is the envelope of the golden values below.
+func deposit() *event.Envelope {
+	return &event.Envelope{
+		EventID:       "e1",
+		EventType:     event.TypeDeposit,
+		SchemaVersion: 2,
+		OccurredAt:    time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		Producer:      event.Producer,
+		Payload:       json.RawMessage(`{"tenant_id":"acme","account_id":1,"amount":100,"currency":"TMT","transaction_type":"deposit"}`),
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex: %v", err)
+	}
+	return b
+}
+
+// Topics are registered in sorted order, so the Avro schema of
+// account-deposit gets ID 1 and the Protobuf one of account-transfer ID 2
+// whatever order they are configured in.
+const topicCodecs = "account-transfer=protobuf,account-deposit=avro"
+
+func TestGoldenValues(t *testing.T) {
+	tests := []struct {
+		topic  string
+		codec  string
+		golden string
+	}{
+		{
+			topic: "account-deposit",
+			codec: Avro,
+			golden: "00" + "00000001" + // magic byte, schema ID 1
+				"04" + "6531" + // event_id "e1"
+				"26" + "7472616e73616374696f6e2e6465706f736974" + // event_type
+				"04" + // schema_version 2
+				"80c0b5b08487af06" + // occurred_at in microseconds
+				"10" + "63617368666c6f77" + // producer "cashflow"
+				"08" + "61636d65" + // tenant_id "acme"
+				"02" + // account_id 1
+				"00" + // related_account_id: null branch
+				"0000000000005940" + // amount 100.0
+				"06" + "544d54" + // currency "TMT"
+				"0e" + "6465706f736974", // transaction_type "deposit"
+		},
+		{
+			topic: "account-transfer",
+			codec: Protobuf,
+			golden: "00" + "00000002" + // magic byte, schema ID 2
+				"00" + // message index path [0]
+				"0a02" + "6531" + // 1: event_id
+				"1213" + "7472616e73616374696f6e2e6465706f736974" + // 2: event_type
+				"1802" + // 3: schema_version
+				"2206" + "08c0e9d2d606" + // 4: occurred_at {seconds}
+				"2a08" + "63617368666c6f77" + // 5: producer
+				"321f" + // 6: payload
+				"0a04" + "61636d65" + // 1: tenant_id
+				"1001" + // 2: account_id
+				"21" + "0000000000005940" + // 4: amount
+				"2a03" + "544d54" + // 5: currency
+				"3207" + "6465706f736974", // 6: transaction_type
+		},
+	}
+
+	s := newSet(t, topicCodecs)
+	for _, tt := range tests {
+		t.Run(tt.codec, func(t *testing.T) {
+			c, err := s.Codec(tt.topic)
+			if err != nil {
+				t.Fatalf("Codec: %v", err)
+			}
+			if c.Name() != tt.codec {
+				t.Fatalf("codec of %s = %s, want %s", tt.topic, c.Name(), tt.codec)
+			}
+
+			golden := mustHex(t, tt.golden)
+			got, err := c.Encode(deposit())
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			if !bytes.Equal(got, golden) {
+				t.Fatalf("Encode =\n%x\nwant\n%x", got, golden)
+			}
+
+			env, err := s.Decode(golden)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			want := deposit()
+			if env.EventID != want.EventID || env.EventType != want.EventType ||
+				env.SchemaVersion != want.SchemaVersion || !env.OccurredAt.Equal(want.OccurredAt) {
+				t.Fatalf("Decode = %+v, want %+v", env, want)
+			}
+			if !jsonEqual(t, env.Payload, want.Payload) {
+				t.Fatalf("payload = %s, want %s", env.Payload, want.Payload)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	related := int64(2)
+	payloads := []entity.TransactionEvent{
+		{TenantID: "acme", AccountID: 1, Amount: 100, Currency: "TMT", TransactionType: entity.TransactionTypeDeposit},
+		{TenantID: "acme", AccountID: 1, Amount: 0.25, TransactionType: entity.TransactionTypeWithdrawal},
+		{TenantID: "acme", AccountID: 1, RelatedAccount: &related, Amount: 50, Currency: "TMT", TransactionType: entity.TransactionTypeTransfer},
+	}
+	// Avro keeps microseconds, Protobuf and JSON nanoseconds.
+	occurredAt := time.Date(2026, 10, 18, 12, 0, 0, 123456000, time.UTC)
+
+	s := newSet(t, "avro-topic=avro,protobuf-topic=protobuf,json-topic=json")
+	for _, topic := range []string{"avro-topic", "protobuf-topic", "json-topic"} {
+		for _, p := range payloads {
+			t.Run(topic+"/"+p.TransactionType, func(t *testing.T) {
+				env, err := event.NewTransaction(p, occurredAt)
+				if err != nil {
+					t.Fatalf("NewTransaction: %v", err)
+				}
+				data, err := s.Encode(topic, env)
+				if err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+				if isJSON := data[0] == '{'; isJSON != (topic == "json-topic") {
+					t.Fatalf("value %x of %s has the wrong format", data, topic)
+				}
+
+				decoded, err := s.Decode(data)
+				if err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				if decoded.EventID != env.EventID || !decoded.OccurredAt.Equal(occurredAt) {
+					t.Fatalf("Decode = %+v, want %+v", decoded, env)
+				}
+				got, err := decoded.Transaction()
+				if err != nil {
+					t.Fatalf("Transaction: %v", err)
+				}
+				if !reflect.DeepEqual(got, p) {
+					t.Fatalf("payload = %+v, want %+v", got, p)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeByWriterSchema(t *testing.T) {
+	// A value written while the topic used Avro is still read after the
+	// writer's set has gone, through the schema ID in its header.
+	dir := t.TempDir()
+	registry, err := schemaregistry.Open(filepath.Join(dir, "registry.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	writer, err := NewSet(config.KafkaConfig{Codec: Avro}, registry)
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	data, err := writer.Encode("account-deposit", deposit())
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	other, err := schemaregistry.Open(filepath.Join(dir, "registry.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	reader, err := NewSet(config.KafkaConfig{Codec: JSON}, other)
+	if err != nil {
+		t.Fatalf("NewSet: %v", err)
+	}
+	env, err := reader.Decode(data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if env.EventID != "e1" {
+		t.Fatalf("event ID = %q, want e1", env.EventID)
+	}
+}
+
+func TestDecodeRejectsMalformedValues(t *testing.T) {
+	s := newSet(t, topicCodecs)
+	if _, err := s.Codec("account-deposit"); err != nil {
+		t.Fatalf("Codec: %v", err)
+	}
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"short header", "000000"},
+		{"unknown schema ID", "0000000063" + "00"},
+		{"truncated avro", "0000000001" + "04" + "65"},
+		{"trailing avro bytes", "0000000001" + "04" + "6531" + "00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Decode(mustHex(t, tt.value)); !errors.Is(err, event.ErrInvalid) {
+				t.Fatalf("Decode error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestWireHeader(t *testing.T) {
+	header := writeHeader(0x01020304)
+	if want := []byte{0, 1, 2, 3, 4}; !bytes.Equal(header, want) {
+		t.Fatalf("writeHeader = %x, want %x", header, want)
+	}
+	id, body, err := readHeader(append(header, 0xaa))
+	if err != nil || id != 0x01020304 || !bytes.Equal(body, []byte{0xaa}) {
+		t.Fatalf("readHeader = %d, %x, %v", id, body, err)
+	}
+	if _, _, err := readHeader([]byte{1, 0, 0, 0, 1}); !errors.Is(err, event.ErrInvalid) {
+		t.Fatalf("readHeader of a wrong magic byte: %v, want ErrInvalid", err)
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(x, y)
+}
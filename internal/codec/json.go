@@ -0,0 +1,26 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/serikdev/CashFlow/internal/event"
+)
+
+// jsonCodec writes the envelope as plain JSON, without a wire format header,
+// which is what consumers predating the codecs expect.
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return JSON }
+
+func (jsonCodec) Encode(env *event.Envelope) ([]byte, error) {
+	return json.Marshal(env)
+}
+
+func decodeJSON(v interface{}) (*event.Envelope, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", event.ErrInvalid, err)
+	}
+	return event.Decode(data)
+}
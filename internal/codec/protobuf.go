@@ -0,0 +1,193 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/schemaregistry"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const timestampName = "google.protobuf.Timestamp"
+
+// protobufCodec writes the first message of a registered .proto file. The
+// schema is compiled at run time, so a registered schema can be read
+// without generated code.
+type protobufCodec struct {
+	id      int
+	message protoreflect.MessageDescriptor
+}
+
+func newProtobufCodec(s schemaregistry.Schema) (*protobufCodec, error) {
+	const name = "schema.proto"
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{name: s.Schema}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf schema: %w", err)
+	}
+	messages := files[0].Messages()
+	if messages.Len() == 0 {
+		return nil, fmt.Errorf("protobuf schema has no message")
+	}
+	return &protobufCodec{id: s.ID, message: messages.Get(0)}, nil
+}
+
+func (c *protobufCodec) Name() string { return Protobuf }
+
+func (c *protobufCodec) Encode(env *event.Envelope) ([]byte, error) {
+	r, err := toRecord(env)
+	if err != nil {
+		return nil, err
+	}
+	m := dynamicpb.NewMessage(c.message)
+	if err := setMessage(m, r); err != nil {
+		return nil, err
+	}
+
+	// A single zero stands for the message index path [0], the first
+	// message of the file.
+	// Deterministic writes the fields of a dynamic message in field number
+	// order, so an envelope always encodes to the same bytes.
+	b := append(writeHeader(c.id), 0)
+	return proto.MarshalOptions{Deterministic: true}.MarshalAppend(b, m)
+}
+
+func (c *protobufCodec) decode(data []byte) (*event.Envelope, error) {
+	md, body, err := c.messageAt(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", event.ErrInvalid, err)
+	}
+	m := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(body, m); err != nil {
+		return nil, fmt.Errorf("%w: %v", event.ErrInvalid, err)
+	}
+	return fromRecord(readMessage(m))
+}
+
+// messageAt resolves the message index path that follows the wire format header.
+func (c *protobufCodec) messageAt(data []byte) (protoreflect.MessageDescriptor, []byte, error) {
+	n, data, err := readVarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n == 0 {
+		return c.message, data, nil
+	}
+
+	messages := c.message.ParentFile().Messages()
+	var md protoreflect.MessageDescriptor
+	for i := int64(0); i < n; i++ {
+		var index int64
+		if index, data, err = readVarint(data); err != nil {
+			return nil, nil, err
+		}
+		if index < 0 || int(index) >= messages.Len() {
+			return nil, nil, fmt.Errorf("message index %d out of range", index)
+		}
+		md = messages.Get(int(index))
+		messages = md.Messages()
+	}
+	return md, data, nil
+}
+
+func setMessage(m protoreflect.Message, r record) error {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		v := r[string(fd.Name())]
+		if v == nil {
+			continue
+		}
+		pv, err := protoValue(m, fd, v)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fd.Name(), err)
+		}
+		m.Set(fd, pv)
+	}
+	return nil
+}
+
+func protoValue(m protoreflect.Message, fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if s, ok := v.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+	case protoreflect.BoolKind:
+		if b, ok := v.(bool); ok {
+			return protoreflect.ValueOfBool(b), nil
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if n, ok := v.(int64); ok {
+			return protoreflect.ValueOfInt64(n), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if n, ok := v.(int64); ok {
+			return protoreflect.ValueOfInt32(int32(n)), nil
+		}
+	case protoreflect.DoubleKind:
+		if f, ok := v.(float64); ok {
+			return protoreflect.ValueOfFloat64(f), nil
+		}
+	case protoreflect.FloatKind:
+		if f, ok := v.(float64); ok {
+			return protoreflect.ValueOfFloat32(float32(f)), nil
+		}
+	case protoreflect.MessageKind:
+		sub := m.NewField(fd).Message()
+		switch x := v.(type) {
+		case time.Time:
+			if fd.Message().FullName() == timestampName {
+				sub.Set(sub.Descriptor().Fields().ByName("seconds"), protoreflect.ValueOfInt64(x.Unix()))
+				sub.Set(sub.Descriptor().Fields().ByName("nanos"), protoreflect.ValueOfInt32(int32(x.Nanosecond())))
+				return protoreflect.ValueOfMessage(sub), nil
+			}
+		case record:
+			if err := setMessage(sub, x); err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfMessage(sub), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot write %T as %s", v, fd.Kind())
+}
+
+func readMessage(m protoreflect.Message) record {
+	r := make(record)
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.HasPresence() && !m.Has(fd) {
+			r[string(fd.Name())] = nil
+			continue
+		}
+		v := m.Get(fd)
+		switch fd.Kind() {
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+			r[string(fd.Name())] = v.Int()
+		case protoreflect.FloatKind:
+			r[string(fd.Name())] = v.Float()
+		case protoreflect.MessageKind:
+			sub := v.Message()
+			if fd.Message().FullName() == timestampName {
+				seconds := sub.Get(sub.Descriptor().Fields().ByName("seconds")).Int()
+				nanos := sub.Get(sub.Descriptor().Fields().ByName("nanos")).Int()
+				r[string(fd.Name())] = time.Unix(seconds, nanos).UTC()
+			} else {
+				r[string(fd.Name())] = readMessage(sub)
+			}
+		default:
+			r[string(fd.Name())] = v.Interface()
+		}
+	}
+	return r
+}
@@ -0,0 +1,43 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/serikdev/CashFlow/internal/event"
+)
+
+// The Confluent wire format: a zero magic byte and the big endian schema ID
+// precede the encoded value.
+const (
+	magicByte  = 0
+	headerSize = 5
+)
+
+func writeHeader(id int) []byte {
+	b := make([]byte, headerSize, 64)
+	b[0] = magicByte
+	binary.BigEndian.PutUint32(b[1:], uint32(id))
+	return b
+}
+
+func readHeader(data []byte) (int, []byte, error) {
+	if len(data) < headerSize || data[0] != magicByte {
+		return 0, nil, fmt.Errorf("%w: missing wire format header", event.ErrInvalid)
+	}
+	return int(binary.BigEndian.Uint32(data[1:headerSize])), data[headerSize:], nil
+}
+
+// appendVarint and readVarint handle the zig-zag varints of Avro longs and
+// of the Protobuf message indexes.
+func appendVarint(b []byte, v int64) []byte {
+	return binary.AppendVarint(b, v)
+}
+
+func readVarint(data []byte) (int64, []byte, error) {
+	v, n := binary.Varint(data)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: malformed varint", event.ErrInvalid)
+	}
+	return v, data[n:], nil
+}
@@ -2,17 +2,33 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 )
 
 type Config struct {
-	DatabaseURL  string
-	DBConfig     DBConfig
-	LoggerConfig LoggerConfig
-	KafkaConfig  KafkaConfig
+	DatabaseURL    string
+	DBConfig       DBConfig
+	LoggerConfig   LoggerConfig
+	KafkaConfig    KafkaConfig
+	AuthConfig     AuthConfig
+	WebhookConfig  WebhookConfig
+	SSEConfig      SSEConfig
+	GRPCConfig     GRPCConfig
+	MetricsConfig  MetricsConfig
+	TracingConfig  TracingConfig
+	HealthConfig   HealthConfig
+	ShutdownConfig ShutdownConfig
+	LedgerConfig   LedgerConfig
+	BusConfig      BusConfig
+	ConsumerConfig ConsumerConfig
+	// TransactionMode is "async", where consumers apply the published
+	// transaction events, or "sync", where requests apply them directly.
+	TransactionMode string
 }
 
 type DBConfig struct {
@@ -29,6 +45,132 @@ type LoggerConfig struct {
 }
 type KafkaConfig struct {
 	Brokers []string
+	// Codec is the value encoding of topics missing from TopicCodecs:
+	// "json", "avro" or "protobuf".
+	Codec string
+	// TopicCodecs is a comma separated list of topic=codec entries.
+	TopicCodecs string
+	// SchemaRegistryFile stores the schemas of the Avro and Protobuf values.
+	SchemaRegistryFile string
+	Topics             TopicsConfig
+}
+
+// Topic provisioning modes.
+const (
+	ProvisionCreate   = "create"
+	ProvisionValidate = "validate"
+	ProvisionOff      = "off"
+)
+
+// TopicsConfig names the transaction topics and sets how they are created.
+// Topic names given anywhere in the configuration are without Prefix.
+type TopicsConfig struct {
+	// Prefix is prepended to every topic name, e.g. "staging.".
+	Prefix   string
+	Deposit  string
+	Withdraw string
+	Transfer string
+	// Partitions, ReplicationFactor and Retention apply to created topics
+	// and are checked on existing ones.
+	Partitions        int
+	ReplicationFactor int
+	Retention         time.Duration
+	// Provision is "create", "validate" or "off".
+	Provision string
+}
+
+// Name returns the full name of topic.
+func (c TopicsConfig) Name(topic string) string {
+	return c.Prefix + topic
+}
+
+// Transactions returns the full names of the deposit, withdraw and transfer
+// topics.
+func (c TopicsConfig) Transactions() []string {
+	return []string{c.Name(c.Deposit), c.Name(c.Withdraw), c.Name(c.Transfer)}
+}
+
+type BusConfig struct {
+	// Driver is "kafka", "postgres" or "memory".
+	Driver string
+	// PollInterval, BatchSize and Lease tune the Postgres queue.
+	PollInterval time.Duration
+	BatchSize    int
+	Lease        time.Duration
+}
+
+type ConsumerConfig struct {
+	// Topics is the comma separated list of topics the consumer reads,
+	// by default the transaction topics.
+	Topics string
+	// Workers is how many messages of a topic are processed at once;
+	// messages of one account still run in order.
+	Workers int
+	// QueueSize bounds the fetched but unfinished messages of a topic.
+	QueueSize int
+	// TopicWorkers overrides Workers as a comma separated list of
+	// topic=workers entries.
+	TopicWorkers string
+}
+
+type AuthConfig struct {
+	// APIKeys is a comma separated list of key:subject:role[:tenant] entries.
+	APIKeys string
+}
+
+type GRPCConfig struct {
+	Addr string
+}
+
+// MetricsConfig is the internal listener of GET /metrics, kept off the
+// public API port.
+type MetricsConfig struct {
+	Addr string
+}
+
+type TracingConfig struct {
+	// Exporter is "none", "stdout" or "otlp".
+	Exporter       string
+	OTLPEndpoint   string
+	OTLPInsecure   bool
+	SampleRatio    float64
+	ServiceName    string
+	ServiceVersion string
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each readiness dependency check.
+	CheckTimeout time.Duration
+}
+
+type LedgerConfig struct {
+	// SigningKey is a base64 encoded 32-byte Ed25519 seed. Checkpoints are
+	// disabled when it is empty.
+	SigningKey         string
+	CheckpointInterval time.Duration
+	// ReconcileInterval is how often balances are reconciled against the
+	// transaction log. Zero disables the periodic job.
+	ReconcileInterval time.Duration
+}
+
+type ShutdownConfig struct {
+	// Timeout bounds the whole shutdown sequence.
+	Timeout time.Duration
+	// DrainDelay is how long the servers keep serving after /readyz starts
+	// failing, so that load balancers stop routing to the instance first.
+	DrainDelay time.Duration
+}
+
+type SSEConfig struct {
+	HeartbeatInterval time.Duration
+}
+
+type WebhookConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	Timeout        time.Duration
+	PollInterval   time.Duration
+	BatchSize      int
 }
 
 func LoadConfig() *Config {
@@ -51,7 +193,72 @@ func LoadConfig() *Config {
 			LogLevel: getEnv("LOG_LEVEL", "debug"),
 		},
 		KafkaConfig: KafkaConfig{
-			Brokers: strings.Split(getEnv("KAFKA_BROKER", "localhost:9092"), ","),
+			Brokers:            strings.Split(getEnv("KAFKA_BROKER", "localhost:9092"), ","),
+			Codec:              getEnv("KAFKA_CODEC", "json"),
+			TopicCodecs:        getEnv("KAFKA_TOPIC_CODECS", ""),
+			SchemaRegistryFile: getEnv("SCHEMA_REGISTRY_FILE", "schema-registry.json"),
+			Topics: TopicsConfig{
+				Prefix:            getEnv("KAFKA_TOPIC_PREFIX", ""),
+				Deposit:           getEnv("KAFKA_TOPIC_DEPOSIT", "account-deposit"),
+				Withdraw:          getEnv("KAFKA_TOPIC_WITHDRAW", "account-withdraw"),
+				Transfer:          getEnv("KAFKA_TOPIC_TRANSFER", "account-transfer"),
+				Partitions:        getEnvInt("KAFKA_TOPIC_PARTITIONS", 3),
+				ReplicationFactor: getEnvInt("KAFKA_TOPIC_REPLICATION_FACTOR", 1),
+				Retention:         getEnvDuration("KAFKA_TOPIC_RETENTION", 7*24*time.Hour),
+				Provision:         getEnv("KAFKA_TOPIC_PROVISION", ProvisionCreate),
+			},
+		},
+		AuthConfig: AuthConfig{
+			APIKeys: getEnv("AUTH_API_KEYS", ""),
+		},
+		WebhookConfig: WebhookConfig{
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			InitialBackoff: getEnvDuration("WEBHOOK_INITIAL_BACKOFF", 10*time.Second),
+			Timeout:        getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			PollInterval:   getEnvDuration("WEBHOOK_POLL_INTERVAL", 2*time.Second),
+			BatchSize:      getEnvInt("WEBHOOK_BATCH_SIZE", 20),
+		},
+		GRPCConfig: GRPCConfig{
+			Addr: getEnv("GRPC_ADDR", ":50051"),
+		},
+		MetricsConfig: MetricsConfig{
+			Addr: getEnv("METRICS_ADDR", ":9090"),
+		},
+		SSEConfig: SSEConfig{
+			HeartbeatInterval: getEnvDuration("SSE_HEARTBEAT_INTERVAL", 15*time.Second),
+		},
+		TracingConfig: TracingConfig{
+			Exporter:       getEnv("TRACING_EXPORTER", "none"),
+			OTLPEndpoint:   getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+			OTLPInsecure:   getEnv("OTEL_EXPORTER_OTLP_INSECURE", "true") == "true",
+			SampleRatio:    getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+			ServiceName:    getEnv("SERVICE_NAME", "CashFlow"),
+			ServiceVersion: getEnv("APP_VERSION", ""),
+		},
+		HealthConfig: HealthConfig{
+			CheckTimeout: getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		},
+		LedgerConfig: LedgerConfig{
+			SigningKey:         getEnv("LEDGER_SIGNING_KEY", ""),
+			CheckpointInterval: getEnvDuration("LEDGER_CHECKPOINT_INTERVAL", time.Hour),
+			ReconcileInterval:  getEnvDuration("RECONCILE_INTERVAL", 24*time.Hour),
+		},
+		ShutdownConfig: ShutdownConfig{
+			Timeout:    getEnvDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+			DrainDelay: getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		},
+		TransactionMode: getEnv("TRANSACTION_MODE", "async"),
+		BusConfig: BusConfig{
+			Driver:       getEnv("MESSAGE_BUS", "kafka"),
+			PollInterval: getEnvDuration("BUS_POLL_INTERVAL", 500*time.Millisecond),
+			BatchSize:    getEnvInt("BUS_BATCH_SIZE", 50),
+			Lease:        getEnvDuration("BUS_LEASE", 30*time.Second),
+		},
+		ConsumerConfig: ConsumerConfig{
+			Topics:       getEnv("CONSUMER_TOPICS", ""),
+			Workers:      getEnvInt("CONSUMER_WORKERS", 4),
+			QueueSize:    getEnvInt("CONSUMER_QUEUE_SIZE", 100),
+			TopicWorkers: getEnv("CONSUMER_TOPIC_WORKERS", ""),
 		},
 	}
 }
@@ -62,3 +269,42 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.WithError(err).Errorf("Invalid integer in %s, using default", key)
+		return defaultValue
+	}
+	return n
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		logrus.WithError(err).Errorf("Invalid number in %s, using default", key)
+		return defaultValue
+	}
+	return f
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithError(err).Errorf("Invalid duration in %s, using default", key)
+		return defaultValue
+	}
+	return d
+}
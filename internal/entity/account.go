@@ -4,6 +4,7 @@ import "time"
 
 type Account struct {
 	ID        int        `json:"id"`
+	TenantID  string     `json:"tenant_id"`
 	Balance   float64    `json:"balance"`
 	Currency  string     `json:"currency"`
 	IsLocked  bool       `json:"is_locked"`
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+type AccountRelation string
+
+const (
+	RelationViewer    AccountRelation = "viewer"
+	RelationSignatory AccountRelation = "signatory"
+	RelationOwner     AccountRelation = "owner"
+)
+
+var relationRank = map[AccountRelation]int{
+	RelationViewer:    1,
+	RelationSignatory: 2,
+	RelationOwner:     3,
+}
+
+func (r AccountRelation) Valid() bool {
+	_, ok := relationRank[r]
+	return ok
+}
+
+// Includes reports whether r grants at least the rights of other,
+// e.g. an owner is also a signatory and a viewer.
+func (r AccountRelation) Includes(other AccountRelation) bool {
+	return relationRank[r] > 0 && relationRank[r] >= relationRank[other]
+}
+
+type AccountAccess struct {
+	TenantID  string          `json:"tenant_id"`
+	AccountID int64           `json:"account_id"`
+	Subject   string          `json:"subject"`
+	Relation  AccountRelation `json:"relation"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,9 @@
+package entity
+
+// AccountActivity is pushed to live subscribers of an account after a transaction is applied.
+type AccountActivity struct {
+	AccountID   int64        `json:"account_id"`
+	Balance     *float64     `json:"balance,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Replayed    bool         `json:"replayed,omitempty"`
+}
@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actions. Transaction requests are recorded when accepted and again
+// when the consumer completes or rejects them; both share the request ID.
+const (
+	AuditAccountCreate        = "account.create"
+	AuditAccountDelete        = "account.delete"
+	AuditAccountLock          = "account.lock"
+	AuditAccountUnlock        = "account.unlock"
+	AuditAccountShare         = "account.share"
+	AuditTransactionDeposit   = "transaction.deposit"
+	AuditTransactionWithdraw  = "transaction.withdraw"
+	AuditTransactionTransfer  = "transaction.transfer"
+	AuditTransactionCompleted = "transaction.completed"
+	AuditTransactionFailed    = "transaction.failed"
+	AuditWebhookSubscribe     = "webhook.subscribe"
+	AuditWebhookUnsubscribe   = "webhook.unsubscribe"
+	AuditWebhookRedeliver     = "webhook.redeliver"
+	AuditCorrectionOpen       = "correction.open"
+	AuditCorrectionApprove    = "correction.approve"
+	AuditCorrectionReject     = "correction.reject"
+)
+
+// AuditEntry is one append-only record of a state-changing operation.
+type AuditEntry struct {
+	ID            int64           `json:"id"`
+	TenantID      string          `json:"tenant_id"`
+	ActorSubject  string          `json:"actor_subject"`
+	ActorRole     string          `json:"actor_role"`
+	Action        string          `json:"action" example:"account.lock"`
+	AccountID     *int64          `json:"account_id,omitempty"`
+	TransactionID *int64          `json:"transaction_id,omitempty"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	RequestID     string          `json:"request_id,omitempty"`
+	SourceIP      string          `json:"source_ip,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows an audit log query. Zero values do not filter.
+type AuditFilter struct {
+	ActorSubject  string
+	Action        string
+	AccountID     int64
+	TransactionID int64
+	RequestID     string
+	From          time.Time
+	To            time.Time
+}
@@ -0,0 +1,42 @@
+package entity
+
+import "time"
+
+// ChainHead is the last hashed transaction of an account's chain.
+type ChainHead struct {
+	AccountID     int64  `json:"account_id"`
+	TransactionID int64  `json:"transaction_id"`
+	Hash          string `json:"hash"`
+}
+
+// LedgerCheckpoint seals the heads of every chain of a tenant at a point in
+// time. Signature is an Ed25519 signature of Root.
+type LedgerCheckpoint struct {
+	ID        int64       `json:"id"`
+	TenantID  string      `json:"tenant_id"`
+	Heads     []ChainHead `json:"heads"`
+	Root      string      `json:"root"`
+	KeyID     string      `json:"key_id"`
+	Signature string      `json:"signature"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ChainBreak describes the first link of a chain that failed verification.
+type ChainBreak struct {
+	AccountID     int64  `json:"account_id"`
+	TransactionID int64  `json:"transaction_id"`
+	Reason        string `json:"reason" example:"hash does not match transaction content"`
+	Expected      string `json:"expected,omitempty"`
+	Actual        string `json:"actual,omitempty"`
+}
+
+// ChainReport is the result of walking the transaction chains.
+type ChainReport struct {
+	Valid               bool `json:"valid"`
+	AccountsChecked     int  `json:"accounts_checked"`
+	TransactionsChecked int  `json:"transactions_checked"`
+	// Unsealed counts transactions persisted before hashing was introduced.
+	Unsealed     int         `json:"unsealed"`
+	CheckpointID int64       `json:"checkpoint_id,omitempty"`
+	FirstBreak   *ChainBreak `json:"first_break,omitempty"`
+}
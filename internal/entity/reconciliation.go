@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+const (
+	CorrectionPending  = "pending"
+	CorrectionApproved = "approved"
+	CorrectionRejected = "rejected"
+)
+
+// BalanceMismatch is an account whose stored balance differs from the
+// balance recomputed from its opening balance and transaction log.
+type BalanceMismatch struct {
+	AccountID      int64   `json:"account_id"`
+	Currency       string  `json:"currency"`
+	Balance        float64 `json:"balance"`
+	Expected       float64 `json:"expected_balance"`
+	Difference     float64 `json:"difference"`
+	OpeningBalance float64 `json:"opening_balance"`
+	Deposits       float64 `json:"deposits"`
+	Withdrawals    float64 `json:"withdrawals"`
+	TransfersOut   float64 `json:"transfers_out"`
+	TransfersIn    float64 `json:"transfers_in"`
+	Corrections    float64 `json:"corrections"`
+	Transactions   int     `json:"transactions"`
+	CorrectionID   *int64  `json:"correction_id,omitempty"`
+}
+
+type ReconciliationReport struct {
+	AccountsChecked   int               `json:"accounts_checked"`
+	Mismatches        []BalanceMismatch `json:"mismatches"`
+	CorrectionsOpened int               `json:"corrections_opened"`
+	StartedAt         time.Time         `json:"started_at"`
+	FinishedAt        time.Time         `json:"finished_at"`
+}
+
+// BalanceCorrection proposes logging Amount as a correction transaction so
+// that the transaction log explains the stored balance. It takes effect
+// only once approved by someone other than its requester.
+type BalanceCorrection struct {
+	ID              int64      `json:"id"`
+	TenantID        string     `json:"tenant_id"`
+	AccountID       int64      `json:"account_id"`
+	Amount          float64    `json:"amount"`
+	Balance         float64    `json:"balance"`
+	ExpectedBalance float64    `json:"expected_balance"`
+	Status          string     `json:"status" example:"pending"`
+	RequestedBy     string     `json:"requested_by"`
+	DecidedBy       *string    `json:"decided_by,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	TransactionID   *int64     `json:"transaction_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,73 @@
+package entity
+
+import "time"
+
+// ReplayAccount is an account as a replay starts from it. Baseline is the
+// balance before the first replayed event; Balance is the live balance the
+// replayed one is compared with.
+type ReplayAccount struct {
+	TenantID  string     `json:"tenant_id"`
+	ID        int64      `json:"id"`
+	Currency  string     `json:"currency"`
+	Baseline  float64    `json:"baseline"`
+	Balance   float64    `json:"balance"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ReplayTransaction is a transaction rebuilt from an event, or a live
+// transaction when Topic is empty.
+type ReplayTransaction struct {
+	ID              int64     `json:"id,omitempty"`
+	TenantID        string    `json:"tenant_id"`
+	AccountID       int64     `json:"account_id"`
+	RelatedAccount  *int64    `json:"related_account_id,omitempty"`
+	Amount          float64   `json:"amount"`
+	TransactionType string    `json:"transaction_type"`
+	CreatedAt       time.Time `json:"created_at"`
+	RequestID       string    `json:"request_id,omitempty"`
+	InitiatedBy     string    `json:"initiated_by,omitempty"`
+	Topic           string    `json:"topic,omitempty"`
+	Partition       int       `json:"partition,omitempty"`
+	Offset          int64     `json:"offset,omitempty"`
+}
+
+// ReplayRejection is an event the replay did not apply, as the consumer
+// would have rejected it.
+type ReplayRejection struct {
+	Topic           string  `json:"topic"`
+	Partition       int     `json:"partition"`
+	Offset          int64   `json:"offset"`
+	TenantID        string  `json:"tenant_id,omitempty"`
+	AccountID       int64   `json:"account_id,omitempty"`
+	TransactionType string  `json:"transaction_type,omitempty"`
+	Amount          float64 `json:"amount,omitempty"`
+	Reason          string  `json:"reason"`
+}
+
+type BalanceDiff struct {
+	TenantID   string  `json:"tenant_id"`
+	AccountID  int64   `json:"account_id"`
+	Live       float64 `json:"live"`
+	Replayed   float64 `json:"replayed"`
+	Difference float64 `json:"difference"`
+}
+
+// ReplayReport compares a replay with the live tables. MissingInLive are
+// replayed transactions the live log lacks; UnexpectedInLive are live
+// transactions in the replayed period that no applied event explains.
+type ReplayReport struct {
+	Schema           string              `json:"schema"`
+	Start            string              `json:"start"`
+	Cutoff           *time.Time          `json:"cutoff,omitempty"`
+	Messages         int                 `json:"messages"`
+	Applied          int                 `json:"applied"`
+	Rejected         int                 `json:"rejected"`
+	AccountsCompared int                 `json:"accounts_compared"`
+	Consistent       bool                `json:"consistent"`
+	BalanceDiffs     []BalanceDiff       `json:"balance_diffs"`
+	MissingInLive    []ReplayTransaction `json:"missing_in_live"`
+	UnexpectedInLive []ReplayTransaction `json:"unexpected_in_live"`
+	StartedAt        time.Time           `json:"started_at"`
+	FinishedAt       time.Time           `json:"finished_at"`
+}
@@ -2,11 +2,50 @@ package entity
 
 import "time"
 
+// Transaction types, as stored in transactions.transaction_type.
+const (
+	TransactionTypeDeposit    = "deposit"
+	TransactionTypeWithdrawal = "withdrawal"
+	TransactionTypeTransfer   = "transfer"
+	// TransactionTypeCorrection is logged by an approved balance correction.
+	TransactionTypeCorrection = "correction"
+)
+
 type Transaction struct {
 	ID              int        `json:"id"`
+	TenantID        string     `json:"tenant_id"`
 	AccountID       int        `json:"account_id"`
+	RelatedAccount  *int       `json:"related_account_id,omitempty"`
 	Amount          float64    `json:"amount"`
 	TransactionType string     `json:"transaction_type"`
 	CreatedAt       time.Time  `json:"created_at"`
 	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	RequestID       string     `json:"request_id,omitempty"`
+	TraceID         string     `json:"trace_id,omitempty"`
+	InitiatedBy     string     `json:"initiated_by,omitempty"`
+	PrevHash        string     `json:"prev_hash,omitempty"`
+	Hash            string     `json:"hash,omitempty"`
+	// EventID is the event the transaction was submitted as. It is only
+	// set on transactions returned by a submission.
+	EventID string `json:"event_id,omitempty"`
+}
+
+// Transaction result statuses.
+const (
+	ResultPending   = "pending"
+	ResultCompleted = "completed"
+	ResultFailed    = "failed"
+)
+
+// TransactionResult is the outcome of a submitted transaction event. A
+// completed result holds the stored transaction, a failed one the code and
+// message of the error it was rejected with.
+type TransactionResult struct {
+	EventID     string       `json:"event_id"`
+	AccountID   int64        `json:"account_id,omitempty"`
+	Status      string       `json:"status" example:"completed"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	ErrorCode   string       `json:"error_code,omitempty" example:"INSUFFICIENT_FUNDS"`
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   *time.Time   `json:"created_at,omitempty"`
 }
@@ -1,11 +1,13 @@
 package entity
 
-import "time"
-
+// TransactionEvent is the payload of the events on the transaction topics.
+// Its schema is api/events/transaction.<type>.v2.schema.json; the envelope
+// around it is defined in package event.
 type TransactionEvent struct {
-	AccountID       int64     `json:"account_id"`
-	RelatedAccount  *int64    `json:"related_account,omitempty"`
-	Amount          float64   `json:"amount"`
-	TransactionType string    `json:"transaction_type"`
-	CreatedAt       time.Time `json:"created_at"`
+	TenantID        string  `json:"tenant_id"`
+	AccountID       int64   `json:"account_id"`
+	RelatedAccount  *int64  `json:"related_account_id,omitempty"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency,omitempty"`
+	TransactionType string  `json:"transaction_type"`
 }
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventTransactionCompleted = "transaction.completed"
+	EventTransactionFailed    = "transaction.failed"
+	EventAccountLocked        = "account.locked"
+	EventAccountClosed        = "account.closed"
+)
+
+var WebhookEventTypes = []string{
+	EventTransactionCompleted,
+	EventTransactionFailed,
+	EventAccountLocked,
+	EventAccountClosed,
+}
+
+type WebhookSubscription struct {
+	ID         int       `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	TenantID       string          `json:"tenant_id"`
+	SubscriptionID int             `json:"subscription_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+
+	// URL and Secret are loaded with the subscription when a delivery is claimed.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookEvent is the body POSTed to subscribers.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	TenantID  string      `json:"tenant_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
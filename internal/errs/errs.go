@@ -0,0 +1,112 @@
+// Package errs defines the domain error kinds shared by repositories,
+// usecases and transports. Transports map a kind to a status code with
+// errors.Is and expose Code as a stable machine-readable error code.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrLocked            = errors.New("account is locked")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrValidation        = errors.New("validation failed")
+	ErrConflict          = errors.New("conflict")
+)
+
+const (
+	CodeNotFound          = "NOT_FOUND"
+	CodeAccountLocked     = "ACCOUNT_LOCKED"
+	CodeInsufficientFunds = "INSUFFICIENT_FUNDS"
+	CodeValidation        = "VALIDATION_FAILED"
+	CodeConflict          = "CONFLICT"
+	CodeInternal          = "INTERNAL"
+)
+
+// Error is a domain error of a given kind with a human readable message and an optional cause.
+type Error struct {
+	kind error
+	msg  string
+	err  error
+}
+
+func (e *Error) Error() string {
+	if e.err != nil {
+		return e.msg + ": " + e.err.Error()
+	}
+	return e.msg
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+func New(kind error, format string, args ...interface{}) error {
+	return &Error{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// Wrap attaches a kind and message to a lower level cause.
+func Wrap(kind, err error, format string, args ...interface{}) error {
+	return &Error{kind: kind, msg: fmt.Sprintf(format, args...), err: err}
+}
+
+func NotFound(format string, args ...interface{}) error {
+	return New(ErrNotFound, format, args...)
+}
+
+func Locked(format string, args ...interface{}) error {
+	return New(ErrLocked, format, args...)
+}
+
+func InsufficientFunds(format string, args ...interface{}) error {
+	return New(ErrInsufficientFunds, format, args...)
+}
+
+func Validation(format string, args ...interface{}) error {
+	return New(ErrValidation, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) error {
+	return New(ErrConflict, format, args...)
+}
+
+// Code returns the stable error code for the kind of err.
+func Code(err error) string {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, ErrLocked):
+		return CodeAccountLocked
+	case errors.Is(err, ErrInsufficientFunds):
+		return CodeInsufficientFunds
+	case errors.Is(err, ErrValidation):
+		return CodeValidation
+	case errors.Is(err, ErrConflict):
+		return CodeConflict
+	default:
+		return CodeInternal
+	}
+}
+
+// FromCode rebuilds an error of the kind behind code, for errors that were
+// stored by their Code and message. Unknown codes give a plain error.
+func FromCode(code, message string) error {
+	kinds := map[string]error{
+		CodeNotFound:          ErrNotFound,
+		CodeAccountLocked:     ErrLocked,
+		CodeInsufficientFunds: ErrInsufficientFunds,
+		CodeValidation:        ErrValidation,
+		CodeConflict:          ErrConflict,
+	}
+	kind, ok := kinds[code]
+	if !ok {
+		return errors.New(message)
+	}
+	return &Error{kind: kind, msg: message}
+}
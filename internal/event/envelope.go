@@ -0,0 +1,147 @@
+// Package event defines the envelope of the messages published on the
+// transaction topics, upcasts payloads of older schema versions and checks
+// both against the JSON Schemas in api/events. The producer and the consumer
+// go through the same checks, so a message one accepts the other can read.
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+)
+
+// Event types of the transaction topics.
+const (
+	TypeDeposit    = "transaction.deposit"
+	TypeWithdrawal = "transaction.withdrawal"
+	TypeTransfer   = "transaction.transfer"
+)
+
+// CurrentVersion is the payload schema version published by this build.
+const CurrentVersion = 2
+
+// Producer names this service in the envelopes it publishes.
+const Producer = "cashflow"
+
+var ErrInvalid = errors.New("invalid event")
+
+// Envelope wraps every event. SchemaVersion is the version of Payload, which
+// is decoded according to EventType.
+type Envelope struct {
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Producer      string          `json:"producer"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewTransaction wraps a transaction event in an envelope of the current
+// version and checks it against its schema.
+func NewTransaction(e entity.TransactionEvent, occurredAt time.Time) (*Envelope, error) {
+	eventType, err := transactionEventType(e.TransactionType)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding event payload: %w", err)
+	}
+
+	env := &Envelope{
+		EventID:       newID(),
+		EventType:     eventType,
+		SchemaVersion: CurrentVersion,
+		OccurredAt:    occurredAt.UTC(),
+		Producer:      Producer,
+		Payload:       payload,
+	}
+	if err := env.validate(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Decode parses a message value and upcasts its payload to CurrentVersion.
+// Values published before the envelope existed are read as version 1
+// payloads without an event ID; the caller supplies one.
+func Decode(data []byte) (*Envelope, error) {
+	var probe struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var env Envelope
+	if probe.SchemaVersion == 0 {
+		env = Envelope{SchemaVersion: 1, Payload: json.RawMessage(data)}
+	} else if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if env.SchemaVersion > CurrentVersion {
+		return nil, fmt.Errorf("%w: schema version %d is newer than %d", ErrInvalid, env.SchemaVersion, CurrentVersion)
+	}
+
+	if err := upcast(&env); err != nil {
+		return nil, err
+	}
+	if env.EventID == "" {
+		// A version 1 value has no envelope to check.
+		if err := validate(payloadSchema(env.EventType, env.SchemaVersion), env.Payload); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		return &env, nil
+	}
+	if err := env.validate(); err != nil {
+		return nil, err
+	}
+	return &env, nil
+}
+
+// Transaction decodes the payload of a transaction event.
+func (e *Envelope) Transaction() (entity.TransactionEvent, error) {
+	var t entity.TransactionEvent
+	if err := json.Unmarshal(e.Payload, &t); err != nil {
+		return t, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return t, nil
+}
+
+// validate checks the envelope and its payload against their schemas.
+func (e *Envelope) validate() error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error encoding event: %w", err)
+	}
+	if err := validate(envelopeSchema, data); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := validate(payloadSchema(e.EventType, e.SchemaVersion), e.Payload); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return nil
+}
+
+func transactionEventType(transactionType string) (string, error) {
+	switch transactionType {
+	case entity.TransactionTypeDeposit:
+		return TypeDeposit, nil
+	case entity.TransactionTypeWithdrawal:
+		return TypeWithdrawal, nil
+	case entity.TransactionTypeTransfer:
+		return TypeTransfer, nil
+	}
+	return "", fmt.Errorf("%w: unknown transaction type %q", ErrInvalid, transactionType)
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
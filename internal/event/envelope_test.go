@@ -0,0 +1,247 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/tenant"
+)
+
+var occurredAt = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// transactionEvents are valid current payloads of every transaction type.
+var transactionEvents = []struct {
+	eventType string
+	payload   entity.TransactionEvent
+}{
+	{TypeDeposit, entity.TransactionEvent{
+		TenantID: "acme", AccountID: 1, Amount: 100, Currency: "TMT",
+		TransactionType: entity.TransactionTypeDeposit,
+	}},
+	{TypeWithdrawal, entity.TransactionEvent{
+		TenantID: "acme", AccountID: 1, Amount: 25.5,
+		TransactionType: entity.TransactionTypeWithdrawal,
+	}},
+	{TypeTransfer, entity.TransactionEvent{
+		TenantID: "acme", AccountID: 1, RelatedAccount: int64Ptr(2), Amount: 50, Currency: "TMT",
+		TransactionType: entity.TransactionTypeTransfer,
+	}},
+}
+
+// TestProducerConsumerRoundTrip checks that what the producer publishes the
+// consumer reads back unchanged.
+func TestProducerConsumerRoundTrip(t *testing.T) {
+	for _, tt := range transactionEvents {
+		t.Run(tt.eventType, func(t *testing.T) {
+			env, err := NewTransaction(tt.payload, occurredAt.In(time.FixedZone("TMT", 5*3600)))
+			if err != nil {
+				t.Fatalf("NewTransaction: %v", err)
+			}
+			if env.EventType != tt.eventType || env.SchemaVersion != CurrentVersion || env.Producer != Producer {
+				t.Fatalf("envelope = %s v%d by %s, want %s v%d by %s",
+					env.EventType, env.SchemaVersion, env.Producer, tt.eventType, CurrentVersion, Producer)
+			}
+			if len(env.EventID) != 32 {
+				t.Fatalf("event ID %q is not 32 hex characters", env.EventID)
+			}
+
+			data, err := json.Marshal(env)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			decoded, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if decoded.EventID != env.EventID || decoded.EventType != env.EventType ||
+				decoded.SchemaVersion != CurrentVersion || !decoded.OccurredAt.Equal(occurredAt) {
+				t.Fatalf("decoded envelope = %+v, want %+v", decoded, env)
+			}
+			payload, err := decoded.Transaction()
+			if err != nil {
+				t.Fatalf("Transaction: %v", err)
+			}
+			if !reflect.DeepEqual(payload, tt.payload) {
+				t.Fatalf("payload = %+v, want %+v", payload, tt.payload)
+			}
+		})
+	}
+}
+
+// TestPayloadsMatchSchemas checks the published envelopes and payloads
+// against the embedded JSON Schemas directly.
+func TestPayloadsMatchSchemas(t *testing.T) {
+	for _, tt := range transactionEvents {
+		t.Run(tt.eventType, func(t *testing.T) {
+			env, err := NewTransaction(tt.payload, occurredAt)
+			if err != nil {
+				t.Fatalf("NewTransaction: %v", err)
+			}
+			data, err := json.Marshal(env)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if err := validate(envelopeSchema, data); err != nil {
+				t.Errorf("envelope: %v", err)
+			}
+			if err := validate(payloadSchema(tt.eventType, CurrentVersion), env.Payload); err != nil {
+				t.Errorf("payload: %v", err)
+			}
+		})
+	}
+}
+
+func TestProducerRejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload entity.TransactionEvent
+	}{
+		{"zero amount", entity.TransactionEvent{
+			TenantID: "acme", AccountID: 1, TransactionType: entity.TransactionTypeDeposit,
+		}},
+		{"missing tenant", entity.TransactionEvent{
+			AccountID: 1, Amount: 1, TransactionType: entity.TransactionTypeDeposit,
+		}},
+		{"transfer without target", entity.TransactionEvent{
+			TenantID: "acme", AccountID: 1, Amount: 1, TransactionType: entity.TransactionTypeTransfer,
+		}},
+		{"deposit with target", entity.TransactionEvent{
+			TenantID: "acme", AccountID: 1, RelatedAccount: int64Ptr(2), Amount: 1,
+			TransactionType: entity.TransactionTypeDeposit,
+		}},
+		{"long currency", entity.TransactionEvent{
+			TenantID: "acme", AccountID: 1, Amount: 1, Currency: "TMTX",
+			TransactionType: entity.TransactionTypeWithdrawal,
+		}},
+		{"unknown type", entity.TransactionEvent{
+			TenantID: "acme", AccountID: 1, Amount: 1, TransactionType: "refund",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTransaction(tt.payload, occurredAt); !errors.Is(err, ErrInvalid) {
+				t.Fatalf("NewTransaction error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestConsumerRejectsInvalidMessages(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not JSON", `{`},
+		{"newer version", `{"event_id":"e1","event_type":"transaction.deposit","schema_version":3,
+			"occurred_at":"2026-10-18T12:00:00Z","producer":"cashflow",
+			"payload":{"tenant_id":"acme","account_id":1,"amount":1,"transaction_type":"deposit"}}`},
+		{"payload of another type", `{"event_id":"e1","event_type":"transaction.deposit","schema_version":2,
+			"occurred_at":"2026-10-18T12:00:00Z","producer":"cashflow",
+			"payload":{"tenant_id":"acme","account_id":1,"amount":1,"transaction_type":"withdrawal"}}`},
+		{"unknown payload field", `{"event_id":"e1","event_type":"transaction.deposit","schema_version":2,
+			"occurred_at":"2026-10-18T12:00:00Z","producer":"cashflow",
+			"payload":{"tenant_id":"acme","account_id":1,"amount":1,"transaction_type":"deposit","note":"x"}}`},
+		{"v1 without created_at", `{"account_id":1,"amount":1,"transaction_type":"deposit"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode([]byte(tt.value)); !errors.Is(err, ErrInvalid) {
+				t.Fatalf("Decode error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestDecodeUpcastsV1(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		eventType string
+		want      entity.TransactionEvent
+	}{
+		{
+			name:      "deposit",
+			value:     `{"account_id":1,"amount":100,"currency":"TMT","transaction_type":"deposit","created_at":"2026-10-18T12:00:00Z"}`,
+			eventType: TypeDeposit,
+			want: entity.TransactionEvent{
+				TenantID: tenant.Default, AccountID: 1, Amount: 100, Currency: "TMT",
+				TransactionType: entity.TransactionTypeDeposit,
+			},
+		},
+		{
+			name:      "legacy withdraw",
+			value:     `{"tenant_id":"acme","account_id":3,"amount":7.5,"transaction_type":"withdraw","created_at":"2026-10-18T17:00:00+05:00"}`,
+			eventType: TypeWithdrawal,
+			want: entity.TransactionEvent{
+				TenantID: "acme", AccountID: 3, Amount: 7.5,
+				TransactionType: entity.TransactionTypeWithdrawal,
+			},
+		},
+		{
+			name:      "transfer",
+			value:     `{"account_id":1,"related_account":2,"amount":50,"transaction_type":"transfer","created_at":"2026-10-18T12:00:00Z"}`,
+			eventType: TypeTransfer,
+			want: entity.TransactionEvent{
+				TenantID: tenant.Default, AccountID: 1, RelatedAccount: int64Ptr(2), Amount: 50,
+				TransactionType: entity.TransactionTypeTransfer,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := Decode([]byte(tt.value))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if env.SchemaVersion != CurrentVersion || env.EventType != tt.eventType {
+				t.Fatalf("envelope = %s v%d, want %s v%d", env.EventType, env.SchemaVersion, tt.eventType, CurrentVersion)
+			}
+			if env.EventID != "" {
+				t.Fatalf("event ID = %q, want none for the caller to fill in", env.EventID)
+			}
+			if !env.OccurredAt.Equal(occurredAt) {
+				t.Fatalf("occurred at = %s, want the v1 created_at %s", env.OccurredAt, occurredAt)
+			}
+			if err := validate(payloadSchema(tt.eventType, CurrentVersion), env.Payload); err != nil {
+				t.Fatalf("upcast payload does not match the current schema: %v", err)
+			}
+			payload, err := env.Transaction()
+			if err != nil {
+				t.Fatalf("Transaction: %v", err)
+			}
+			if !reflect.DeepEqual(payload, tt.want) {
+				t.Fatalf("payload = %+v, want %+v", payload, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecodeUpcastsV1Envelope covers a version 1 payload inside an
+// envelope, as stored by a producer that wrapped the old payload.
+func TestDecodeUpcastsV1Envelope(t *testing.T) {
+	value := `{"event_id":"e1","event_type":"transaction.withdrawal","schema_version":1,
+		"occurred_at":"2026-10-18T12:00:00Z","producer":"cashflow",
+		"payload":{"tenant_id":"acme","account_id":3,"amount":7.5,"transaction_type":"withdraw","created_at":"2026-10-17T12:00:00Z"}}`
+	env, err := Decode([]byte(value))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if env.EventID != "e1" || env.SchemaVersion != CurrentVersion || !env.OccurredAt.Equal(occurredAt) {
+		t.Fatalf("envelope = %+v, want event e1 v%d occurred at %s", env, CurrentVersion, occurredAt)
+	}
+	payload, err := env.Transaction()
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	if payload.TransactionType != entity.TransactionTypeWithdrawal {
+		t.Fatalf("transaction type = %q, want %q", payload.TransactionType, entity.TransactionTypeWithdrawal)
+	}
+}
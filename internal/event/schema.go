@@ -0,0 +1,196 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/serikdev/CashFlow/api/events"
+)
+
+// schema is the subset of JSON Schema used by the files in api/events:
+// type, required, properties, additionalProperties, enum, const, minimum,
+// exclusiveMinimum, minLength, maxLength and the date-time format. Other
+// keywords are ignored.
+type schema struct {
+	Type                 string             `json:"type"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Enum                 []interface{}      `json:"enum"`
+	Const                interface{}        `json:"const"`
+	Minimum              *float64           `json:"minimum"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Format               string             `json:"format"`
+}
+
+const envelopeSchema = "envelope"
+
+// schemas are loaded once from the embedded files, keyed by file name
+// without the .schema.json suffix.
+var schemas = mustLoadSchemas()
+
+func mustLoadSchemas() map[string]*schema {
+	loaded := map[string]*schema{}
+	err := fs.WalkDir(events.Schemas, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".schema.json") {
+			return err
+		}
+		data, err := events.Schemas.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var s schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		loaded[strings.TrimSuffix(path, ".schema.json")] = &s
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("event: invalid embedded schema: %v", err))
+	}
+	return loaded
+}
+
+func payloadSchema(eventType string, version int) string {
+	return fmt.Sprintf("%s.v%d", eventType, version)
+}
+
+// validate checks data against the named schema.
+func validate(name string, data []byte) error {
+	s, ok := schemas[name]
+	if !ok {
+		return fmt.Errorf("no schema %s", name)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := s.validate(v, "$"); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *schema) validate(v interface{}, path string) error {
+	if s.Const != nil && !equal(v, s.Const) {
+		return fmt.Errorf("%s must be %v", path, s.Const)
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s must be one of %v", path, s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "":
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", path)
+		}
+		return s.validateObject(obj, path)
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+		return s.validateString(str, path)
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s must be a %s", path, s.Type)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s must be a %s", path, s.Type)
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s must be at least %v", path, *s.Minimum)
+		}
+		if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+			return fmt.Errorf("%s must be greater than %v", path, *s.ExclusiveMinimum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", path, s.Type)
+	}
+	return nil
+}
+
+func (s *schema) validateObject(obj map[string]interface{}, path string) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s.%s is required", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("%s.%s is not allowed", path, name)
+			}
+			continue
+		}
+		if err := prop.validate(obj[name], path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *schema) validateString(str, path string) error {
+	length := len([]rune(str))
+	if s.MinLength != nil && length < *s.MinLength {
+		return fmt.Errorf("%s must be at least %d characters", path, *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return fmt.Errorf("%s must be at most %d characters", path, *s.MaxLength)
+	}
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339Nano, str); err != nil {
+			return fmt.Errorf("%s must be an RFC 3339 date-time", path)
+		}
+	}
+	return nil
+}
+
+// equal compares a decoded value with a schema literal, numbers by value.
+func equal(v, literal interface{}) bool {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		l, isNumber := literal.(float64)
+		return err == nil && isNumber && f == l
+	}
+	return reflect.DeepEqual(v, literal)
+}
@@ -0,0 +1,96 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/tenant"
+)
+
+// Upcaster rewrites the payload of an envelope into the next schema version.
+// It may fill in envelope fields the older version did not have.
+type Upcaster func(env *Envelope) error
+
+// upcasters are keyed by the version they upgrade from. Adding a version
+// means adding its schemas and the upcaster from the previous one.
+var upcasters = map[int]Upcaster{
+	1: upcastV1,
+}
+
+func upcast(env *Envelope) error {
+	for env.SchemaVersion < CurrentVersion {
+		up, ok := upcasters[env.SchemaVersion]
+		if !ok {
+			return fmt.Errorf("%w: no upcaster from schema version %d", ErrInvalid, env.SchemaVersion)
+		}
+		if err := up(env); err != nil {
+			return err
+		}
+		env.SchemaVersion++
+	}
+	return nil
+}
+
+// transactionV1 is the bare event published before the envelope existed. It
+// named withdrawals "withdraw" and carried its own creation time.
+type transactionV1 struct {
+	TenantID        string    `json:"tenant_id,omitempty"`
+	AccountID       int64     `json:"account_id"`
+	RelatedAccount  *int64    `json:"related_account,omitempty"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency,omitempty"`
+	TransactionType string    `json:"transaction_type"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func upcastV1(env *Envelope) error {
+	var probe struct {
+		TransactionType string `json:"transaction_type"`
+	}
+	if err := json.Unmarshal(env.Payload, &probe); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	transactionType := probe.TransactionType
+	if transactionType == "withdraw" {
+		transactionType = entity.TransactionTypeWithdrawal
+	}
+	eventType, err := transactionEventType(transactionType)
+	if err != nil {
+		return err
+	}
+	if err := validate(payloadSchema(eventType, 1), env.Payload); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var v1 transactionV1
+	if err := json.Unmarshal(env.Payload, &v1); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	// Events published before tenants existed belong to the default tenant.
+	if v1.TenantID == "" {
+		v1.TenantID = tenant.Default
+	}
+
+	payload, err := json.Marshal(entity.TransactionEvent{
+		TenantID:        v1.TenantID,
+		AccountID:       v1.AccountID,
+		RelatedAccount:  v1.RelatedAccount,
+		Amount:          v1.Amount,
+		Currency:        v1.Currency,
+		TransactionType: transactionType,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding upcast payload: %w", err)
+	}
+
+	env.Payload = payload
+	if env.EventType == "" {
+		env.EventType = eventType
+	}
+	if env.OccurredAt.IsZero() {
+		env.OccurredAt = v1.CreatedAt.UTC()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package health runs the dependency checks behind the liveness and
+// readiness endpoints.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc reports whether a dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string `json:"status" example:"up"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status" example:"ready"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type named struct {
+	name  string
+	check CheckFunc
+}
+
+// Service holds the registered readiness checks and the shutdown flag.
+type Service struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       []named
+	shuttingDown atomic.Bool
+}
+
+// NewService creates a Service whose checks each get timeout to complete.
+func NewService(timeout time.Duration) *Service {
+	return &Service{timeout: timeout}
+}
+
+// AddCheck registers a readiness check under name.
+func (s *Service) AddCheck(name string, check CheckFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks = append(s.checks, named{name: name, check: check})
+	sort.Slice(s.checks, func(i, j int) bool { return s.checks[i].name < s.checks[j].name })
+}
+
+// SetShuttingDown makes every following readiness probe fail so that load
+// balancers stop routing traffic before the servers close.
+func (s *Service) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *Service) ShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// Ready runs all checks concurrently. The report is ready only when every check passes.
+func (s *Service) Ready(ctx context.Context) Report {
+	if s.ShuttingDown() {
+		return Report{Status: StatusShuttingDown}
+	}
+
+	s.mu.RLock()
+	checks := append([]named(nil), s.checks...)
+	s.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c named) {
+			defer wg.Done()
+			results[i] = s.run(ctx, c.check)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+func (s *Service) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{Status: StatusUp, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
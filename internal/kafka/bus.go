@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/sirupsen/logrus"
+)
+
+// system names Kafka in the messaging spans.
+const system = "kafka"
+
+// Bus is the Kafka MessageBus. Every Consume joins the consumer group with
+// a reader of its own for all of its topics.
+type Bus struct {
+	cfg      config.KafkaConfig
+	groupID  string
+	producer *ProducerImpl
+	logger   *logrus.Entry
+
+	mu        sync.Mutex
+	consumers []*ConsumerImpl
+}
+
+func NewBus(cfg config.KafkaConfig, groupID string, encoder bus.Encoder, logger *logrus.Entry) *Bus {
+	return &Bus{
+		cfg:      cfg,
+		groupID:  groupID,
+		producer: NewProducerImpl(cfg.Brokers, encoder, logger),
+		logger:   logger,
+	}
+}
+
+func (b *Bus) Publish(ctx context.Context, topic, key string, env *event.Envelope) error {
+	return b.producer.Publish(ctx, topic, key, env)
+}
+
+func (b *Bus) Consume(ctx context.Context, subs []bus.Subscription, handler bus.Handler) error {
+	c := NewConsumerImpl(b.cfg.Brokers, subs, b.groupID, handler, b.logger)
+	b.mu.Lock()
+	b.consumers = append(b.consumers, c)
+	b.mu.Unlock()
+	return c.Run(ctx)
+}
+
+// ClientIDs lists the client IDs of the running consumers, for the group
+// membership check.
+func (b *Bus) ClientIDs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ids := make([]string, 0, len(b.consumers))
+	for _, c := range b.consumers {
+		ids = append(ids, c.ClientID())
+	}
+	return ids
+}
+
+// Close closes the consumers, then the producer.
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var errs []error
+	for _, c := range b.consumers {
+		errs = append(errs, c.Close())
+	}
+	errs = append(errs, b.producer.Close())
+	return errors.Join(errs...)
+}
+
+func toBusMessage(m kafka.Message) bus.Message {
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return bus.Message{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Key:       string(m.Key),
+		Value:     m.Value,
+		Headers:   headers,
+		Time:      m.Time,
+	}
+}
+
+func toKafkaHeaders(headers map[string]string) []kafka.Header {
+	out := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		out = append(out, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
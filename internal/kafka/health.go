@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// BrokerCheck reports whether at least one of the brokers accepts a connection
+// and answers a metadata request.
+func BrokerCheck(brokers []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, broker := range brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", broker)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			_, err = conn.Brokers()
+			conn.Close()
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return fmt.Errorf("no kafka broker reachable: %w", errors.Join(errs...))
+	}
+}
+
+// GroupMembershipCheck reports whether every consumer listed by clientIDs is
+// currently a member of its consumer group, matching members by client ID.
+// It fails while no consumer is listed, since the process then consumes
+// nothing.
+func GroupMembershipCheck(brokers []string, groupID string, clientIDs func() []string) func(ctx context.Context) error {
+	client := &kafka.Client{Addr: kafka.TCP(brokers...)}
+	return func(ctx context.Context) error {
+		ids := clientIDs()
+		if len(ids) == 0 {
+			return fmt.Errorf("no consumers of group %s registered", groupID)
+		}
+
+		resp, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{groupID}})
+		if err != nil {
+			return fmt.Errorf("describe group %s: %w", groupID, err)
+		}
+		if len(resp.Groups) == 0 {
+			return fmt.Errorf("group %s not found", groupID)
+		}
+		group := resp.Groups[0]
+		if group.Error != nil {
+			return fmt.Errorf("describe group %s: %w", groupID, group.Error)
+		}
+
+		if len(group.Members) == 0 {
+			return fmt.Errorf("group %s (%s) has no members", groupID, group.GroupState)
+		}
+		members := make(map[string]bool, len(group.Members))
+		for _, m := range group.Members {
+			members[m.ClientID] = true
+		}
+		var missing []string
+		for _, id := range ids {
+			if !members[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("group %s (%s) is missing consumers: %s", groupID, group.GroupState, strings.Join(missing, ", "))
+		}
+		return nil
+	}
+}
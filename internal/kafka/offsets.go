@@ -0,0 +1,68 @@
+package kafka
+
+import "sync"
+
+// offsetTracker follows the fetched messages of each partition while
+// workers process them out of order, so that a committed offset never
+// passes a message that is still being processed.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[topicPartition]*partitionOffsets
+}
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+type partitionOffsets struct {
+	// fetched holds the unfinished offsets in fetch order, hence ascending.
+	fetched []int64
+	done    map[int64]bool
+	// commitMu guards committed, the last committed offset, so that a
+	// slower worker never commits an older offset over a newer one.
+	commitMu  sync.Mutex
+	committed int64
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[topicPartition]*partitionOffsets)}
+}
+
+func (t *offsetTracker) partition(p topicPartition) *partitionOffsets {
+	po, ok := t.partitions[p]
+	if !ok {
+		po = &partitionOffsets{done: make(map[int64]bool), committed: -1}
+		t.partitions[p] = po
+	}
+	return po
+}
+
+// fetch records that the message at offset was handed to the workers.
+func (t *offsetTracker) fetch(partition topicPartition, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	po := t.partition(partition)
+	po.fetched = append(po.fetched, offset)
+}
+
+// complete records that the message at offset was processed. It returns the
+// highest offset whose message and every earlier one are processed, and
+// false when that did not move.
+func (t *offsetTracker) complete(partition topicPartition, offset int64) (*partitionOffsets, int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	po := t.partition(partition)
+	po.done[offset] = true
+
+	var (
+		last  int64
+		moved bool
+	)
+	for len(po.fetched) > 0 && po.done[po.fetched[0]] {
+		last, moved = po.fetched[0], true
+		delete(po.done, last)
+		po.fetched = po.fetched[1:]
+	}
+	return po, last, moved
+}
@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+// replaySlack is how far before a start time partitions are read. Events are
+// selected by their occurred_at, which precedes the message timestamp.
+const replaySlack = time.Minute
+
+// StartPosition selects where a replay starts in every partition: at Time
+// when it is set, otherwise at Offset, clamped to the retained offsets.
+type StartPosition struct {
+	Offset int64
+	Time   time.Time
+}
+
+// ReplayMessage is a transaction event read back from its topic and upcast
+// to the current schema version. Err is set when it could not be decoded.
+type ReplayMessage struct {
+	Topic       string
+	Partition   int
+	Offset      int64
+	EventID     string
+	OccurredAt  time.Time
+	Event       entity.TransactionEvent
+	Correlation bus.Correlation
+	Err         error
+}
+
+// ReplayRange is what ReadTopics read. FromBeginning reports whether every
+// partition was read from offset zero, i.e. no earlier event was skipped or
+// removed by retention.
+type ReplayRange struct {
+	Messages      []ReplayMessage
+	FromBeginning bool
+}
+
+// ReadTopics reads every partition of topics from start up to the high water
+// marks found when it is called, so that a replay has a fixed end even while
+// producers keep writing. Messages are returned in partition order.
+func ReadTopics(ctx context.Context, brokers []string, topics []string, start StartPosition, decoder bus.Decoder, logger *logrus.Entry) (*ReplayRange, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(topics...)
+	conn.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read partitions: %w", err)
+	}
+
+	result := &ReplayRange{FromBeginning: start.Time.IsZero()}
+	for _, p := range partitions {
+		first, begin, end, err := partitionRange(ctx, p, start)
+		if err != nil {
+			return nil, err
+		}
+		if first > 0 || begin > 0 {
+			result.FromBeginning = false
+		}
+
+		log := logger.WithFields(logrus.Fields{
+			"topic":     p.Topic,
+			"partition": p.ID,
+			"from":      begin,
+			"to":        end,
+		})
+		if begin >= end {
+			log.Info("Partition has nothing to replay")
+			continue
+		}
+
+		messages, err := readPartition(ctx, brokers, p, begin, end, start.Time, decoder)
+		if err != nil {
+			return nil, err
+		}
+		log.WithField("messages", len(messages)).Info("Partition read")
+		result.Messages = append(result.Messages, messages...)
+	}
+	return result, nil
+}
+
+// partitionRange returns the first retained offset and the [begin, end)
+// offsets to read, where end is the current high water mark.
+func partitionRange(ctx context.Context, p kafka.Partition, start StartPosition) (first, begin, end int64, err error) {
+	leader := net.JoinHostPort(p.Leader.Host, strconv.Itoa(p.Leader.Port))
+	conn, err := kafka.DialLeader(ctx, "tcp", leader, p.Topic, p.ID)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to dial leader of %s/%d: %w", p.Topic, p.ID, err)
+	}
+	defer conn.Close()
+
+	if first, end, err = conn.ReadOffsets(); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to read offsets of %s/%d: %w", p.Topic, p.ID, err)
+	}
+
+	begin = start.Offset
+	if !start.Time.IsZero() {
+		if begin, err = conn.ReadOffset(start.Time.Add(-replaySlack)); err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to find offset of %s/%d at %s: %w", p.Topic, p.ID, start.Time, err)
+		}
+		// No message at or after the time.
+		if begin < 0 {
+			begin = end
+		}
+	}
+	if begin < first {
+		begin = first
+	}
+	if begin > end {
+		begin = end
+	}
+	return first, begin, end, nil
+}
+
+func readPartition(ctx context.Context, brokers []string, p kafka.Partition, begin, end int64, since time.Time, decoder bus.Decoder) ([]ReplayMessage, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   brokers,
+		Topic:     p.Topic,
+		Partition: p.ID,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(begin); err != nil {
+		return nil, fmt.Errorf("failed to seek %s/%d to %d: %w", p.Topic, p.ID, begin, err)
+	}
+
+	var messages []ReplayMessage
+	for {
+		m, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s/%d: %w", p.Topic, p.ID, err)
+		}
+
+		bm := toBusMessage(m)
+		msg := ReplayMessage{
+			Topic:       m.Topic,
+			Partition:   m.Partition,
+			Offset:      m.Offset,
+			Correlation: bus.CorrelationFromHeaders(bm.Headers),
+		}
+		if env, payload, err := bus.DecodeTransaction(decoder, bm); err != nil {
+			msg.Err = fmt.Errorf("undecodable event: %w", err)
+		} else {
+			msg.EventID = env.EventID
+			msg.OccurredAt = env.OccurredAt
+			msg.Event = payload
+		}
+
+		if msg.Err != nil || since.IsZero() || !msg.OccurredAt.Before(since) {
+			messages = append(messages, msg)
+		}
+		if m.Offset+1 >= end {
+			return messages, nil
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// retentionConfig is the topic setting that TopicsConfig.Retention maps to.
+const retentionConfig = "retention.ms"
+
+// Provisioner creates or validates topics with the Kafka admin API, so that
+// the service does not depend on the broker creating topics on first use.
+type Provisioner struct {
+	client *kafka.Client
+	cfg    config.TopicsConfig
+	logger *logrus.Entry
+}
+
+func NewProvisioner(brokers []string, cfg config.TopicsConfig, logger *logrus.Entry) *Provisioner {
+	return &Provisioner{
+		client: &kafka.Client{Addr: kafka.TCP(brokers...)},
+		cfg:    cfg,
+		logger: logger.WithField("component", "topic-provisioner"),
+	}
+}
+
+// Provision makes sure topics exist as configured. In "create" mode missing
+// topics are created; in "validate" mode they are an error. Existing topics
+// with fewer partitions or another replication factor than configured are
+// an error in both modes, a different retention only a warning.
+func (p *Provisioner) Provision(ctx context.Context, topics []string) error {
+	switch p.cfg.Provision {
+	case config.ProvisionOff:
+		return nil
+	case config.ProvisionCreate, config.ProvisionValidate:
+	default:
+		return fmt.Errorf("unknown topic provisioning mode %q", p.cfg.Provision)
+	}
+
+	topics = slices.Compact(slices.Sorted(slices.Values(topics)))
+	meta, err := p.client.Metadata(ctx, &kafka.MetadataRequest{Topics: topics})
+	if err != nil {
+		return fmt.Errorf("read topic metadata: %w", err)
+	}
+	existing := make(map[string]kafka.Topic, len(meta.Topics))
+	for _, t := range meta.Topics {
+		if t.Error == nil {
+			existing[t.Name] = t
+		} else if !errors.Is(t.Error, kafka.UnknownTopicOrPartition) {
+			return fmt.Errorf("read metadata of topic %s: %w", t.Name, t.Error)
+		}
+	}
+
+	var missing []string
+	for _, topic := range topics {
+		if _, ok := existing[topic]; !ok {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) > 0 {
+		if p.cfg.Provision == config.ProvisionValidate {
+			return fmt.Errorf("missing topics: %s", strings.Join(missing, ", "))
+		}
+		if err := p.create(ctx, missing); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	for _, topic := range topics {
+		if t, ok := existing[topic]; ok {
+			errs = append(errs, p.check(t))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return p.checkRetention(ctx, topics)
+}
+
+func (p *Provisioner) create(ctx context.Context, topics []string) error {
+	req := &kafka.CreateTopicsRequest{}
+	for _, topic := range topics {
+		req.Topics = append(req.Topics, kafka.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     p.cfg.Partitions,
+			ReplicationFactor: p.cfg.ReplicationFactor,
+			ConfigEntries: []kafka.ConfigEntry{{
+				ConfigName:  retentionConfig,
+				ConfigValue: strconv.FormatInt(p.cfg.Retention.Milliseconds(), 10),
+			}},
+		})
+	}
+	resp, err := p.client.CreateTopics(ctx, req)
+	if err != nil {
+		return fmt.Errorf("create topics: %w", err)
+	}
+
+	var errs []error
+	for _, topic := range topics {
+		err := resp.Errors[topic]
+		switch {
+		case err == nil:
+			p.logger.WithFields(logrus.Fields{
+				"topic":              topic,
+				"partitions":         p.cfg.Partitions,
+				"replication_factor": p.cfg.ReplicationFactor,
+				"retention":          p.cfg.Retention.String(),
+			}).Info("Topic created")
+		case errors.Is(err, kafka.TopicAlreadyExists):
+			// Another instance created it first.
+		default:
+			errs = append(errs, fmt.Errorf("create topic %s: %w", topic, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Provisioner) check(t kafka.Topic) error {
+	if len(t.Partitions) < p.cfg.Partitions {
+		return fmt.Errorf("topic %s has %d partitions, want at least %d", t.Name, len(t.Partitions), p.cfg.Partitions)
+	}
+	if len(t.Partitions) > p.cfg.Partitions {
+		p.logger.WithField("topic", t.Name).Infof("Topic has %d partitions, more than the configured %d", len(t.Partitions), p.cfg.Partitions)
+	}
+	for _, partition := range t.Partitions {
+		if n := len(partition.Replicas); n != p.cfg.ReplicationFactor {
+			return fmt.Errorf("topic %s has replication factor %d, want %d", t.Name, n, p.cfg.ReplicationFactor)
+		}
+	}
+	return nil
+}
+
+func (p *Provisioner) checkRetention(ctx context.Context, topics []string) error {
+	req := &kafka.DescribeConfigsRequest{}
+	for _, topic := range topics {
+		req.Resources = append(req.Resources, kafka.DescribeConfigRequestResource{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: topic,
+			ConfigNames:  []string{retentionConfig},
+		})
+	}
+	resp, err := p.client.DescribeConfigs(ctx, req)
+	if err != nil {
+		return fmt.Errorf("describe topic configs: %w", err)
+	}
+
+	want := strconv.FormatInt(p.cfg.Retention.Milliseconds(), 10)
+	for _, res := range resp.Resources {
+		if res.Error != nil {
+			return fmt.Errorf("describe config of topic %s: %w", res.ResourceName, res.Error)
+		}
+		for _, entry := range res.ConfigEntries {
+			if entry.ConfigName == retentionConfig && entry.ConfigValue != want {
+				p.logger.WithFields(logrus.Fields{
+					"topic":        res.ResourceName,
+					"retention_ms": entry.ConfigValue,
+					"want_ms":      want,
+				}).Warn("Topic retention differs from the configuration")
+			}
+		}
+	}
+	return nil
+}
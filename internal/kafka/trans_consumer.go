@@ -2,60 +2,85 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/requestid"
 
 	"github.com/segmentio/kafka-go"
 	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
-type TransactionRepository interface {
-	Deposit(accountID int64, amount float64) error
-	Withdraw(accountID int64, amount float64) error
-	Transfer(fromAccountID, toAccountID int64, amount float64) error
-	SaveTransaction(tx *entity.Transaction) error
-}
-
-type TransactionEvent struct {
-	AccountID       int64     `json:"account_id"`
-	RelatedAccount  *int64    `json:"related_account,omitempty"`
-	Amount          float64   `json:"amount"`
-	TransactionType string    `json:"transaction_type"`
-	CreatedAt       time.Time `json:"created_at"`
-}
+// commitTimeout bounds the offset commit of a processed message.
+const commitTimeout = 10 * time.Second
 
+// ConsumerImpl reads the subscribed topics as one member of the consumer
+// group and processes their messages on one pool of workers, in which each
+// topic has the workers and queue size of its subscription. Messages
+// sharing an ordering key, an account, are processed one at a time in fetch
+// order, whichever topics carry them; a partition's offset is only
+// committed once every earlier message of the partition has been processed.
 type ConsumerImpl struct {
-	reader     *kafka.Reader
-	logger     *logrus.Entry
-	repository TransactionRepository
+	reader   *kafka.Reader
+	subs     []bus.Subscription
+	groupID  string
+	clientID string
+	offsets  *offsetTracker
+	logger   *logrus.Entry
+	handler  bus.Handler
 }
 
-func NewConsumerImpl(brokers []string, topic, groupID string, repo TransactionRepository, logger *logrus.Entry) *ConsumerImpl {
+func NewConsumerImpl(brokers []string, subs []bus.Subscription, groupID string, handler bus.Handler, logger *logrus.Entry) *ConsumerImpl {
+	// A unique client ID lets the readiness check find this reader among the group members.
+	clientID := fmt.Sprintf("cashflow-%s", requestid.New()[:8])
 	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     brokers,
-		GroupID:     groupID,
-		GroupTopics: []string{"account-deposit", "account-withdraw", "account-transfer"},
-		Topic:       topic,
+		Brokers: brokers,
+		GroupID: groupID,
+		Dialer: &kafka.Dialer{
+			ClientID:  clientID,
+			Timeout:   10 * time.Second,
+			DualStack: true,
+		},
+		GroupTopics: bus.Topics(subs),
 		MinBytes:    10e3,
 		MaxBytes:    10e6,
 	})
 
 	return &ConsumerImpl{
-		reader:     r,
-		logger:     logger.WithField("topic", topic),
-		repository: repo,
+		reader:   r,
+		subs:     subs,
+		groupID:  groupID,
+		clientID: clientID,
+		offsets:  newOffsetTracker(),
+		logger:   logger.WithField("client_id", clientID),
+		handler:  handler,
 	}
 }
 
+// Run consumes messages until ctx is cancelled. Cancelling ctx only stops
+// fetching: the fetched messages are processed and their offsets committed
+// before Run returns. A topic whose queue is full stops fetching for every
+// topic until a worker frees a slot.
 func (c *ConsumerImpl) Run(ctx context.Context) error {
-	c.logger.Info("Consumer started")
+	msgCtx := context.WithoutCancel(ctx)
+	subscribed := make(map[string]bool, len(c.subs))
+	for _, sub := range c.subs {
+		subscribed[sub.Topic] = true
+		metrics.SetConsumerWorkers(sub.Topic, sub.Workers)
+		c.logger.WithFields(logrus.Fields{"topic": sub.Topic, "workers": sub.Workers}).Info("Consumer started")
+	}
+	pool := bus.NewOrderedPool(c.subs, func(m bus.Message) {
+		c.process(ctx, msgCtx, m)
+	}, metrics.SetConsumerQueueDepth)
+	defer pool.Wait()
 
 	for {
-		m, err := c.reader.ReadMessage(ctx)
+		m, err := c.reader.FetchMessage(ctx)
 		if err != nil {
 
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
@@ -63,52 +88,74 @@ func (c *ConsumerImpl) Run(ctx context.Context) error {
 				return nil
 			}
 
+			metrics.ConsumeError(strings.Join(bus.Topics(c.subs), ","), "read")
 			c.logger.WithError(err).Error("Failed to read message, will retry...")
 			continue
 		}
 
-		var event TransactionEvent
-		if err := json.Unmarshal(m.Value, &event); err != nil {
-			c.logger.WithError(err).Error("Failed to unmarshal event")
+		if !subscribed[m.Topic] {
+			// The group only assigns subscribed topics.
+			c.logger.WithField("topic", m.Topic).Warn("Skipping message of an unsubscribed topic")
 			continue
 		}
+		metrics.SetConsumerLag(m.Topic, m.Partition, m.Offset, m.HighWaterMark)
+		c.offsets.fetch(topicPartition{m.Topic, m.Partition}, m.Offset)
+		if err := pool.Submit(ctx, toBusMessage(m)); err != nil {
+			// Not processed, so not committed: the group delivers it again.
+			c.logger.Info("Consumer context cancelled, shutting down gracefully")
+			return nil
+		}
+	}
+}
 
+// process delivers m, retrying it until stop is done, and commits it unless
+// it is still to be retried. An uncommitted message holds back the commits
+// of its partition, so the group delivers it again after a restart.
+func (c *ConsumerImpl) process(stop, ctx context.Context, m bus.Message) {
+	err := bus.DeliverRetrying(stop, ctx, system, m, c.handler, semconv.MessagingKafkaOffset(int(m.Offset)))
+	if bus.IsRetryable(err) {
 		c.logger.WithFields(logrus.Fields{
-			"key":   string(m.Key),
-			"value": string(m.Value),
-		}).Info("Message received")
-
-		switch event.TransactionType {
-		case "deposit":
-			err = c.repository.Deposit(event.AccountID, event.Amount)
-		case "withdraw":
-			err = c.repository.Withdraw(event.AccountID, event.Amount)
-		case "transfer":
-			if event.RelatedAccount == nil {
-				err = fmt.Errorf("related account is nil for transfer")
-			} else {
-				err = c.repository.Transfer(event.AccountID, *event.RelatedAccount, event.Amount)
-			}
-		default:
-			c.logger.Warnf("unknown transaction type: %s", event.TransactionType)
-			continue
-		}
+			"topic":     m.Topic,
+			"partition": m.Partition,
+			"offset":    m.Offset,
+		}).Warn("Message left uncommitted, it is delivered again after a restart")
+		return
+	}
+	c.commit(ctx, m)
+}
 
-		if err != nil {
-			c.logger.WithError(err).Error("Failed to process transaction")
-			continue
-		}
+// commit commits the partition of m up to its lowest unfinished message.
+func (c *ConsumerImpl) commit(ctx context.Context, m bus.Message) {
+	po, offset, moved := c.offsets.complete(topicPartition{m.Topic, m.Partition}, m.Offset)
+	if !moved {
+		return
+	}
+	po.commitMu.Lock()
+	defer po.commitMu.Unlock()
+	if offset <= po.committed {
+		return
+	}
 
-		tx := &entity.Transaction{
-			AccountID:       int(event.AccountID),
-			Amount:          event.Amount,
-			TransactionType: event.TransactionType,
-			CreatedAt:       event.CreatedAt,
-		}
-		if err := c.repository.SaveTransaction(tx); err != nil {
-			c.logger.WithError(err).Error("Failed to save transaction")
-		}
+	commitCtx, cancel := context.WithTimeout(ctx, commitTimeout)
+	defer cancel()
+	err := c.reader.CommitMessages(commitCtx, kafka.Message{Topic: m.Topic, Partition: m.Partition, Offset: offset})
+	if err != nil {
+		metrics.ConsumeError(m.Topic, "commit")
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"partition": m.Partition,
+			"offset":    offset,
+		}).Error("Failed to commit offset")
+		return
 	}
+	po.committed = offset
+}
+
+func (c *ConsumerImpl) ClientID() string {
+	return c.clientID
+}
+
+func (c *ConsumerImpl) GroupID() string {
+	return c.groupID
 }
 
 func (c *ConsumerImpl) Close() error {
@@ -5,42 +5,69 @@ import (
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
 	"github.com/sirupsen/logrus"
 )
 
 type ProducerImpl struct {
-	writer *kafka.Writer
-	logger *logrus.Entry
+	writer  *kafka.Writer
+	encoder bus.Encoder
+	logger  *logrus.Entry
 }
 
-func NewProducerImpl(brokers []string, logger *logrus.Entry) *ProducerImpl {
+func NewProducerImpl(brokers []string, encoder bus.Encoder, logger *logrus.Entry) *ProducerImpl {
 	return &ProducerImpl{
 		writer: &kafka.Writer{
-			Addr:         kafka.TCP(brokers...),
-			Balancer:     &kafka.LeastBytes{},
+			Addr: kafka.TCP(brokers...),
+			// Messages of a key go to the partition its hash selects, so
+			// that they are consumed in the order they were published.
+			Balancer:     &kafka.Hash{},
 			RequiredAcks: kafka.RequireOne,
 		},
-		logger: logger,
+		encoder: encoder,
+		logger:  logger,
 	}
 }
 
-func (p *ProducerImpl) Publish(topic string, key string, value []byte) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// Publish writes env in the encoding selected for topic. The message headers
+// carry the request ID, trace context and caller identity found in ctx.
+// Cancelling ctx does not abort the write.
+func (p *ProducerImpl) Publish(ctx context.Context, topic string, key string, env *event.Envelope) error {
+	log := logger.FromContext(ctx, p.logger)
+
+	value, err := p.encoder.Encode(topic, env)
+	if err != nil {
+		log.WithError(err).Errorf("failed to encode message for topic=%s", topic)
+		return err
+	}
+
+	ctx, span := bus.StartPublish(ctx, system, topic)
 
 	msg := kafka.Message{
-		Topic: topic,
-		Key:   []byte(key),
-		Value: value,
-		Time:  time.Now(),
+		Topic:   topic,
+		Key:     []byte(key),
+		Value:   value,
+		Headers: toKafkaHeaders(bus.HeadersFromContext(ctx)),
+		Time:    time.Now(),
 	}
 
-	if err := p.writer.WriteMessages(ctx, msg); err != nil {
-		p.logger.WithError(err).Errorf("failed to publish message to topic=%s", topic)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err = p.writer.WriteMessages(ctx, msg)
+	metrics.ObservePublish(topic, time.Since(start), err)
+	tracing.End(span, err)
+	if err != nil {
+		log.WithError(err).Errorf("failed to publish message to topic=%s", topic)
 		return err
 	}
 
-	p.logger.WithFields(logrus.Fields{
+	log.WithFields(logrus.Fields{
 		"topic": topic,
 		"key":   key,
 	}).Info("Message published to Kafka")
@@ -0,0 +1,32 @@
+package kafka
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+)
+
+func TestProducerKeepsKeysOnOnePartition(t *testing.T) {
+	p := NewProducerImpl([]string{"localhost:9092"}, nil, logrus.NewEntry(logrus.New()))
+	partitions := []int{0, 1, 2}
+
+	used := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		key := []byte(fmt.Sprintf("acme:%d", i))
+		want := p.writer.Balancer.Balance(kafka.Message{Key: key}, partitions...)
+		used[want] = true
+		// Values of every size, which a load based balancer would spread.
+		for size := 1; size <= 4096; size *= 4 {
+			m := kafka.Message{Key: key, Value: bytes.Repeat([]byte{'x'}, size)}
+			if got := p.writer.Balancer.Balance(m, partitions...); got != want {
+				t.Fatalf("key %s went to partition %d, then %d", key, want, got)
+			}
+		}
+	}
+	if len(used) != len(partitions) {
+		t.Fatalf("50 keys used partitions %v, want all of %v", used, partitions)
+	}
+}
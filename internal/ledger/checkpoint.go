@@ -0,0 +1,93 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+)
+
+// Signer signs checkpoints with an Ed25519 key. Auditors verify them with the
+// public key, which the service publishes next to the checkpoints.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner loads the key from a base64 encoded 32-byte Ed25519 seed.
+func NewSigner(seed string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ledger signing key: %w", err)
+	}
+	if len(raw) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid ledger signing key: want %d bytes, got %d", ed25519.SeedSize, len(raw))
+	}
+	key := ed25519.NewKeyFromSeed(raw)
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}, nil
+}
+
+// KeyID is a short fingerprint of a public key.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the base64 encoded public key.
+func (s *Signer) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Seal sorts the heads, computes the root and signs it.
+func (s *Signer) Seal(cp *entity.LedgerCheckpoint) {
+	sort.Slice(cp.Heads, func(i, j int) bool { return cp.Heads[i].AccountID < cp.Heads[j].AccountID })
+	cp.Root = Root(cp.TenantID, cp.CreatedAt, cp.Heads)
+	cp.KeyID = s.keyID
+	cp.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, []byte(cp.Root)))
+}
+
+// Verify checks that the checkpoint root matches its content and that the
+// signature of the root was made by this signer's key.
+func (s *Signer) Verify(cp entity.LedgerCheckpoint) error {
+	if cp.KeyID != s.keyID {
+		return fmt.Errorf("checkpoint signed with key %s, current key is %s", cp.KeyID, s.keyID)
+	}
+	if root := Root(cp.TenantID, cp.CreatedAt, cp.Heads); root != cp.Root {
+		return fmt.Errorf("checkpoint root %s does not match its heads (%s)", cp.Root, root)
+	}
+	sig, err := base64.StdEncoding.DecodeString(cp.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint signature encoding: %w", err)
+	}
+	if !ed25519.Verify(s.key.Public().(ed25519.PublicKey), []byte(cp.Root), sig) {
+		return fmt.Errorf("checkpoint signature is invalid")
+	}
+	return nil
+}
+
+// Root is the hex SHA-256 over the tenant, the checkpoint time and the heads
+// in account order.
+func Root(tenantID string, createdAt time.Time, heads []entity.ChainHead) string {
+	fields := []string{hashVersion, tenantID, createdAt.UTC().Format(time.RFC3339Nano)}
+	for _, h := range heads {
+		fields = append(fields,
+			strconv.FormatInt(h.AccountID, 10),
+			strconv.FormatInt(h.TransactionID, 10),
+			h.Hash,
+		)
+	}
+	encoded, _ := json.Marshal(fields)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
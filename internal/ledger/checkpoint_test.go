@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+)
+
+// The key is the RFC 8032 test key whose seed is the bytes 0 to 31.
+const (
+	testSeed      = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	testPublicKey = "A6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg="
+	testKeyID     = "56475aa75463474c"
+)
+
+// checkpoint is sealed over the heads of the records of hash_test.go, given
+// out of account order.
+func checkpoint() *entity.LedgerCheckpoint {
+	return &entity.LedgerCheckpoint{
+		TenantID:  "acme",
+		CreatedAt: time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC),
+		Heads: []entity.ChainHead{
+			{AccountID: 9, TransactionID: 42, Hash: firstHash},
+			{AccountID: 7, TransactionID: 43, Hash: secondHash},
+		},
+	}
+}
+
+const (
+	checkpointRoot      = "c9682f2e5267a8b00047dd9f89b591fd8108538cfb578390fc5d8094e576e329"
+	emptyCheckpointRoot = "006932cca8eeaff409aefcc51f00a7c458ddb11524620b5b82e3975e5cebde8e"
+	checkpointSignature = "5qFcPmbynmgiamjlGQElY2S11V22RENtBvV4b8yYmpQ2l8ztkjBq2dhgjp6iUhZ/xQoGQgg4E/QB2/7d8BxEBw=="
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	s, err := NewSigner(testSeed)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	return s
+}
+
+func TestNewSigner(t *testing.T) {
+	s := newTestSigner(t)
+	if got := s.PublicKey(); got != testPublicKey {
+		t.Errorf("PublicKey = %s, want %s", got, testPublicKey)
+	}
+	if got := s.KeyID(); got != testKeyID {
+		t.Errorf("KeyID = %s, want %s", got, testKeyID)
+	}
+
+	for _, seed := range []string{"not base64!", base64.StdEncoding.EncodeToString(make([]byte, 31))} {
+		if _, err := NewSigner(seed); err == nil {
+			t.Errorf("NewSigner(%q) succeeded, want an error", seed)
+		}
+	}
+}
+
+func TestRootGolden(t *testing.T) {
+	cp := checkpoint()
+	heads := []entity.ChainHead{cp.Heads[1], cp.Heads[0]}
+	if got := Root(cp.TenantID, cp.CreatedAt, heads); got != checkpointRoot {
+		t.Errorf("Root = %s, want %s", got, checkpointRoot)
+	}
+	if got := Root(cp.TenantID, cp.CreatedAt, nil); got != emptyCheckpointRoot {
+		t.Errorf("Root without heads = %s, want %s", got, emptyCheckpointRoot)
+	}
+}
+
+func TestSeal(t *testing.T) {
+	cp := checkpoint()
+	newTestSigner(t).Seal(cp)
+
+	if cp.Heads[0].AccountID != 7 || cp.Heads[1].AccountID != 9 {
+		t.Errorf("heads are not sorted by account: %+v", cp.Heads)
+	}
+	if cp.Root != checkpointRoot {
+		t.Errorf("Root = %s, want %s", cp.Root, checkpointRoot)
+	}
+	if cp.KeyID != testKeyID {
+		t.Errorf("KeyID = %s, want %s", cp.KeyID, testKeyID)
+	}
+	if cp.Signature != checkpointSignature {
+		t.Errorf("Signature = %s, want %s", cp.Signature, checkpointSignature)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s := newTestSigner(t)
+	other, err := NewSigner(base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)))
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(cp *entity.LedgerCheckpoint)
+		// want is part of the error, or "" when the checkpoint is valid.
+		want string
+	}{
+		{name: "valid", edit: func(*entity.LedgerCheckpoint) {}},
+		{name: "other key", edit: other.Seal, want: "checkpoint signed with key"},
+		{name: "edited head", edit: func(cp *entity.LedgerCheckpoint) { cp.Heads[0].Hash = firstHash }, want: "does not match its heads"},
+		{name: "dropped head", edit: func(cp *entity.LedgerCheckpoint) { cp.Heads = cp.Heads[:1] }, want: "does not match its heads"},
+		{name: "edited time", edit: func(cp *entity.LedgerCheckpoint) { cp.CreatedAt = cp.CreatedAt.Add(time.Second) }, want: "does not match its heads"},
+		{name: "edited tenant", edit: func(cp *entity.LedgerCheckpoint) { cp.TenantID = "other" }, want: "does not match its heads"},
+		{
+			name: "root recomputed without the key",
+			edit: func(cp *entity.LedgerCheckpoint) {
+				cp.Heads = cp.Heads[:1]
+				cp.Root = Root(cp.TenantID, cp.CreatedAt, cp.Heads)
+			},
+			want: "checkpoint signature is invalid",
+		},
+		{
+			name: "signed by another key under this key ID",
+			edit: func(cp *entity.LedgerCheckpoint) {
+				other.Seal(cp)
+				cp.KeyID = testKeyID
+			},
+			want: "checkpoint signature is invalid",
+		},
+		{name: "signature not base64", edit: func(cp *entity.LedgerCheckpoint) { cp.Signature = "!" }, want: "invalid checkpoint signature encoding"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := checkpoint()
+			s.Seal(cp)
+			tt.edit(cp)
+			err := s.Verify(*cp)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Verify = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Verify = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
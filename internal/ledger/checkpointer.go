@@ -0,0 +1,52 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type CheckpointCreator interface {
+	Checkpoint(ctx context.Context) (*entity.LedgerCheckpoint, error)
+}
+
+// Checkpointer periodically seals the chain heads of every tenant.
+type Checkpointer struct {
+	creator  CheckpointCreator
+	tenants  []string
+	interval time.Duration
+	logger   *logrus.Entry
+}
+
+func NewCheckpointer(creator CheckpointCreator, tenants []string, interval time.Duration, logger *logrus.Entry) *Checkpointer {
+	return &Checkpointer{
+		creator:  creator,
+		tenants:  tenants,
+		interval: interval,
+		logger:   logger.WithField("component", "ledger-checkpointer"),
+	}
+}
+
+func (c *Checkpointer) Run(ctx context.Context) error {
+	c.logger.WithField("interval", c.interval.String()).Info("Ledger checkpointer started")
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("Ledger checkpointer stopped")
+			return nil
+		case <-ticker.C:
+			for _, tenantID := range c.tenants {
+				if _, err := c.creator.Checkpoint(tenant.WithID(ctx, tenantID)); err != nil {
+					c.logger.WithError(err).WithField("tenant_id", tenantID).Error("Failed to create ledger checkpoint")
+				}
+			}
+		}
+	}
+}
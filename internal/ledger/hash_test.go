@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// first and second are two chained records; their golden hashes were
+// computed outside Go from the encoding described on Hash, so a change of
+// the encoding, which would break every stored chain, fails here.
+var (
+	first = Record{
+		TenantID:        "acme",
+		ID:              42,
+		AccountID:       7,
+		RelatedAccount:  ptr[int64](9),
+		Amount:          "100.50",
+		TransactionType: "transfer",
+		CreatedAt:       time.Date(2026, 10, 18, 12, 0, 0, 123456000, time.UTC),
+		RequestID:       "req-1",
+		TraceID:         "4bf92f3577b34da6a3ce929d0e0e4736",
+		InitiatedBy:     "alice",
+	}
+	second = Record{
+		TenantID:        "acme",
+		ID:              43,
+		AccountID:       7,
+		Amount:          "0.01",
+		TransactionType: "deposit",
+		CreatedAt:       time.Date(2026, 10, 18, 12, 0, 1, 0, time.UTC),
+	}
+)
+
+const (
+	firstHash  = "4b814504cc07b3d546b9149d95a6d4abfc955bc0584929c268604786fcf508af"
+	secondHash = "256682fb897d907dd792c6eac1e2b257ccaae70b9411a63e1a1cc6ada13b49bb"
+)
+
+func TestHashGolden(t *testing.T) {
+	if got := Hash("", first); got != firstHash {
+		t.Errorf("Hash of the first record = %s, want %s", got, firstHash)
+	}
+	if got := Hash(firstHash, second); got != secondHash {
+		t.Errorf("Hash of the second record = %s, want %s", got, secondHash)
+	}
+}
+
+// TestHashCoversEveryField checks that changing any field, or the previous
+// hash, changes the hash, while the time zone of CreatedAt does not.
+func TestHashCoversEveryField(t *testing.T) {
+	edits := map[string]func(r *Record){
+		"tenant":          func(r *Record) { r.TenantID = "other" },
+		"id":              func(r *Record) { r.ID++ },
+		"account":         func(r *Record) { r.AccountID++ },
+		"related account": func(r *Record) { r.RelatedAccount = ptr[int64](10) },
+		"no related":      func(r *Record) { r.RelatedAccount = nil },
+		"amount":          func(r *Record) { r.Amount = "100.5" },
+		"type":            func(r *Record) { r.TransactionType = "withdrawal" },
+		"created at":      func(r *Record) { r.CreatedAt = r.CreatedAt.Add(time.Microsecond) },
+		"request ID":      func(r *Record) { r.RequestID = "req-2" },
+		"trace ID":        func(r *Record) { r.TraceID = "" },
+		"initiated by":    func(r *Record) { r.InitiatedBy = "bob" },
+	}
+	for name, edit := range edits {
+		r := first
+		edit(&r)
+		if Hash("", r) == firstHash {
+			t.Errorf("editing the %s does not change the hash", name)
+		}
+	}
+	if Hash(secondHash, first) == firstHash {
+		t.Errorf("the previous hash does not change the hash")
+	}
+
+	r := first
+	r.CreatedAt = r.CreatedAt.In(time.FixedZone("TMT", 5*60*60))
+	if got := Hash("", r); got != firstHash {
+		t.Errorf("Hash in another time zone = %s, want %s", got, firstHash)
+	}
+}
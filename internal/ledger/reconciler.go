@@ -0,0 +1,57 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+type BalanceReconciler interface {
+	Reconcile(ctx context.Context, accountID int64, openCorrections bool) (*entity.ReconciliationReport, error)
+}
+
+// Reconciler periodically reconciles the balances of every tenant. It only
+// reports: corrections are opened through the admin API.
+type Reconciler struct {
+	reconciler BalanceReconciler
+	tenants    []string
+	interval   time.Duration
+	logger     *logrus.Entry
+}
+
+func NewReconciler(reconciler BalanceReconciler, tenants []string, interval time.Duration, logger *logrus.Entry) *Reconciler {
+	return &Reconciler{
+		reconciler: reconciler,
+		tenants:    tenants,
+		interval:   interval,
+		logger:     logger.WithField("component", "ledger-reconciler"),
+	}
+}
+
+func (r *Reconciler) Run(ctx context.Context) error {
+	r.logger.WithField("interval", r.interval.String()).Info("Balance reconciler started")
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Balance reconciler stopped")
+			return nil
+		case <-ticker.C:
+			for _, tenantID := range r.tenants {
+				report, err := r.reconciler.Reconcile(tenant.WithID(ctx, tenantID), 0, false)
+				if err != nil {
+					r.logger.WithError(err).WithField("tenant_id", tenantID).Error("Failed to reconcile balances")
+					continue
+				}
+				metrics.SetReconciliationMismatches(tenantID, len(report.Mismatches))
+			}
+		}
+	}
+}
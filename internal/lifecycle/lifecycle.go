@@ -0,0 +1,173 @@
+// Package lifecycle starts the long running components of the service and
+// stops them in a fixed order when the process is asked to terminate.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StopFunc stops one component. It must return once ctx is done, even if
+// the component has not finished stopping.
+type StopFunc func(ctx context.Context) error
+
+type hook struct {
+	name string
+	stop StopFunc
+}
+
+// Manager runs background workers and the ordered shutdown hooks. Workers get
+// a context that is cancelled by StopWorkers; a worker that fails triggers
+// the shutdown instead of terminating the process.
+type Manager struct {
+	timeout time.Duration
+	logger  *logrus.Entry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu    sync.Mutex
+	hooks []hook
+
+	failed   chan struct{}
+	failOnce sync.Once
+	failErr  error
+}
+
+// New creates a Manager whose whole shutdown must complete within timeout.
+func New(timeout time.Duration, logger *logrus.Entry) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		timeout: timeout,
+		logger:  logger.WithField("component", "lifecycle"),
+		ctx:     ctx,
+		cancel:  cancel,
+		failed:  make(chan struct{}),
+	}
+}
+
+// Go runs fn in a goroutine with the worker context. fn must return when the
+// context is cancelled, after finishing the work it has already started.
+func (m *Manager) Go(name string, fn func(ctx context.Context) error) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := fn(m.ctx); err != nil {
+			m.logger.WithError(err).WithField("worker", name).Error("Worker stopped with error")
+			m.fail(fmt.Errorf("%s: %w", name, err))
+		}
+	}()
+}
+
+// OnStop registers a shutdown hook. Hooks run one after another in the order
+// they were registered.
+func (m *Manager) OnStop(name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, stop: stop})
+}
+
+// StopWorkers cancels the worker context and waits for every goroutine
+// started with Go to return. Register it with OnStop after the hooks that
+// stop accepting new work and before the ones that close what workers use.
+func (m *Manager) StopWorkers(ctx context.Context) error {
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait blocks until SIGINT or SIGTERM is received or a worker fails, and
+// returns the failure, if any.
+func (m *Manager) Wait() error {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case sig := <-stop:
+		m.logger.WithField("signal", sig.String()).Info("Shutdown requested")
+		return nil
+	case <-m.failed:
+		m.logger.Error("Shutting down after worker failure")
+		return m.failErr
+	}
+}
+
+// Shutdown runs the hooks in order under the overall deadline. Once the
+// deadline has passed the remaining hooks are skipped.
+func (m *Manager) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	m.mu.Lock()
+	hooks := append([]hook(nil), m.hooks...)
+	m.mu.Unlock()
+
+	start := time.Now()
+	var errs []error
+	for i, h := range hooks {
+		if ctx.Err() != nil {
+			skipped := make([]string, 0, len(hooks)-i)
+			for _, s := range hooks[i:] {
+				skipped = append(skipped, s.name)
+			}
+			m.logger.WithField("skipped", skipped).Error("Shutdown deadline exceeded")
+			errs = append(errs, fmt.Errorf("shutdown deadline exceeded, skipped %v", skipped))
+			break
+		}
+
+		log := m.logger.WithField("stage", h.name)
+		stageStart := time.Now()
+		if err := m.run(ctx, h); err != nil {
+			log.WithError(err).Error("Shutdown stage failed")
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		log.WithField("elapsed", time.Since(stageStart).String()).Info("Shutdown stage completed")
+	}
+
+	m.cancel()
+	m.logger.WithField("elapsed", time.Since(start).String()).Info("Shutdown finished")
+	return errors.Join(errs...)
+}
+
+// run calls the hook and stops waiting for it when ctx expires, so that a
+// hook which ignores its context cannot hold the process past the deadline.
+func (m *Manager) run(ctx context.Context, h hook) error {
+	done := make(chan error, 1)
+	go func() { done <- h.stop(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) fail(err error) {
+	m.failOnce.Do(func() {
+		m.failErr = err
+		close(m.failed)
+	})
+}
@@ -0,0 +1,200 @@
+// Package metrics defines the Prometheus collectors of CashFlow and the
+// handler that serves them on /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cashflow"
+
+// Registry holds every CashFlow collector plus the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC requests by full method name and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC request latency by full method name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	publishDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "publish_duration_seconds",
+		Help:      "Latency of publishing a message to Kafka by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	publishFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "publish_failures_total",
+		Help:      "Messages that could not be published to Kafka by topic.",
+	}, []string{"topic"})
+
+	consumeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consume_duration_seconds",
+		Help:      "Time spent processing a consumed message by topic.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	consumeErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consume_errors_total",
+		Help:      "Consumer errors by topic and stage (read, decode, route, apply, save, commit).",
+	}, []string{"topic", "stage"})
+
+	consumerLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consumer_lag",
+		Help:      "Messages between the last consumed offset and the partition high water mark.",
+	}, []string{"topic", "partition"})
+
+	consumerWorkers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consumer_workers",
+		Help:      "Workers processing the messages of a topic concurrently.",
+	}, []string{"topic"})
+
+	consumerQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consumer_queue_depth",
+		Help:      "Fetched messages of a topic waiting for a worker or for an earlier message of the same account.",
+	}, []string{"topic"})
+
+	transactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_total",
+		Help:      "Applied transactions by type and currency.",
+	}, []string{"type", "currency"})
+
+	transactionVolume = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transaction_volume_total",
+		Help:      "Sum of applied transaction amounts by type and currency.",
+	}, []string{"type", "currency"})
+
+	reconciliationMismatches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "reconciliation_mismatches",
+		Help:      "Accounts whose balance differed from their transaction log in the last reconciliation, by tenant.",
+	}, []string{"tenant"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		grpcRequests, grpcDuration,
+		publishDuration, publishFailures,
+		consumeDuration, consumeErrors, consumerLag,
+		consumerWorkers, consumerQueueDepth,
+		transactions, transactionVolume,
+		reconciliationMismatches,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest records a served request. route is the ServeMux pattern,
+// never the raw path, to keep label cardinality bounded.
+func ObserveHTTPRequest(method, route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// ObserveGRPCRequest records a served RPC by its full method name.
+func ObserveGRPCRequest(method, code string, elapsed time.Duration) {
+	grpcRequests.WithLabelValues(method, code).Inc()
+	grpcDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+func ObservePublish(topic string, elapsed time.Duration, err error) {
+	publishDuration.WithLabelValues(topic).Observe(elapsed.Seconds())
+	if err != nil {
+		publishFailures.WithLabelValues(topic).Inc()
+	}
+}
+
+func ObserveConsume(topic string, elapsed time.Duration) {
+	consumeDuration.WithLabelValues(topic).Observe(elapsed.Seconds())
+}
+
+func ConsumeError(topic, stage string) {
+	consumeErrors.WithLabelValues(topic, stage).Inc()
+}
+
+// SetConsumerLag records the lag of a partition after reading the message at offset.
+func SetConsumerLag(topic string, partition int, offset, highWaterMark int64) {
+	lag := highWaterMark - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	consumerLag.WithLabelValues(topic, strconv.Itoa(partition)).Set(float64(lag))
+}
+
+func SetConsumerWorkers(topic string, workers int) {
+	consumerWorkers.WithLabelValues(topic).Set(float64(workers))
+}
+
+func SetConsumerQueueDepth(topic string, depth int) {
+	consumerQueueDepth.WithLabelValues(topic).Set(float64(depth))
+}
+
+// ObserveTransaction counts an applied transaction and its amount.
+func ObserveTransaction(transactionType, currency string, amount float64) {
+	if currency == "" {
+		currency = "unknown"
+	}
+	transactions.WithLabelValues(transactionType, currency).Inc()
+	transactionVolume.WithLabelValues(transactionType, currency).Add(amount)
+}
+
+// SetReconciliationMismatches records the outcome of the last reconciliation of a tenant.
+func SetReconciliationMismatches(tenantID string, mismatches int) {
+	reconciliationMismatches.WithLabelValues(tenantID).Set(float64(mismatches))
+}
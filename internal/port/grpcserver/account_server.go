@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"context"
+
+	pb "github.com/serikdev/CashFlow/api/cashflow/v1"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// maxPageSize is the largest page AccountService.List returns.
+const maxPageSize = 100
+
+type accountServer struct {
+	pb.UnimplementedAccountServiceServer
+	service AccountUsecase
+	logger  *logrus.Entry
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.Account, error) {
+	account, err := s.service.Create(ctx, &entity.Account{
+		Balance:  req.GetBalance(),
+		Currency: req.GetCurrency(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBAccount(account), nil
+}
+
+func (s *accountServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.Account, error) {
+	account, err := s.service.GetByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBAccount(account), nil
+}
+
+func (s *accountServer) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*emptypb.Empty, error) {
+	if err := s.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *accountServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	page, limit := int(req.GetPage()), int(req.GetLimit())
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	limit = min(limit, maxPageSize)
+
+	accounts, total, err := s.service.List(ctx, page, limit)
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+
+	resp := &pb.ListAccountsResponse{
+		Total:       int32(total),
+		CurrentPage: int32(page),
+		LastPage:    int32((total + limit - 1) / limit),
+	}
+	for i := range accounts {
+		resp.Data = append(resp.Data, toPBAccount(&accounts[i]))
+	}
+	return resp, nil
+}
+
+func (s *accountServer) GrantAccess(ctx context.Context, req *pb.GrantAccessRequest) (*pb.AccountAccess, error) {
+	access, err := s.service.GrantAccess(ctx, req.GetAccountId(), req.GetSubject(), entity.AccountRelation(req.GetRelation()))
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBAccess(access), nil
+}
+
+func (s *accountServer) LockAccount(ctx context.Context, req *pb.LockAccountRequest) (*pb.Account, error) {
+	account, err := s.service.Lock(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBAccount(account), nil
+}
+
+func (s *accountServer) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.Account, error) {
+	account, err := s.service.Unlock(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBAccount(account), nil
+}
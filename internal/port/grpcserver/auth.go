@@ -0,0 +1,127 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/serikdev/CashFlow/api/cashflow/v1"
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/clientip"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// methodPermissions applies the REST permission matrix to every RPC.
+var methodPermissions = map[string]auth.Permission{
+	pb.AccountService_CreateAccount_FullMethodName: auth.PermAccountCreate,
+	pb.AccountService_GetAccount_FullMethodName:    auth.PermAccountRead,
+	pb.AccountService_DeleteAccount_FullMethodName: auth.PermAccountDelete,
+	pb.AccountService_ListAccounts_FullMethodName:  auth.PermAccountList,
+	pb.AccountService_GrantAccess_FullMethodName:   auth.PermAccountShare,
+	pb.AccountService_LockAccount_FullMethodName:   auth.PermAccountLock,
+	pb.AccountService_UnlockAccount_FullMethodName: auth.PermAccountLock,
+
+	pb.TransactionService_Deposit_FullMethodName:                  auth.PermTransactionDeposit,
+	pb.TransactionService_Withdraw_FullMethodName:                 auth.PermTransactionWithdraw,
+	pb.TransactionService_Transfer_FullMethodName:                 auth.PermTransactionTransfer,
+	pb.TransactionService_ListTransactions_FullMethodName:         auth.PermTransactionList,
+	pb.TransactionService_StreamTransactionHistory_FullMethodName: auth.PermTransactionList,
+}
+
+// publicMethods need no credentials. Any other method missing from
+// methodPermissions is denied, so that a new RPC is not public by mistake.
+var publicMethods = map[string]bool{
+	grpc_reflection_v1.ServerReflection_ServerReflectionInfo_FullMethodName:      true,
+	grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfo_FullMethodName: true,
+	grpc_health_v1.Health_Check_FullMethodName:                                   true,
+	grpc_health_v1.Health_List_FullMethodName:                                    true,
+	grpc_health_v1.Health_Watch_FullMethodName:                                   true,
+}
+
+type authInterceptor struct {
+	authn  Authenticator
+	logger *logrus.Entry
+}
+
+func (a *authInterceptor) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authInterceptor) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorize resolves the caller from "authorization: Bearer <key>" or
+// "x-api-key" metadata. Only reflection and health methods are public;
+// methods outside the matrix are denied.
+func (a *authInterceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+	perm, ok := methodPermissions[method]
+	if !ok {
+		logger.FromContext(ctx, a.logger).WithField("method", method).Warn("gRPC method missing from the permission matrix")
+		return nil, status.Errorf(codes.PermissionDenied, "method %s is not allowed", method)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	credential := first(md.Get("x-api-key"))
+	if bearer, ok := strings.CutPrefix(first(md.Get("authorization")), "Bearer "); ok {
+		credential = strings.TrimSpace(bearer)
+	}
+	if credential == "" {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	principal, err := a.authn.Authenticate(ctx, credential)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	if !principal.Role.Can(perm) {
+		logger.FromContext(ctx, a.logger).WithFields(logrus.Fields{
+			"method":  method,
+			"subject": principal.Subject,
+			"role":    principal.Role,
+		}).Warn("gRPC permission denied")
+		return nil, status.Errorf(codes.PermissionDenied, "role %s is not allowed to %s", principal.Role, perm)
+	}
+
+	ctx = auth.WithPrincipal(ctx, principal)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ctx = clientip.WithIP(ctx, clientip.FromAddr(p.Addr.String()))
+	}
+	return tenant.WithID(ctx, principal.TenantID), nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,88 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/serikdev/CashFlow/api/cashflow/v1"
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+type keyAuthenticator map[string]*auth.Principal
+
+func (k keyAuthenticator) Authenticate(_ context.Context, credential string) (*auth.Principal, error) {
+	if p, ok := k[credential]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrUnauthenticated
+}
+
+func testLogger() *logrus.Entry {
+	l := logrus.New()
+	l.SetLevel(logrus.PanicLevel)
+	return logrus.NewEntry(l)
+}
+
+func TestAuthorize(t *testing.T) {
+	a := &authInterceptor{
+		authn: keyAuthenticator{
+			"alice-secret": {Subject: "alice", Role: auth.RoleCustomer, TenantID: "acme"},
+			"root-secret":  {Subject: "root", Role: auth.RoleAdmin, TenantID: "acme"},
+		},
+		logger: testLogger(),
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		want   codes.Code
+	}{
+		{name: "reflection is public", method: grpc_reflection_v1.ServerReflection_ServerReflectionInfo_FullMethodName, want: codes.OK},
+		{name: "health is public", method: grpc_health_v1.Health_Check_FullMethodName, want: codes.OK},
+		{name: "unknown method", method: "/cashflow.v1.AccountService/Purge", want: codes.PermissionDenied},
+		{name: "unknown method of an admin", method: "/cashflow.v1.AccountService/Purge", key: "root-secret", want: codes.PermissionDenied},
+		{name: "no credentials", method: pb.AccountService_GetAccount_FullMethodName, want: codes.Unauthenticated},
+		{name: "invalid credentials", method: pb.AccountService_GetAccount_FullMethodName, key: "wrong", want: codes.Unauthenticated},
+		{name: "allowed", method: pb.AccountService_GetAccount_FullMethodName, key: "alice-secret", want: codes.OK},
+		{name: "role not allowed", method: pb.AccountService_LockAccount_FullMethodName, key: "alice-secret", want: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.key != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tt.key))
+			}
+			_, err := a.authorize(ctx, tt.method)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("authorize(%s) = %v, want %v", tt.method, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{err: errs.NotFound("account with 1 not found"), code: codes.NotFound, message: "account with 1 not found"},
+		{err: errs.InsufficientFunds("account 1 has insufficient funds"), code: codes.FailedPrecondition, message: "account 1 has insufficient funds"},
+		{err: errors.New("error to fetch account: dial tcp 10.0.0.5:5432: connection refused"), code: codes.Internal, message: "internal error"},
+	}
+	for _, tt := range tests {
+		s := status.Convert(toStatus(context.Background(), testLogger(), tt.err))
+		if s.Code() != tt.code || s.Message() != tt.message {
+			t.Errorf("toStatus(%q) = %v %q, want %v %q", tt.err, s.Code(), s.Message(), tt.code, tt.message)
+		}
+	}
+}
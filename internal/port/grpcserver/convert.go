@@ -0,0 +1,49 @@
+package grpcserver
+
+import (
+	pb "github.com/serikdev/CashFlow/api/cashflow/v1"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toPBAccount(a *entity.Account) *pb.Account {
+	account := &pb.Account{
+		Id:        int64(a.ID),
+		TenantId:  a.TenantID,
+		Balance:   a.Balance,
+		Currency:  a.Currency,
+		IsLocked:  a.IsLocked,
+		CreatedAt: timestamppb.New(a.CreatedAt),
+	}
+	if a.DeletedAt != nil {
+		account.DeletedAt = timestamppb.New(*a.DeletedAt)
+	}
+	return account
+}
+
+func toPBTransaction(t *entity.Transaction) *pb.Transaction {
+	tx := &pb.Transaction{
+		Id:              int64(t.ID),
+		TenantId:        t.TenantID,
+		AccountId:       int64(t.AccountID),
+		Amount:          t.Amount,
+		TransactionType: t.TransactionType,
+		CreatedAt:       timestamppb.New(t.CreatedAt),
+		EventId:         t.EventID,
+	}
+	if t.RelatedAccount != nil {
+		related := int64(*t.RelatedAccount)
+		tx.RelatedAccountId = &related
+	}
+	return tx
+}
+
+func toPBAccess(a *entity.AccountAccess) *pb.AccountAccess {
+	return &pb.AccountAccess{
+		TenantId:  a.TenantID,
+		AccountId: a.AccountID,
+		Subject:   a.Subject,
+		Relation:  string(a.Relation),
+		CreatedAt: timestamppb.New(a.CreatedAt),
+	}
+}
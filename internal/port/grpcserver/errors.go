@@ -0,0 +1,35 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps usecase errors to gRPC status codes, mirroring the HTTP
+// status each REST handler returns for the same call. Other errors are
+// logged and returned as a fixed message, since they may carry database or
+// broker details.
+func toStatus(ctx context.Context, log *logrus.Entry, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, errs.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, errs.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errs.ErrLocked), errors.Is(err, errs.ErrInsufficientFunds), errors.Is(err, errs.ErrConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		logger.FromContext(ctx, log).WithError(err).Error("gRPC call failed")
+		return status.Error(codes.Internal, "internal error")
+	}
+}
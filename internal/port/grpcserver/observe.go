@@ -0,0 +1,133 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key of the request ID, X-Request-ID in REST.
+const requestIDKey = "x-request-id"
+
+// observer gives every RPC what the REST middleware gives a request: a
+// request ID, a server span continuing the caller's trace, a request-scoped
+// logger, an access log line and the request metrics.
+type observer struct {
+	logger *logrus.Entry
+}
+
+func (o *observer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, finish := o.start(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	finish(err)
+	return resp, err
+}
+
+func (o *observer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, finish := o.start(ss.Context(), info.FullMethod)
+	err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	finish(err)
+	return err
+}
+
+// start prepares the context of an RPC. finish records its outcome.
+func (o *observer) start(ctx context.Context, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := first(md.Get(requestIDKey))
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id)); err != nil {
+		o.logger.WithError(err).Debug("Request ID header cannot be set")
+	}
+	ctx = requestid.WithID(ctx, id)
+
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, span := tracing.Tracer().Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(name)),
+	)
+
+	log := o.logger.WithFields(logrus.Fields{
+		"request_id": id,
+		"trace_id":   span.SpanContext().TraceID().String(),
+	})
+	ctx = logger.WithContext(ctx, log)
+
+	return ctx, func(err error) {
+		code := status.Code(err)
+		elapsed := time.Since(start)
+
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		if serverError(code) {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+
+		metrics.ObserveGRPCRequest(method, code.String(), elapsed)
+
+		entry := log.WithFields(logrus.Fields{
+			"method":      method,
+			"code":        code.String(),
+			"duration_ms": elapsed.Milliseconds(),
+		})
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			entry = entry.WithField("remote_addr", p.Addr.String())
+		}
+		switch {
+		case serverError(code):
+			entry.WithError(err).Error("gRPC request")
+		case code != codes.OK:
+			entry.Warn("gRPC request")
+		default:
+			entry.Info("gRPC request")
+		}
+	}
+}
+
+// serverError reports whether code means the server failed, like a 5xx
+// status in REST.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// metadataCarrier lets the W3C propagator read incoming metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return first(metadata.MD(c).Get(key))
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
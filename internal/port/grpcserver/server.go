@@ -0,0 +1,63 @@
+package grpcserver
+
+import (
+	"context"
+
+	pb "github.com/serikdev/CashFlow/api/cashflow/v1"
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// AccountUsecase is the account service shared with the REST handlers.
+type AccountUsecase interface {
+	Create(ctx context.Context, account *entity.Account) (*entity.Account, error)
+	GetByID(ctx context.Context, id int64) (*entity.Account, error)
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context, page, limit int) ([]entity.Account, int, error)
+	GrantAccess(ctx context.Context, accountID int64, subject string, relation entity.AccountRelation) (*entity.AccountAccess, error)
+	Lock(ctx context.Context, id int64) (*entity.Account, error)
+	Unlock(ctx context.Context, id int64) (*entity.Account, error)
+}
+
+// TransactionUsecase is the transaction service shared with the REST handlers.
+type TransactionUsecase interface {
+	Deposit(ctx context.Context, accountID int64, amount float64) (*entity.Transaction, error)
+	Withdraw(ctx context.Context, accountID int64, amount float64) (*entity.Transaction, error)
+	Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) (*entity.Transaction, error)
+	ListTransactions(ctx context.Context, accountID int64) ([]entity.Transaction, error)
+	History(ctx context.Context, accountID, afterID int64, limit int) ([]entity.Transaction, error)
+}
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+type Deps struct {
+	AccountService     AccountUsecase
+	TransactionService TransactionUsecase
+	Authenticator      Authenticator
+	Logger             *logrus.Entry
+}
+
+// NewServer builds the gRPC server exposing the same usecases as the REST API.
+func NewServer(deps Deps) *grpc.Server {
+	logger := deps.Logger.WithField("component", "grpc")
+	observer := &observer{logger: logger}
+	interceptor := &authInterceptor{authn: deps.Authenticator, logger: logger}
+
+	// Outermost first, as in the REST middleware: request ID, tracing,
+	// access log and metrics, then authentication.
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(observer.unary, interceptor.unary),
+		grpc.ChainStreamInterceptor(observer.stream, interceptor.stream),
+	)
+
+	pb.RegisterAccountServiceServer(server, &accountServer{service: deps.AccountService, logger: logger})
+	pb.RegisterTransactionServiceServer(server, &transactionServer{service: deps.TransactionService, logger: logger})
+	reflection.Register(server)
+
+	return server
+}
@@ -0,0 +1,76 @@
+package grpcserver
+
+import (
+	"context"
+
+	pb "github.com/serikdev/CashFlow/api/cashflow/v1"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+const historyBatchSize = 500
+
+type transactionServer struct {
+	pb.UnimplementedTransactionServiceServer
+	service TransactionUsecase
+	logger  *logrus.Entry
+}
+
+func (s *transactionServer) Deposit(ctx context.Context, req *pb.DepositRequest) (*pb.Transaction, error) {
+	tx, err := s.service.Deposit(ctx, req.GetAccountId(), req.GetAmount())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBTransaction(tx), nil
+}
+
+func (s *transactionServer) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*pb.Transaction, error) {
+	tx, err := s.service.Withdraw(ctx, req.GetAccountId(), req.GetAmount())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBTransaction(tx), nil
+}
+
+func (s *transactionServer) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.Transaction, error) {
+	tx, err := s.service.Transfer(ctx, req.GetFromAccountId(), req.GetToAccountId(), req.GetAmount())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+	return toPBTransaction(tx), nil
+}
+
+func (s *transactionServer) ListTransactions(ctx context.Context, req *pb.ListTransactionsRequest) (*pb.ListTransactionsResponse, error) {
+	txs, err := s.service.ListTransactions(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, toStatus(ctx, s.logger, err)
+	}
+
+	resp := &pb.ListTransactionsResponse{}
+	for i := range txs {
+		resp.Transactions = append(resp.Transactions, toPBTransaction(&txs[i]))
+	}
+	return resp, nil
+}
+
+// StreamTransactionHistory pages through the transaction log in batches until it is exhausted.
+func (s *transactionServer) StreamTransactionHistory(req *pb.StreamTransactionHistoryRequest, stream grpc.ServerStreamingServer[pb.Transaction]) error {
+	ctx := stream.Context()
+	afterID := req.GetAfterId()
+
+	for {
+		txs, err := s.service.History(ctx, req.GetAccountId(), afterID, historyBatchSize)
+		if err != nil {
+			return toStatus(ctx, s.logger, err)
+		}
+		for i := range txs {
+			if err := stream.Send(toPBTransaction(&txs[i])); err != nil {
+				return err
+			}
+			afterID = int64(txs[i].ID)
+		}
+		if len(txs) < historyBatchSize {
+			return nil
+		}
+	}
+}
@@ -2,12 +2,12 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/port/rest/handler/dto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,6 +17,9 @@ type AccountUsecase interface {
 	GetByID(ctx context.Context, id int64) (*entity.Account, error)
 	Delete(ctx context.Context, id int64) error
 	List(ctx context.Context, page, limit int) ([]entity.Account, int, error)
+	GrantAccess(ctx context.Context, accountID int64, subject string, relation entity.AccountRelation) (*entity.AccountAccess, error)
+	Lock(ctx context.Context, id int64) (*entity.Account, error)
+	Unlock(ctx context.Context, id int64) (*entity.Account, error)
 }
 type AccountHandler struct {
 	*BaseHandler
@@ -45,22 +48,24 @@ func NewAccountHandler(baseHandler *BaseHandler, service AccountUsecase, logger
 // @Router /accounts [post]
 func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var input entity.Account
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, "invalid request body")
+	var payload dto.CreateAccountRequest
+	if !h.DecodeAndValidate(w, r, &payload) {
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
-	account, err := h.service.Create(ctx, &input)
+	account, err := h.service.Create(ctx, &entity.Account{
+		Balance:  payload.Balance,
+		Currency: payload.Currency,
+	})
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to create account")
-		h.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		h.RequestLogger(r).WithError(err).Error("Failed to create account")
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
 
@@ -75,16 +80,17 @@ func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "ID аккаунта"
 // @Success 200 {object} entity.Account
 // @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
 // @Failure 404 {object} handler.ErrorResponse
 // @Router /accounts/{id} [get]
 func (h *AccountHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 	id, err := h.GetIDFromPath(r)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -92,7 +98,7 @@ func (h *AccountHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	account, err := h.service.GetByID(ctx, id)
 	if err != nil {
-		h.RespondWithError(w, http.StatusNotFound, err.Error())
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
 
@@ -106,17 +112,18 @@ func (h *AccountHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "ID аккаунта"
 // @Success 204 "No Content"
 // @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
 // @Failure 404 {object} handler.ErrorResponse
 // @Router /accounts/{id} [delete]
 func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	id, err := h.GetIDFromPath(r)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -124,12 +131,12 @@ func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	if err := h.service.Delete(ctx, id); err != nil {
-		h.logger.WithError(err).WithField("account_id", id).Error("Failed to delete account")
-		h.RespondWithError(w, http.StatusInternalServerError, "Failed to delete account")
+		h.RequestLogger(r).WithError(err).WithField("account_id", id).Error("Failed to delete account")
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
 
-	h.logger.Info("Successfully deleted id: ", id)
+	h.RequestLogger(r).Info("Successfully deleted id: ", id)
 	h.RespondWithJSON(w, http.StatusNoContent, nil)
 }
 
@@ -144,7 +151,7 @@ func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Router /accounts [get]
 func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -161,13 +168,13 @@ func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
 		limit = 10
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	account, total, err := h.service.List(ctx, page, limit)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to fetch account")
-		h.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		h.RequestLogger(r).WithError(err).Error("Failed to fetch account")
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
 
@@ -180,3 +187,96 @@ func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
 		},
 	})
 }
+
+// GrantAccess godoc
+// @Summary Выдать доступ к счету
+// @Description Владелец счета выдает другому пользователю роль viewer или signatory; роль owner выдает только администратор.
+// @Description Если у вызывающего нет права делиться счетом, ответ 404, как для несуществующего счета
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "ID аккаунта"
+// @Param request body dto.GrantAccessRequest true "Доступ"
+// @Success 200 {object} entity.AccountAccess
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Router /accounts/{id}/access [put]
+func (h *AccountHandler) GrantAccess(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetIDFromPath(r)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var payload dto.GrantAccessRequest
+	if !h.DecodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	access, err := h.service.GrantAccess(ctx, id, payload.Subject, entity.AccountRelation(payload.Relation))
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+
+	h.RespondWithJSON(w, http.StatusOK, access)
+}
+
+// Lock godoc
+// @Summary Заблокировать счет
+// @Tags accounts
+// @Produce json
+// @Param id path int true "ID аккаунта"
+// @Success 200 {object} entity.Account
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Router /accounts/{id}/lock [post]
+func (h *AccountHandler) Lock(w http.ResponseWriter, r *http.Request) {
+	h.setLocked(w, r, h.service.Lock)
+}
+
+// Unlock godoc
+// @Summary Разблокировать счет
+// @Tags accounts
+// @Produce json
+// @Param id path int true "ID аккаунта"
+// @Success 200 {object} entity.Account
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Router /accounts/{id}/unlock [post]
+func (h *AccountHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	h.setLocked(w, r, h.service.Unlock)
+}
+
+func (h *AccountHandler) setLocked(w http.ResponseWriter, r *http.Request, update func(context.Context, int64) (*entity.Account, error)) {
+	if r.Method != http.MethodPost {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetIDFromPath(r)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	account, err := update(ctx, id)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+
+	h.RespondWithJSON(w, http.StatusOK, account)
+}
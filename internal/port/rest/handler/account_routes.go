@@ -1,10 +1,17 @@
 package handler
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
 
 func RegisterAccountRouter(mux *http.ServeMux, accountHandler *AccountHandler) {
-	mux.HandleFunc("POST /api/accounts", accountHandler.Create)
-	mux.HandleFunc("GET /api/accounts/{id}", accountHandler.GetByID)
-	mux.HandleFunc("DELETE /api/accounts/{id}", accountHandler.Delete)
-	mux.HandleFunc("GET /api/accounts", accountHandler.List)
+	mux.HandleFunc("POST /api/accounts", accountHandler.Authorize(auth.PermAccountCreate, accountHandler.Create))
+	mux.HandleFunc("GET /api/accounts/{id}", accountHandler.Authorize(auth.PermAccountRead, accountHandler.GetByID))
+	mux.HandleFunc("DELETE /api/accounts/{id}", accountHandler.Authorize(auth.PermAccountDelete, accountHandler.Delete))
+	mux.HandleFunc("GET /api/accounts", accountHandler.Authorize(auth.PermAccountList, accountHandler.List))
+	mux.HandleFunc("PUT /api/accounts/{id}/access", accountHandler.Authorize(auth.PermAccountShare, accountHandler.GrantAccess))
+	mux.HandleFunc("POST /api/accounts/{id}/lock", accountHandler.Authorize(auth.PermAccountLock, accountHandler.Lock))
+	mux.HandleFunc("POST /api/accounts/{id}/unlock", accountHandler.Authorize(auth.PermAccountLock, accountHandler.Unlock))
 }
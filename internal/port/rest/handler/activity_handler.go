@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+type ActivityUsecase interface {
+	Subscribe(ctx context.Context, accountID int64) (<-chan entity.AccountActivity, func(), error)
+	Replay(ctx context.Context, accountID, lastEventID int64, send func(entity.AccountActivity) error) error
+	Snapshot(ctx context.Context, accountID int64) (*entity.AccountActivity, error)
+}
+
+type ActivityHandler struct {
+	*BaseHandler
+	service   ActivityUsecase
+	heartbeat time.Duration
+	logger    *logrus.Entry
+}
+
+func NewActivityHandler(baseHandler *BaseHandler, service ActivityUsecase, heartbeat time.Duration, logger *logrus.Entry) *ActivityHandler {
+	return &ActivityHandler{
+		BaseHandler: baseHandler,
+		service:     service,
+		heartbeat:   heartbeat,
+		logger:      logger,
+	}
+}
+
+// Stream godoc
+// @Summary Поток событий счета (SSE)
+// @Description Server-Sent Events: событие transaction после каждой примененной транзакции (id = ID транзакции), событие balance с текущим балансом и комментарии heartbeat. Заголовок Last-Event-ID продолжает поток из журнала транзакций.
+// @Tags accounts
+// @Produce text/event-stream
+// @Param id path int true "ID аккаунта"
+// @Param Last-Event-ID header int false "ID последней полученной транзакции"
+// @Success 200 {object} entity.AccountActivity
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Router /accounts/{id}/events [get]
+func (h *ActivityHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetIDFromPath(r)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var lastEventID int64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastEventID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.RespondWithError(w, r, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	// Subscribe before replaying so nothing applied in between is lost.
+	events, unsubscribe, err := h.service.Subscribe(ctx, id)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	defer unsubscribe()
+
+	snapshot, err := h.service.Snapshot(ctx, id)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+
+	// The server WriteTimeout would otherwise cut long-lived streams.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.RequestLogger(r).WithError(err).Debug("Write deadline cannot be cleared for SSE stream")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Transactions can commit out of ID order, so live events are checked
+	// against the IDs replayed rather than the highest one.
+	replayed := make(map[int]bool)
+	if lastEventID > 0 {
+		err := h.service.Replay(ctx, id, lastEventID, func(activity entity.AccountActivity) error {
+			replayed[activity.Transaction.ID] = true
+			return h.writeTransaction(w, activity)
+		})
+		if err != nil {
+			h.RequestLogger(r).WithError(err).Error("Failed to replay account activity")
+			return
+		}
+	}
+	if err := h.writeEvent(w, "", "balance", snapshot); err != nil {
+		return
+	}
+	rc.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case activity, ok := <-events:
+			if !ok {
+				return
+			}
+			if activity.Transaction != nil && replayed[activity.Transaction.ID] {
+				// Each transaction is announced once, so it is not seen again.
+				delete(replayed, activity.Transaction.ID)
+				continue
+			}
+			if err := h.writeTransaction(w, activity); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *ActivityHandler) writeTransaction(w http.ResponseWriter, activity entity.AccountActivity) error {
+	id := ""
+	if activity.Transaction != nil {
+		id = strconv.Itoa(activity.Transaction.ID)
+	}
+	return h.writeEvent(w, id, "transaction", activity)
+}
+
+func (h *ActivityHandler) writeEvent(w http.ResponseWriter, id, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed encoding SSE event")
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,11 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
+
+func RegisterActivityRouter(mux *http.ServeMux, activityHandler *ActivityHandler) {
+	mux.HandleFunc("GET /api/accounts/{id}/events", activityHandler.Authorize(auth.PermAccountRead, activityHandler.Stream))
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditUsecase interface {
+	List(ctx context.Context, filter entity.AuditFilter, page, limit int) ([]entity.AuditEntry, int, error)
+}
+
+type AuditHandler struct {
+	*BaseHandler
+	service AuditUsecase
+	logger  *logrus.Entry
+}
+
+func NewAuditHandler(baseHandler *BaseHandler, service AuditUsecase, logger *logrus.Entry) *AuditHandler {
+	return &AuditHandler{
+		BaseHandler: baseHandler,
+		service:     service,
+		logger:      logger,
+	}
+}
+
+// List godoc
+// @Summary Журнал аудита
+// @Description Возвращает записи журнала аудита тенанта, новые первыми. Доступно ролям admin и auditor
+// @Tags audit
+// @Produce json
+// @Param actor query string false "Субъект, выполнивший действие"
+// @Param action query string false "Действие, например account.lock"
+// @Param account_id query int false "ID счета"
+// @Param transaction_id query int false "ID транзакции"
+// @Param request_id query string false "ID запроса"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода, не включая (RFC 3339)"
+// @Param page query int false "Номер страницы"
+// @Param limit query int false "Количество элементов (до 500)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /audit [get]
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := parseAuditFilter(query)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	entries, total, err := h.service.List(ctx, filter, page, limit)
+	if err != nil {
+		h.RequestLogger(r).WithError(err).Error("Failed to fetch audit log")
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+
+	h.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"data": entries,
+		"meta": map[string]interface{}{
+			"total":        total,
+			"current_page": page,
+			"last_page":    (total + limit - 1) / limit,
+		},
+	})
+}
+
+func parseAuditFilter(query url.Values) (entity.AuditFilter, error) {
+	filter := entity.AuditFilter{
+		ActorSubject: query.Get("actor"),
+		Action:       query.Get("action"),
+		RequestID:    query.Get("request_id"),
+	}
+
+	var err error
+	if v := query.Get("account_id"); v != "" {
+		if filter.AccountID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid account_id format: %w", err)
+		}
+	}
+	if v := query.Get("transaction_id"); v != "" {
+		if filter.TransactionID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return filter, fmt.Errorf("invalid transaction_id format: %w", err)
+		}
+	}
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("invalid from, expected RFC 3339: %w", err)
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, fmt.Errorf("invalid to, expected RFC 3339: %w", err)
+		}
+	}
+	return filter, nil
+}
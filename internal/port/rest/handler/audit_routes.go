@@ -0,0 +1,11 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
+
+func RegisterAuditRouter(mux *http.ServeMux, auditHandler *AuditHandler) {
+	mux.HandleFunc("GET /api/audit", auditHandler.Authorize(auth.PermAuditRead, auditHandler.List))
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+// Authenticate resolves the caller from the Authorization bearer token or the
+// X-API-Key header. Requests without credentials pass through anonymously and
+// are rejected by Authorize on protected routes.
+func (b *BaseHandler) Authenticate(authn Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			credential = strings.TrimSpace(bearer)
+		}
+		if credential == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := authn.Authenticate(r.Context(), credential)
+		if err != nil {
+			b.RespondWithErrorCode(w, r, http.StatusUnauthorized, ErrCodeUnauthenticated, "invalid credentials")
+			return
+		}
+		ctx := auth.WithPrincipal(r.Context(), principal)
+		ctx = tenant.WithID(ctx, principal.TenantID)
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx, b.logger).WithFields(logrus.Fields{
+			"subject":   principal.Subject,
+			"tenant_id": principal.TenantID,
+		}))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authorize rejects callers whose role lacks the permission for the wrapped handler method.
+func (b *BaseHandler) Authorize(perm auth.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			b.RespondWithErrorCode(w, r, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+			return
+		}
+		if !principal.Role.Can(perm) {
+			b.RespondWithErrorCode(w, r, http.StatusForbidden, ErrCodePermissionDenied,
+				fmt.Sprintf("role %s is not allowed to %s", principal.Role, perm))
+			return
+		}
+		next(w, r)
+	}
+}
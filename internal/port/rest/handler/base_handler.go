@@ -2,11 +2,18 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/serikdev/CashFlow/internal/usecase"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/serikdev/CashFlow/pkg/validator"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,26 +25,141 @@ func NewBaseHandler(logger *logrus.Entry) BaseHandler {
 	return BaseHandler{logger: logger}
 }
 
+// ErrorResponse is an RFC 7807 problem details document, served as application/problem+json.
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Type      string                 `json:"type" example:"/problems/validation-failed"`
+	Title     string                 `json:"title" example:"Bad Request"`
+	Status    int                    `json:"status" example:"400"`
+	Detail    string                 `json:"detail,omitempty"`
+	Instance  string                 `json:"instance,omitempty"`
+	ErrorCode string                 `json:"error_code,omitempty" example:"VALIDATION_FAILED"`
+	Errors    []validator.FieldError `json:"errors,omitempty"`
 }
 
-func (b *BaseHandler) RespondWithError(w http.ResponseWriter, code int, message string) {
-	b.logger.WithFields(logrus.Fields{
-		"code":    code,
-		"message": message,
+const ProblemContentType = "application/problem+json"
+
+const (
+	ErrCodeUnauthenticated     = "UNAUTHENTICATED"
+	ErrCodePermissionDenied    = "PERMISSION_DENIED"
+	ErrCodeAccountAccessDenied = "ACCOUNT_ACCESS_DENIED"
+	ErrCodeInvalidRequest      = "INVALID_REQUEST"
+	ErrCodeMethodNotAllowed    = "METHOD_NOT_ALLOWED"
+)
+
+// RespondWithError writes an error whose error code is derived from the HTTP status.
+func (b *BaseHandler) RespondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	errorCode := errs.CodeInternal
+	switch code {
+	case http.StatusBadRequest:
+		errorCode = ErrCodeInvalidRequest
+	case http.StatusMethodNotAllowed:
+		errorCode = ErrCodeMethodNotAllowed
+	case http.StatusNotFound:
+		errorCode = errs.CodeNotFound
+	}
+	b.RespondWithErrorCode(w, r, code, errorCode, message)
+}
+
+func (b *BaseHandler) RespondWithErrorCode(w http.ResponseWriter, r *http.Request, code int, errorCode, message string) {
+	b.RespondWithProblem(w, r, ErrorResponse{
+		Status:    code,
+		ErrorCode: errorCode,
+		Detail:    message,
+	})
+}
+
+// RespondWithProblem fills in the type, title and instance of p and writes it.
+func (b *BaseHandler) RespondWithProblem(w http.ResponseWriter, r *http.Request, p ErrorResponse) {
+	if p.Type == "" {
+		p.Type = problemType(p.ErrorCode)
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = requestid.FromContext(r.Context())
+	}
+
+	logger.FromContext(r.Context(), b.logger).WithFields(logrus.Fields{
+		"code":       p.Status,
+		"error_code": p.ErrorCode,
+		"message":    p.Detail,
+		"instance":   p.Instance,
 	}).Error("API error response")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	response := ErrorResponse{
-		Error:   http.StatusText(code),
-		Code:    code,
-		Message: message,
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// problemType turns an error code such as INSUFFICIENT_FUNDS into /problems/insufficient-funds.
+func problemType(errorCode string) string {
+	if errorCode == "" {
+		return "about:blank"
 	}
-	json.NewEncoder(w).Encode(response)
+	return "/problems/" + strings.ReplaceAll(strings.ToLower(errorCode), "_", "-")
+}
+
+// DecodeAndValidate decodes the JSON body into dst and checks its validate tags.
+// On failure it writes a problem response and returns false.
+func (b *BaseHandler) DecodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		b.RespondWithError(w, r, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+
+	err := validator.Struct(dst)
+	if err == nil {
+		return true
+	}
+	var fieldErrs validator.Errors
+	if !errors.As(err, &fieldErrs) {
+		b.RespondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	b.RespondWithProblem(w, r, ErrorResponse{
+		Status:    http.StatusBadRequest,
+		ErrorCode: errs.CodeValidation,
+		Detail:    "request body has invalid fields",
+		Errors:    fieldErrs,
+	})
+	return false
+}
+
+// RespondWithServiceError maps errors returned by usecases to a status code
+// and a stable error code. Unknown errors are internal server errors.
+func (b *BaseHandler) RespondWithServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		b.RespondWithErrorCode(w, r, http.StatusUnauthorized, ErrCodeUnauthenticated, err.Error())
+	case errors.Is(err, usecase.ErrAccountAccessDenied):
+		b.RespondWithErrorCode(w, r, http.StatusForbidden, ErrCodeAccountAccessDenied, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		b.RespondWithErrorCode(w, r, http.StatusForbidden, ErrCodePermissionDenied, err.Error())
+	default:
+		b.RespondWithErrorCode(w, r, StatusFor(err), errs.Code(err), err.Error())
+	}
+}
+
+// StatusFor returns the HTTP status code of a domain error kind.
+func StatusFor(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrLocked), errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RequestLogger returns the logger scoped to r by the RequestID middleware.
+func (b *BaseHandler) RequestLogger(r *http.Request) *logrus.Entry {
+	return logger.FromContext(r.Context(), b.logger)
 }
 
 func (b *BaseHandler) RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -64,3 +186,12 @@ func (b *BaseHandler) GetIDFromPath(r *http.Request) (int64, error) {
 	}
 	return id, nil
 }
+
+// GetPathID parses a named path wildcard registered on the ServeMux pattern.
+func (b *BaseHandler) GetPathID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s format: %w", name, err)
+	}
+	return id, nil
+}
@@ -1,18 +1,33 @@
 package dto // data transfer object
 
 type CreateAccountRequest struct {
-	Balance  float64 `json:"balance" example:"1000.0"`
-	Currency string  `json:"currency" example:"TMT"`
+	Balance  float64 `json:"balance" example:"1000.0" validate:"gte=0"`
+	Currency string  `json:"currency" example:"TMT" validate:"required,currency"`
 }
 type DepositRequest struct {
-	Amount float64 `json:"amount"`
+	Amount float64 `json:"amount" validate:"gt=0"`
 }
 
 type WithdrawRequest struct {
-	Amount float64 `json:"amount"`
+	Amount float64 `json:"amount" validate:"gt=0"`
 }
 
 type TransferRequest struct {
-	ToAccountID int64   `json:"to_account_id"`
-	Amount      float64 `json:"amount"`
+	ToAccountID int64   `json:"to_account_id" validate:"required,gt=0"`
+	Amount      float64 `json:"amount" validate:"gt=0"`
+}
+
+type GrantAccessRequest struct {
+	Subject  string `json:"subject" example:"alice" validate:"required,max=255"`
+	Relation string `json:"relation" example:"signatory" validate:"required,oneof=viewer signatory owner"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" example:"https://example.com/hooks/cashflow" validate:"required,url"`
+	EventTypes []string `json:"event_types" example:"transaction.completed,account.locked" validate:"required"`
+}
+
+type ReconcileRequest struct {
+	AccountID       int64 `json:"account_id" example:"0" validate:"gte=0"`
+	OpenCorrections bool  `json:"open_corrections" example:"false"`
 }
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/health"
+	"github.com/sirupsen/logrus"
+)
+
+type HealthUsecase interface {
+	Ready(ctx context.Context) health.Report
+}
+
+type HealthHandler struct {
+	*BaseHandler
+	service HealthUsecase
+	logger  *logrus.Entry
+}
+
+func NewHealthHandler(baseHandler *BaseHandler, service HealthUsecase, logger *logrus.Entry) *HealthHandler {
+	return &HealthHandler{
+		BaseHandler: baseHandler,
+		service:     service,
+		logger:      logger,
+	}
+}
+
+// Liveness godoc
+// @Summary Проверка живости процесса
+// @Description Отвечает 200, пока процесс обслуживает запросы; зависимости не проверяются
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.RespondWithJSON(w, http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// Readiness godoc
+// @Summary Проверка готовности
+// @Description Проверяет Postgres, брокеры Kafka и членство консьюмеров в группе; во время остановки возвращает 503
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.service.Ready(r.Context())
+	if report.Status != health.StatusReady {
+		h.RequestLogger(r).WithField("report", report).Warn("Readiness check failed")
+		h.RespondWithJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, report)
+}
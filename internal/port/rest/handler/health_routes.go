@@ -0,0 +1,9 @@
+package handler
+
+import "net/http"
+
+// RegisterHealthRouter mounts the probes at the root, outside /api and without authorization.
+func RegisterHealthRouter(mux *http.ServeMux, healthHandler *HealthHandler) {
+	mux.HandleFunc("GET /healthz", healthHandler.Liveness)
+	mux.HandleFunc("GET /readyz", healthHandler.Readiness)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+type LedgerUsecase interface {
+	Verify(ctx context.Context, accountID int64) (*entity.ChainReport, error)
+	Checkpoint(ctx context.Context) (*entity.LedgerCheckpoint, error)
+	ListCheckpoints(ctx context.Context, limit int) ([]entity.LedgerCheckpoint, string, error)
+}
+
+type LedgerHandler struct {
+	*BaseHandler
+	service LedgerUsecase
+	logger  *logrus.Entry
+}
+
+func NewLedgerHandler(baseHandler *BaseHandler, service LedgerUsecase, logger *logrus.Entry) *LedgerHandler {
+	return &LedgerHandler{
+		BaseHandler: baseHandler,
+		service:     service,
+		logger:      logger,
+	}
+}
+
+// Verify godoc
+// @Summary Проверка цепочки хешей транзакций
+// @Description Проходит цепочки хешей счетов и последнюю подписанную контрольную точку, возвращает первое нарушенное звено
+// @Tags ledger
+// @Produce json
+// @Param account_id query int false "ID счета; без него проверяются все счета"
+// @Success 200 {object} entity.ChainReport
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /ledger/verify [get]
+func (h *LedgerHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var accountID int64
+	if v := r.URL.Query().Get("account_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			h.RespondWithError(w, r, http.StatusBadRequest, "invalid account_id")
+			return
+		}
+		accountID = id
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	report, err := h.service.Verify(ctx, accountID)
+	if err != nil {
+		h.RequestLogger(r).WithError(err).Error("Failed to verify transaction chain")
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, report)
+}
+
+// ListCheckpoints godoc
+// @Summary Подписанные контрольные точки
+// @Description Возвращает последние контрольные точки и открытый ключ Ed25519 для проверки подписей
+// @Tags ledger
+// @Produce json
+// @Param limit query int false "Количество элементов (до 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /ledger/checkpoints [get]
+func (h *LedgerHandler) ListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	checkpoints, publicKey, err := h.service.ListCheckpoints(ctx, limit)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"public_key": publicKey,
+		"data":       checkpoints,
+	})
+}
+
+// CreateCheckpoint godoc
+// @Summary Создать контрольную точку
+// @Description Подписывает текущие вершины цепочек всех счетов тенанта
+// @Tags ledger
+// @Produce json
+// @Success 201 {object} entity.LedgerCheckpoint
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /ledger/checkpoints [post]
+func (h *LedgerHandler) CreateCheckpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	checkpoint, err := h.service.Checkpoint(ctx)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusCreated, checkpoint)
+}
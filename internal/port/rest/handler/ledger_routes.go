@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
+
+func RegisterLedgerRouter(mux *http.ServeMux, ledgerHandler *LedgerHandler) {
+	mux.HandleFunc("GET /api/ledger/verify", ledgerHandler.Authorize(auth.PermAuditRead, ledgerHandler.Verify))
+	mux.HandleFunc("GET /api/ledger/checkpoints", ledgerHandler.Authorize(auth.PermAuditRead, ledgerHandler.ListCheckpoints))
+	mux.HandleFunc("POST /api/ledger/checkpoints", ledgerHandler.Authorize(auth.PermLedgerCheckpoint, ledgerHandler.CreateCheckpoint))
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/clientip"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestID accepts the caller's X-Request-ID or generates one, echoes it in
+// the response and stores it, with the client IP and a request-scoped
+// logger, in the context.
+func (b *BaseHandler) RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+
+		ctx := requestid.WithID(r.Context(), id)
+		ctx = clientip.WithIP(ctx, clientip.FromAddr(r.RemoteAddr))
+		ctx = logger.WithContext(ctx, b.logger.WithField("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Trace starts a server span named after the route pattern, continuing the
+// caller's W3C trace context, and adds the trace ID to the request logger.
+func (b *BaseHandler) Trace(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := routes.Handler(r)
+		name := route
+		if name == "" {
+			name = r.Method + " unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		traceID := span.SpanContext().TraceID().String()
+		ctx = logger.WithContext(ctx, logger.FromContext(ctx, b.logger).WithField("trace_id", traceID))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// AccessLog writes one structured line per request with status and latency.
+// routes resolves the matched pattern so that logs group by route, not by path.
+func (b *BaseHandler) AccessLog(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		_, route := routes.Handler(r)
+
+		next.ServeHTTP(rec, r)
+
+		entry := logger.FromContext(r.Context(), b.logger).WithFields(logrus.Fields{
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"route":       route,
+			"status":      rec.status,
+			"bytes":       rec.bytes,
+			"duration_ms": time.Since(start).Milliseconds(),
+			"remote_addr": r.RemoteAddr,
+			"user_agent":  r.UserAgent(),
+		})
+		switch {
+		case rec.status >= http.StatusInternalServerError:
+			entry.Error("HTTP request")
+		case rec.status >= http.StatusBadRequest:
+			entry.Warn("HTTP request")
+		default:
+			entry.Info("HTTP request")
+		}
+	})
+}
+
+// Metrics records request count and latency per route pattern.
+func (b *BaseHandler) Metrics(routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		_, route := routes.Handler(r)
+
+		next.ServeHTTP(rec, r)
+
+		metrics.ObserveHTTPRequest(r.Method, route, rec.status, time.Since(start))
+	})
+}
+
+// Recover turns a handler panic into a 500 problem response and logs the stack.
+func (b *BaseHandler) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			logger.FromContext(r.Context(), b.logger).WithFields(logrus.Fields{
+				"panic": fmt.Sprint(rec),
+				"stack": string(debug.Stack()),
+			}).Error("Recovered from handler panic")
+			b.RespondWithError(w, r, http.StatusInternalServerError, "internal server error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder captures the status code and body size written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach Flush and deadlines of the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
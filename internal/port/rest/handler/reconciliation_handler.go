@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/port/rest/handler/dto"
+	"github.com/sirupsen/logrus"
+)
+
+type ReconciliationUsecase interface {
+	Reconcile(ctx context.Context, accountID int64, openCorrections bool) (*entity.ReconciliationReport, error)
+	ListCorrections(ctx context.Context, status string, limit int) ([]entity.BalanceCorrection, error)
+	ApproveCorrection(ctx context.Context, id int64) (*entity.BalanceCorrection, error)
+	RejectCorrection(ctx context.Context, id int64) (*entity.BalanceCorrection, error)
+}
+
+type ReconciliationHandler struct {
+	*BaseHandler
+	service ReconciliationUsecase
+	logger  *logrus.Entry
+}
+
+func NewReconciliationHandler(baseHandler *BaseHandler, service ReconciliationUsecase, logger *logrus.Entry) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		BaseHandler: baseHandler,
+		service:     service,
+		logger:      logger,
+	}
+}
+
+// Reconcile godoc
+// @Summary Сверка балансов
+// @Description Пересчитывает балансы счетов по начальному балансу и журналу транзакций и возвращает расхождения. С open_corrections для каждого расхождения открывается корректировка, ожидающая подтверждения
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.ReconcileRequest true "Параметры сверки; account_id 0 — все счета"
+// @Success 200 {object} entity.ReconciliationReport
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /admin/reconcile [post]
+func (h *ReconciliationHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var payload dto.ReconcileRequest
+	if !h.DecodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	report, err := h.service.Reconcile(ctx, payload.AccountID, payload.OpenCorrections)
+	if err != nil {
+		h.RequestLogger(r).WithError(err).Error("Failed to reconcile balances")
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, report)
+}
+
+// ListCorrections godoc
+// @Summary Корректировки балансов
+// @Description Возвращает корректировки тенанта, новые первыми
+// @Tags admin
+// @Produce json
+// @Param status query string false "Статус: pending, approved или rejected"
+// @Param limit query int false "Количество элементов (до 100)"
+// @Success 200 {array} entity.BalanceCorrection
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /admin/corrections [get]
+func (h *ReconciliationHandler) ListCorrections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	corrections, err := h.service.ListCorrections(ctx, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, corrections)
+}
+
+// ApproveCorrection godoc
+// @Summary Подтвердить корректировку
+// @Description Записывает сумму корректировки в журнал транзакций. Подтверждает не тот, кто открыл корректировку; если счет изменился после сверки, возвращается 409
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID корректировки"
+// @Success 200 {object} entity.BalanceCorrection
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Router /admin/corrections/{id}/approve [post]
+func (h *ReconciliationHandler) ApproveCorrection(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.service.ApproveCorrection)
+}
+
+// RejectCorrection godoc
+// @Summary Отклонить корректировку
+// @Tags admin
+// @Produce json
+// @Param id path int true "ID корректировки"
+// @Success 200 {object} entity.BalanceCorrection
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Router /admin/corrections/{id}/reject [post]
+func (h *ReconciliationHandler) RejectCorrection(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.service.RejectCorrection)
+}
+
+func (h *ReconciliationHandler) decide(w http.ResponseWriter, r *http.Request, decide func(context.Context, int64) (*entity.BalanceCorrection, error)) {
+	if r.Method != http.MethodPost {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetPathID(r, "id")
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	correction, err := decide(ctx, id)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, correction)
+}
@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
+
+func RegisterReconciliationRouter(mux *http.ServeMux, reconciliationHandler *ReconciliationHandler) {
+	mux.HandleFunc("POST /api/admin/reconcile", reconciliationHandler.Authorize(auth.PermLedgerReconcile, reconciliationHandler.Reconcile))
+	mux.HandleFunc("GET /api/admin/corrections", reconciliationHandler.Authorize(auth.PermLedgerReconcile, reconciliationHandler.ListCorrections))
+	mux.HandleFunc("POST /api/admin/corrections/{id}/approve", reconciliationHandler.Authorize(auth.PermCorrectionDecide, reconciliationHandler.ApproveCorrection))
+	mux.HandleFunc("POST /api/admin/corrections/{id}/reject", reconciliationHandler.Authorize(auth.PermCorrectionDecide, reconciliationHandler.RejectCorrection))
+}
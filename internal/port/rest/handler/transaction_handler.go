@@ -2,12 +2,14 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/serikdev/CashFlow/internal/entity"
 	"github.com/serikdev/CashFlow/internal/port/rest/handler/dto"
+	"github.com/serikdev/CashFlow/internal/usecase"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,7 +17,21 @@ type TransactionUsecase interface {
 	Deposit(ctx context.Context, accountID int64, amount float64) (*entity.Transaction, error)
 	Withdraw(ctx context.Context, accountID int64, amount float64) (*entity.Transaction, error)
 	Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) (*entity.Transaction, error)
-	ListTransactions(accountID int64) ([]entity.Transaction, error)
+	ListTransactions(ctx context.Context, accountID int64) ([]entity.Transaction, error)
+	Await(ctx context.Context, eventID string, timeout time.Duration) (*entity.Transaction, error)
+	Result(ctx context.Context, eventID string) (*entity.TransactionResult, error)
+}
+
+// maxWait bounds ?wait= so that the response is written before the
+// server's write timeout.
+const maxWait = 25 * time.Second
+
+// PendingTransaction is the response to a submission whose ?wait= ran out
+// before the transaction was applied.
+type PendingTransaction struct {
+	EventID string `json:"event_id" example:"5f0c3d2a9b8e4f6a8c1d2e3f4a5b6c7d"`
+	Status  string `json:"status" example:"pending"`
+	PollURL string `json:"poll_url" example:"/api/transactions/results/5f0c3d2a9b8e4f6a8c1d2e3f4a5b6c7d"`
 }
 
 type TransactionHandler struct {
@@ -40,25 +56,36 @@ func NewTransactionHandler(baseHandler *BaseHandler, service TransactionUsecase,
 // @Produce json
 // @Param id path int true "ID аккаунта"
 // @Param request body dto.DepositRequest true "Сумма пополнения"
+// @Param wait query string false "Дождаться применения транзакции, например 5s (не более 25s)"
 // @Success 201 {object} entity.Transaction
+// @Success 202 {object} handler.PendingTransaction
 // @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 422 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /accounts/{id}/deposit [post]
 func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	id, err := h.GetIDFromPath(r)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	var payload dto.DepositRequest
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, "invalid request body")
+	if !h.DecodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -66,10 +93,10 @@ func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	tx, err := h.service.Deposit(ctx, id, payload.Amount)
 	if err != nil {
-		h.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
-	h.RespondWithJSON(w, http.StatusCreated, tx)
+	h.respondSubmitted(ctx, w, r, tx, wait)
 }
 
 // Withdraw godoc
@@ -79,25 +106,36 @@ func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "ID аккаунта"
 // @Param request body dto.WithdrawRequest true "Сумма снятия"
+// @Param wait query string false "Дождаться применения транзакции, например 5s (не более 25s)"
 // @Success 201 {object} entity.Transaction
+// @Success 202 {object} handler.PendingTransaction
 // @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 422 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /accounts/{id}/withdraw [post]
 func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	id, err := h.GetIDFromPath(r)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	var payload dto.WithdrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, "invalid request body")
+	if !h.DecodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -105,10 +143,10 @@ func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	tx, err := h.service.Withdraw(ctx, id, payload.Amount)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
-	h.RespondWithJSON(w, http.StatusCreated, tx)
+	h.respondSubmitted(ctx, w, r, tx, wait)
 }
 
 // Transfer godoc
@@ -118,25 +156,36 @@ func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "ID аккаунта-отправителя"
 // @Param request body dto.TransferRequest true "Перевод"
+// @Param wait query string false "Дождаться применения транзакции, например 5s (не более 25s)"
 // @Success 201 {object} entity.Transaction
+// @Success 202 {object} handler.PendingTransaction
 // @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 422 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /accounts/{id}/transfer [post]
 func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	fromID, err := h.GetIDFromPath(r)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	var payload dto.TransferRequest
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, "invalid request")
+	if !h.DecodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	wait, err := parseWait(r)
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -144,11 +193,10 @@ func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 	tx, err := h.service.Transfer(ctx, fromID, payload.ToAccountID, payload.Amount)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
-
-	h.RespondWithJSON(w, http.StatusCreated, tx)
+	h.respondSubmitted(ctx, w, r, tx, wait)
 }
 
 // ListTransactions godoc
@@ -158,25 +206,85 @@ func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "ID аккаунта"
 // @Success 200 {array} entity.Transaction
 // @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /accounts/{id}/transactions [get]
 func (h *TransactionHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		h.RespondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	id, err := h.GetIDFromPath(r)
 	if err != nil {
-		h.RespondWithError(w, http.StatusBadRequest, err.Error())
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	txs, err := h.service.ListTransactions(id)
+	txs, err := h.service.ListTransactions(r.Context(), id)
 	if err != nil {
-		h.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		h.RespondWithServiceError(w, r, err)
 		return
 	}
 
 	h.RespondWithJSON(w, http.StatusOK, txs)
 }
+
+// TransactionResult godoc
+// @Summary Результат транзакции
+// @Description Возвращает статус транзакции по event_id: pending, completed или failed
+// @Tags transactions
+// @Produce json
+// @Param event_id path string true "ID события транзакции"
+// @Success 200 {object} entity.TransactionResult
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /transactions/results/{event_id} [get]
+func (h *TransactionHandler) TransactionResult(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.Result(r.Context(), r.PathValue("event_id"))
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, res)
+}
+
+// respondSubmitted writes a submitted transaction. With a wait it first
+// waits for the transaction to be applied: the applied transaction is
+// 201, a rejection is its error and a timeout is 202 with the URL to poll.
+func (h *TransactionHandler) respondSubmitted(ctx context.Context, w http.ResponseWriter, r *http.Request, tx *entity.Transaction, wait time.Duration) {
+	// A transaction with an ID was applied within the request.
+	if wait == 0 || tx.ID != 0 {
+		h.RespondWithJSON(w, http.StatusCreated, tx)
+		return
+	}
+
+	applied, err := h.service.Await(ctx, tx.EventID, wait)
+	switch {
+	case errors.Is(err, usecase.ErrTransactionPending):
+		pollURL := "/api/transactions/results/" + tx.EventID
+		w.Header().Set("Location", pollURL)
+		h.RespondWithJSON(w, http.StatusAccepted, PendingTransaction{
+			EventID: tx.EventID,
+			Status:  entity.ResultPending,
+			PollURL: pollURL,
+		})
+	case err != nil:
+		h.RespondWithServiceError(w, r, err)
+	default:
+		h.RespondWithJSON(w, http.StatusCreated, applied)
+	}
+}
+
+// parseWait reads the optional ?wait= duration.
+func parseWait(r *http.Request) (time.Duration, error) {
+	v := r.URL.Query().Get("wait")
+	if v == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(v)
+	if err != nil || wait < 0 || wait > maxWait {
+		return 0, fmt.Errorf("wait must be a duration between 0s and %s", maxWait)
+	}
+	return wait, nil
+}
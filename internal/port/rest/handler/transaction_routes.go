@@ -1,10 +1,15 @@
 package handler
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
 
 func RegisterTransactionRouter(mux *http.ServeMux, transactionHandler *TransactionHandler) {
-	mux.HandleFunc("POST /api/accounts/{id}/deposit", transactionHandler.Deposit)
-	mux.HandleFunc("POST /api/accounts/{id}/withdraw", transactionHandler.Withdraw)
-	mux.HandleFunc("POST /api/accounts/{id}/transfer", transactionHandler.Transfer)
-	mux.HandleFunc("GET /api/accounts/{id}/transactions", transactionHandler.ListTransactions)
+	mux.HandleFunc("POST /api/accounts/{id}/deposit", transactionHandler.Authorize(auth.PermTransactionDeposit, transactionHandler.Deposit))
+	mux.HandleFunc("POST /api/accounts/{id}/withdraw", transactionHandler.Authorize(auth.PermTransactionWithdraw, transactionHandler.Withdraw))
+	mux.HandleFunc("POST /api/accounts/{id}/transfer", transactionHandler.Authorize(auth.PermTransactionTransfer, transactionHandler.Transfer))
+	mux.HandleFunc("GET /api/accounts/{id}/transactions", transactionHandler.Authorize(auth.PermTransactionList, transactionHandler.ListTransactions))
+	mux.HandleFunc("GET /api/transactions/results/{event_id}", transactionHandler.Authorize(auth.PermTransactionList, transactionHandler.TransactionResult))
 }
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/port/rest/handler/dto"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookUsecase interface {
+	Subscribe(ctx context.Context, url string, eventTypes []string) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	Unsubscribe(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionID int64) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID int64) (*entity.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	*BaseHandler
+	service WebhookUsecase
+	logger  *logrus.Entry
+}
+
+func NewWebhookHandler(baseHandler *BaseHandler, service WebhookUsecase, logger *logrus.Entry) *WebhookHandler {
+	return &WebhookHandler{
+		BaseHandler: baseHandler,
+		service:     service,
+		logger:      logger,
+	}
+}
+
+// Create godoc
+// @Summary Подписка на вебхуки
+// @Description Создает подписку; секрет для проверки подписи HMAC-SHA256 возвращается только в ответе на создание
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateWebhookRequest true "Подписка"
+// @Success 201 {object} entity.WebhookSubscription
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var payload dto.CreateWebhookRequest
+	if !h.DecodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.service.Subscribe(ctx, payload.URL, payload.EventTypes)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusCreated, sub)
+}
+
+// List godoc
+// @Summary Список подписок на вебхуки
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} entity.WebhookSubscription
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	subs, err := h.service.ListSubscriptions(ctx)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, subs)
+}
+
+// Delete godoc
+// @Summary Удалить подписку на вебхуки
+// @Tags webhooks
+// @Param id path int true "ID подписки"
+// @Success 204 "No Content"
+// @Failure 404 {object} handler.ErrorResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetPathID(r, "id")
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := h.service.Unsubscribe(ctx, id); err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// ListDeliveries godoc
+// @Summary Журнал доставок вебхука
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {array} entity.WebhookDelivery
+// @Failure 400 {object} handler.ErrorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetPathID(r, "id")
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	deliveries, err := h.service.ListDeliveries(ctx, id)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// Redeliver godoc
+// @Summary Повторная доставка вебхука
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "ID доставки"
+// @Success 202 {object} entity.WebhookDelivery
+// @Failure 404 {object} handler.ErrorResponse
+// @Router /webhooks/deliveries/{id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.RespondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := h.GetPathID(r, "id")
+	if err != nil {
+		h.RespondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	delivery, err := h.service.Redeliver(ctx, id)
+	if err != nil {
+		h.RespondWithServiceError(w, r, err)
+		return
+	}
+	h.RespondWithJSON(w, http.StatusAccepted, delivery)
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+)
+
+func RegisterWebhookRouter(mux *http.ServeMux, webhookHandler *WebhookHandler) {
+	mux.HandleFunc("POST /api/webhooks", webhookHandler.Authorize(auth.PermWebhookManage, webhookHandler.Create))
+	mux.HandleFunc("GET /api/webhooks", webhookHandler.Authorize(auth.PermWebhookManage, webhookHandler.List))
+	mux.HandleFunc("DELETE /api/webhooks/{id}", webhookHandler.Authorize(auth.PermWebhookManage, webhookHandler.Delete))
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries", webhookHandler.Authorize(auth.PermWebhookManage, webhookHandler.ListDeliveries))
+	mux.HandleFunc("POST /api/webhooks/deliveries/{id}/redeliver", webhookHandler.Authorize(auth.PermWebhookManage, webhookHandler.Redeliver))
+}
@@ -3,13 +3,22 @@ package rest
 import (
 	"net/http"
 
+	"github.com/serikdev/CashFlow/internal/metrics"
 	"github.com/serikdev/CashFlow/internal/port/rest/handler"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type Handlers struct {
-	AccountHandler     *handler.AccountHandler
-	TransactionHandler *handler.TransactionHandler
+	BaseHandler           *handler.BaseHandler
+	Authenticator         handler.Authenticator
+	AccountHandler        *handler.AccountHandler
+	TransactionHandler    *handler.TransactionHandler
+	WebhookHandler        *handler.WebhookHandler
+	ActivityHandler       *handler.ActivityHandler
+	HealthHandler         *handler.HealthHandler
+	AuditHandler          *handler.AuditHandler
+	LedgerHandler         *handler.LedgerHandler
+	ReconciliationHandler *handler.ReconciliationHandler
 }
 
 func NewRouter(handlers *Handlers) http.Handler {
@@ -21,8 +30,41 @@ func NewRouter(handlers *Handlers) http.Handler {
 	if handlers.TransactionHandler != nil {
 		handler.RegisterTransactionRouter(mux, handlers.TransactionHandler)
 	}
+	if handlers.WebhookHandler != nil {
+		handler.RegisterWebhookRouter(mux, handlers.WebhookHandler)
+	}
+	if handlers.ActivityHandler != nil {
+		handler.RegisterActivityRouter(mux, handlers.ActivityHandler)
+	}
+	if handlers.AuditHandler != nil {
+		handler.RegisterAuditRouter(mux, handlers.AuditHandler)
+	}
+	if handlers.LedgerHandler != nil {
+		handler.RegisterLedgerRouter(mux, handlers.LedgerHandler)
+	}
+	if handlers.ReconciliationHandler != nil {
+		handler.RegisterReconciliationRouter(mux, handlers.ReconciliationHandler)
+	}
+	if handlers.HealthHandler != nil {
+		handler.RegisterHealthRouter(mux, handlers.HealthHandler)
+	}
 	// http://localhost:8080/swagger/index.html
 	mux.Handle("/swagger/", httpSwagger.WrapHandler)
 
+	// Outermost first: request ID, tracing, access log, metrics, panic recovery, authentication.
+	base := handlers.BaseHandler
+	var h http.Handler = base.Authenticate(handlers.Authenticator, mux)
+	h = base.Recover(h)
+	h = base.Metrics(mux, h)
+	h = base.AccessLog(mux, h)
+	h = base.Trace(mux, h)
+	return base.RequestID(h)
+}
+
+// NewMetricsRouter serves GET /metrics on the internal metrics listener, so
+// that the API port does not expose them.
+func NewMetricsRouter() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
 	return mux
 }
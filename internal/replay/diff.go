@@ -0,0 +1,71 @@
+package replay
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+)
+
+// diffBalances compares the live balance of each account with the replayed one.
+func diffBalances(live, replayed []entity.ReplayAccount) []entity.BalanceDiff {
+	replayedBalance := make(map[accountKey]float64, len(replayed))
+	for _, a := range replayed {
+		replayedBalance[accountKey{a.TenantID, a.ID}] = a.Balance
+	}
+
+	diffs := []entity.BalanceDiff{}
+	for _, a := range live {
+		balance := replayedBalance[accountKey{a.TenantID, a.ID}]
+		if toMinor(a.Balance) == toMinor(balance) {
+			continue
+		}
+		diffs = append(diffs, entity.BalanceDiff{
+			TenantID:   a.TenantID,
+			AccountID:  a.ID,
+			Live:       a.Balance,
+			Replayed:   balance,
+			Difference: fromMinor(toMinor(a.Balance) - toMinor(balance)),
+		})
+	}
+	return diffs
+}
+
+// diffTransactions matches replayed and live transactions on their content,
+// since IDs differ. It returns the replayed ones without a live match and the
+// live ones that no replayed transaction matched.
+func diffTransactions(replayed, live []entity.ReplayTransaction) (missing, unexpected []entity.ReplayTransaction) {
+	pending := make(map[string][]entity.ReplayTransaction, len(live))
+	for _, t := range live {
+		key := transactionKey(t)
+		pending[key] = append(pending[key], t)
+	}
+
+	missing = []entity.ReplayTransaction{}
+	for _, t := range replayed {
+		key := transactionKey(t)
+		if matches := pending[key]; len(matches) > 0 {
+			pending[key] = matches[1:]
+			continue
+		}
+		missing = append(missing, t)
+	}
+
+	unexpected = []entity.ReplayTransaction{}
+	for _, matches := range pending {
+		unexpected = append(unexpected, matches...)
+	}
+	sort.Slice(unexpected, func(i, j int) bool { return unexpected[i].ID < unexpected[j].ID })
+	return missing, unexpected
+}
+
+func transactionKey(t entity.ReplayTransaction) string {
+	related := ""
+	if t.RelatedAccount != nil {
+		related = fmt.Sprint(*t.RelatedAccount)
+	}
+	return fmt.Sprintf("%s|%d|%s|%s|%d|%s|%s",
+		t.TenantID, t.AccountID, related, t.TransactionType, toMinor(t.Amount),
+		t.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano), t.RequestID)
+}
@@ -0,0 +1,130 @@
+package replay
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+)
+
+func TestDiffTransactions(t *testing.T) {
+	tx := func(id int64, amount float64, at time.Time) entity.ReplayTransaction {
+		return entity.ReplayTransaction{
+			ID:              id,
+			TenantID:        "acme",
+			AccountID:       1,
+			Amount:          amount,
+			TransactionType: entity.TransactionTypeDeposit,
+			CreatedAt:       at,
+			RequestID:       "req-1",
+		}
+	}
+	withRelated := func(t entity.ReplayTransaction, related int64) entity.ReplayTransaction {
+		t.TransactionType, t.RelatedAccount = entity.TransactionTypeTransfer, ptr(related)
+		return t
+	}
+
+	tests := []struct {
+		name       string
+		replayed   []entity.ReplayTransaction
+		live       []entity.ReplayTransaction
+		missing    []int64
+		unexpected []int64
+	}{
+		{
+			name:     "matched on content whatever the IDs",
+			replayed: []entity.ReplayTransaction{tx(1, 10, day), tx(2, 20, day)},
+			live:     []entity.ReplayTransaction{tx(52, 20, day), tx(51, 10, day)},
+		},
+		{
+			name:     "live time in microseconds",
+			replayed: []entity.ReplayTransaction{tx(1, 10, day.Add(1500*time.Nanosecond))},
+			live:     []entity.ReplayTransaction{tx(51, 10, day.Add(time.Microsecond).In(time.FixedZone("TMT", 5*60*60)))},
+		},
+		{
+			name:     "amounts compared in minor units",
+			replayed: []entity.ReplayTransaction{tx(1, 0.1+0.2, day)},
+			live:     []entity.ReplayTransaction{tx(51, 0.3, day)},
+		},
+		{
+			name:       "missing and unexpected",
+			replayed:   []entity.ReplayTransaction{tx(1, 10, day), tx(2, 20, day)},
+			live:       []entity.ReplayTransaction{tx(53, 30, day), tx(51, 10, day), tx(52, 20, day.Add(time.Second))},
+			missing:    []int64{2},
+			unexpected: []int64{52, 53},
+		},
+		{
+			name:     "duplicates matched one for one",
+			replayed: []entity.ReplayTransaction{tx(1, 10, day), tx(2, 10, day), tx(3, 10, day)},
+			live:     []entity.ReplayTransaction{tx(51, 10, day), tx(52, 10, day)},
+			missing:  []int64{3},
+		},
+		{
+			name:       "extra live duplicate",
+			replayed:   []entity.ReplayTransaction{tx(1, 10, day)},
+			live:       []entity.ReplayTransaction{tx(51, 10, day), tx(52, 10, day)},
+			unexpected: []int64{52},
+		},
+		{
+			name:       "related account",
+			replayed:   []entity.ReplayTransaction{withRelated(tx(1, 10, day), 2)},
+			live:       []entity.ReplayTransaction{withRelated(tx(51, 10, day), 3)},
+			missing:    []int64{1},
+			unexpected: []int64{51},
+		},
+		{
+			name: "request ID",
+			replayed: func() []entity.ReplayTransaction {
+				t := tx(1, 10, day)
+				t.RequestID = "req-2"
+				return []entity.ReplayTransaction{t}
+			}(),
+			live:       []entity.ReplayTransaction{tx(51, 10, day)},
+			missing:    []int64{1},
+			unexpected: []int64{51},
+		},
+	}
+
+	ids := func(transactions []entity.ReplayTransaction) []int64 {
+		var ids []int64
+		for _, t := range transactions {
+			ids = append(ids, t.ID)
+		}
+		return ids
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, unexpected := diffTransactions(tt.replayed, tt.live)
+			if missing == nil || unexpected == nil {
+				t.Errorf("diffTransactions returned nil slices, want empty ones for JSON")
+			}
+			if got := ids(missing); !slices.Equal(got, tt.missing) {
+				t.Errorf("missing = %v, want %v", got, tt.missing)
+			}
+			if got := ids(unexpected); !slices.Equal(got, tt.unexpected) {
+				t.Errorf("unexpected = %v, want %v", got, tt.unexpected)
+			}
+		})
+	}
+}
+
+func TestDiffBalances(t *testing.T) {
+	live := []entity.ReplayAccount{
+		{TenantID: "acme", ID: 1, Balance: 100.3},
+		{TenantID: "acme", ID: 2, Balance: 50},
+		{TenantID: "other", ID: 1, Balance: 10},
+	}
+	replayed := []entity.ReplayAccount{
+		{TenantID: "acme", ID: 1, Balance: 100.1 + 0.2},
+		{TenantID: "acme", ID: 2, Balance: 70.25},
+	}
+
+	want := []entity.BalanceDiff{
+		{TenantID: "acme", AccountID: 2, Live: 50, Replayed: 70.25, Difference: -20.25},
+		{TenantID: "other", AccountID: 1, Live: 10, Replayed: 0, Difference: 10},
+	}
+	if got := diffBalances(live, replayed); !slices.Equal(got, want) {
+		t.Errorf("diffBalances = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+// Package replay rebuilds balances and transactions from the transaction
+// topics into a separate schema and compares the result with the live tables.
+package replay
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/kafka"
+)
+
+type accountKey struct {
+	tenantID string
+	id       int64
+}
+
+// account tracks a balance in minor units, so that applying events in the
+// same order always yields the same result.
+type account struct {
+	entity.ReplayAccount
+	balance int64
+}
+
+// Projection applies events with the rules of the consumer: each event is
+// applied at most once, the accounts must exist and not be deleted at the
+// event time, and debits need enough funds. Locks are not events and are not
+// taken into account.
+type Projection struct {
+	accounts     map[accountKey]*account
+	order        []accountKey
+	transactions []entity.ReplayTransaction
+	rejected     []entity.ReplayRejection
+	// seen holds the IDs of the events applied or rejected so far, as the
+	// consumer keeps a result per event ID.
+	seen map[string]bool
+}
+
+// NewProjection starts from the baseline balance of each account.
+func NewProjection(accounts []entity.ReplayAccount) *Projection {
+	p := &Projection{
+		accounts: make(map[accountKey]*account, len(accounts)),
+		seen:     make(map[string]bool),
+	}
+	for _, a := range accounts {
+		key := accountKey{a.TenantID, a.ID}
+		p.accounts[key] = &account{ReplayAccount: a, balance: toMinor(a.Baseline)}
+		p.order = append(p.order, key)
+	}
+	return p
+}
+
+// SortMessages orders messages by event time, then by topic, partition and
+// offset. Topics are consumed concurrently, so this is the canonical order
+// rather than the exact live one.
+func SortMessages(messages []kafka.ReplayMessage) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := messages[i], messages[j]
+		if !a.OccurredAt.Equal(b.OccurredAt) {
+			return a.OccurredAt.Before(b.OccurredAt)
+		}
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		if a.Partition != b.Partition {
+			return a.Partition < b.Partition
+		}
+		return a.Offset < b.Offset
+	})
+}
+
+// Apply applies one event or records why it was rejected.
+func (p *Projection) Apply(m kafka.ReplayMessage) {
+	if m.Err != nil {
+		p.reject(m, m.Err.Error())
+		return
+	}
+	// A record published twice, by a producer retry or a republish, carries
+	// the same event ID.
+	if p.seen[m.EventID] {
+		p.reject(m, fmt.Sprintf("duplicate of event %s", m.EventID))
+		return
+	}
+	p.seen[m.EventID] = true
+
+	e := m.Event
+	// Postgres keeps microseconds.
+	at := m.OccurredAt.UTC().Truncate(time.Microsecond)
+	amount := toMinor(e.Amount)
+	if amount <= 0 {
+		p.reject(m, "amount must be positive")
+		return
+	}
+
+	var related *int64
+	switch e.TransactionType {
+	case entity.TransactionTypeDeposit:
+		acc, reason := p.account(e.TenantID, e.AccountID, at)
+		if acc == nil {
+			p.reject(m, reason)
+			return
+		}
+		acc.balance += amount
+	case entity.TransactionTypeWithdrawal:
+		acc, reason := p.account(e.TenantID, e.AccountID, at)
+		if acc == nil {
+			p.reject(m, reason)
+			return
+		}
+		if acc.balance < amount {
+			p.reject(m, fmt.Sprintf("account %d has insufficient funds", e.AccountID))
+			return
+		}
+		acc.balance -= amount
+	case entity.TransactionTypeTransfer:
+		if e.RelatedAccount == nil {
+			p.reject(m, "related account is nil for transfer")
+			return
+		}
+		from, reason := p.account(e.TenantID, e.AccountID, at)
+		if from == nil {
+			p.reject(m, reason)
+			return
+		}
+		if from.balance < amount {
+			p.reject(m, fmt.Sprintf("account %d has insufficient funds", e.AccountID))
+			return
+		}
+		to, reason := p.account(e.TenantID, *e.RelatedAccount, at)
+		if to == nil {
+			p.reject(m, "target "+reason)
+			return
+		}
+		from.balance -= amount
+		to.balance += amount
+		related = e.RelatedAccount
+	default:
+		p.reject(m, fmt.Sprintf("unknown transaction type: %s", e.TransactionType))
+		return
+	}
+
+	p.transactions = append(p.transactions, entity.ReplayTransaction{
+		ID:              int64(len(p.transactions) + 1),
+		TenantID:        e.TenantID,
+		AccountID:       e.AccountID,
+		RelatedAccount:  related,
+		Amount:          fromMinor(amount),
+		TransactionType: e.TransactionType,
+		CreatedAt:       at,
+		RequestID:       m.Correlation.RequestID,
+		InitiatedBy:     m.Correlation.CallerSubject,
+		Topic:           m.Topic,
+		Partition:       m.Partition,
+		Offset:          m.Offset,
+	})
+}
+
+func (p *Projection) account(tenantID string, id int64, at time.Time) (*account, string) {
+	acc, ok := p.accounts[accountKey{tenantID, id}]
+	if !ok || at.Before(acc.CreatedAt) {
+		return nil, fmt.Sprintf("account %d not found", id)
+	}
+	if acc.DeletedAt != nil && !at.Before(*acc.DeletedAt) {
+		return nil, fmt.Sprintf("account %d is deleted", id)
+	}
+	return acc, ""
+}
+
+func (p *Projection) reject(m kafka.ReplayMessage, reason string) {
+	p.rejected = append(p.rejected, entity.ReplayRejection{
+		Topic:           m.Topic,
+		Partition:       m.Partition,
+		Offset:          m.Offset,
+		TenantID:        m.Event.TenantID,
+		AccountID:       m.Event.AccountID,
+		TransactionType: m.Event.TransactionType,
+		Amount:          m.Event.Amount,
+		Reason:          reason,
+	})
+}
+
+// Accounts returns the accounts in baseline order with their replayed balance.
+func (p *Projection) Accounts() []entity.ReplayAccount {
+	accounts := make([]entity.ReplayAccount, 0, len(p.order))
+	for _, key := range p.order {
+		acc := p.accounts[key]
+		a := acc.ReplayAccount
+		a.Balance = fromMinor(acc.balance)
+		accounts = append(accounts, a)
+	}
+	return accounts
+}
+
+func (p *Projection) Transactions() []entity.ReplayTransaction {
+	return p.transactions
+}
+
+func (p *Projection) Rejected() []entity.ReplayRejection {
+	return p.rejected
+}
+
+func toMinor(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinor(amount int64) float64 {
+	return float64(amount) / 100
+}
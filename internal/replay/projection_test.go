@@ -0,0 +1,289 @@
+package replay
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/kafka"
+)
+
+var day = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// baseline has accounts 1 and 2 of acme with 100 each, created a day before
+// the events, account 3 created an hour after the first event and account 4
+// deleted an hour after it.
+func baseline() []entity.ReplayAccount {
+	return []entity.ReplayAccount{
+		{TenantID: "acme", ID: 1, Baseline: 100, CreatedAt: day.Add(-24 * time.Hour)},
+		{TenantID: "acme", ID: 2, Baseline: 100, CreatedAt: day.Add(-24 * time.Hour)},
+		{TenantID: "acme", ID: 3, CreatedAt: day.Add(time.Hour)},
+		{TenantID: "acme", ID: 4, Baseline: 100, CreatedAt: day.Add(-24 * time.Hour), DeletedAt: ptr(day.Add(time.Hour))},
+	}
+}
+
+// message is a message at offset of an event at day, of acme unless the
+// event has a tenant.
+func message(offset int64, eventID string, e entity.TransactionEvent) kafka.ReplayMessage {
+	if e.TenantID == "" {
+		e.TenantID = "acme"
+	}
+	return kafka.ReplayMessage{
+		Topic:      "account-" + e.TransactionType,
+		Offset:     offset,
+		EventID:    eventID,
+		OccurredAt: day,
+		Event:      e,
+	}
+}
+
+func deposit(offset int64, eventID string, accountID int64, amount float64) kafka.ReplayMessage {
+	return message(offset, eventID, entity.TransactionEvent{AccountID: accountID, Amount: amount, TransactionType: entity.TransactionTypeDeposit})
+}
+
+func withdrawal(offset int64, eventID string, accountID int64, amount float64) kafka.ReplayMessage {
+	return message(offset, eventID, entity.TransactionEvent{AccountID: accountID, Amount: amount, TransactionType: entity.TransactionTypeWithdrawal})
+}
+
+func transfer(offset int64, eventID string, from int64, to *int64, amount float64) kafka.ReplayMessage {
+	return message(offset, eventID, entity.TransactionEvent{AccountID: from, RelatedAccount: to, Amount: amount, TransactionType: entity.TransactionTypeTransfer})
+}
+
+func TestProjectionApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []kafka.ReplayMessage
+		// balances are the replayed balances of accounts 1 to 4.
+		balances []float64
+		applied  int
+		rejected []string
+	}{
+		{
+			name:     "deposit",
+			messages: []kafka.ReplayMessage{deposit(1, "e1", 1, 25.5)},
+			balances: []float64{125.5, 100, 0, 100},
+			applied:  1,
+		},
+		{
+			name:     "withdrawal",
+			messages: []kafka.ReplayMessage{withdrawal(1, "e1", 1, 100)},
+			balances: []float64{0, 100, 0, 100},
+			applied:  1,
+		},
+		{
+			name:     "withdrawal with insufficient funds",
+			messages: []kafka.ReplayMessage{withdrawal(1, "e1", 1, 100.01)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"account 1 has insufficient funds"},
+		},
+		{
+			name:     "transfer",
+			messages: []kafka.ReplayMessage{transfer(1, "e1", 1, ptr[int64](2), 40)},
+			balances: []float64{60, 140, 0, 100},
+			applied:  1,
+		},
+		{
+			name:     "transfer with insufficient funds",
+			messages: []kafka.ReplayMessage{transfer(1, "e1", 1, ptr[int64](2), 200)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"account 1 has insufficient funds"},
+		},
+		{
+			name:     "transfer without a related account",
+			messages: []kafka.ReplayMessage{transfer(1, "e1", 1, nil, 40)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"related account is nil for transfer"},
+		},
+		{
+			name:     "transfer to an unknown account",
+			messages: []kafka.ReplayMessage{transfer(1, "e1", 1, ptr[int64](9), 40)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"target account 9 not found"},
+		},
+		{
+			name:     "account created after the event",
+			messages: []kafka.ReplayMessage{deposit(1, "e1", 3, 10)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"account 3 not found"},
+		},
+		{
+			name:     "account of another tenant",
+			messages: []kafka.ReplayMessage{message(1, "e1", entity.TransactionEvent{TenantID: "other", AccountID: 1, Amount: 10, TransactionType: entity.TransactionTypeDeposit})},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"account 1 not found"},
+		},
+		{
+			name: "account deleted before the event",
+			messages: func() []kafka.ReplayMessage {
+				m := deposit(1, "e1", 4, 10)
+				m.OccurredAt = day.Add(2 * time.Hour)
+				return []kafka.ReplayMessage{m}
+			}(),
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"account 4 is deleted"},
+		},
+		{
+			name:     "account deleted after the event",
+			messages: []kafka.ReplayMessage{withdrawal(1, "e1", 4, 30)},
+			balances: []float64{100, 100, 0, 70},
+			applied:  1,
+		},
+		{
+			name:     "zero amount",
+			messages: []kafka.ReplayMessage{deposit(1, "e1", 1, 0)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"amount must be positive"},
+		},
+		{
+			name:     "amount below a minor unit",
+			messages: []kafka.ReplayMessage{deposit(1, "e1", 1, 0.004)},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"amount must be positive"},
+		},
+		{
+			name:     "amounts in minor units",
+			messages: []kafka.ReplayMessage{deposit(1, "e1", 1, 0.1), deposit(2, "e2", 1, 0.2)},
+			balances: []float64{100.3, 100, 0, 100},
+			applied:  2,
+		},
+		{
+			name:     "unknown type",
+			messages: []kafka.ReplayMessage{message(1, "e1", entity.TransactionEvent{AccountID: 1, Amount: 10, TransactionType: entity.TransactionTypeCorrection})},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"unknown transaction type: correction"},
+		},
+		{
+			name:     "undecodable message",
+			messages: []kafka.ReplayMessage{{Topic: "account-deposit", Offset: 1, Err: errors.New("invalid payload")}},
+			balances: []float64{100, 100, 0, 100},
+			rejected: []string{"invalid payload"},
+		},
+		{
+			name:     "duplicated event",
+			messages: []kafka.ReplayMessage{deposit(1, "e1", 1, 10), deposit(2, "e1", 1, 10), deposit(3, "e2", 1, 10)},
+			balances: []float64{120, 100, 0, 100},
+			applied:  2,
+			rejected: []string{"duplicate of event e1"},
+		},
+		{
+			name:     "duplicate of a rejected event",
+			messages: []kafka.ReplayMessage{withdrawal(1, "e1", 1, 150), deposit(2, "e2", 1, 100), withdrawal(3, "e1", 1, 150)},
+			balances: []float64{200, 100, 0, 100},
+			applied:  1,
+			rejected: []string{"account 1 has insufficient funds", "duplicate of event e1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProjection(baseline())
+			for _, m := range tt.messages {
+				p.Apply(m)
+			}
+
+			var balances []float64
+			for _, a := range p.Accounts() {
+				balances = append(balances, a.Balance)
+			}
+			if !slices.Equal(balances, tt.balances) {
+				t.Errorf("balances = %v, want %v", balances, tt.balances)
+			}
+			if n := len(p.Transactions()); n != tt.applied {
+				t.Errorf("%d transactions applied, want %d", n, tt.applied)
+			}
+			var reasons []string
+			for _, r := range p.Rejected() {
+				reasons = append(reasons, r.Reason)
+			}
+			if !slices.Equal(reasons, tt.rejected) {
+				t.Errorf("rejections = %q, want %q", reasons, tt.rejected)
+			}
+		})
+	}
+}
+
+// TestProjectionTransactions checks the transaction recorded for an applied
+// event and the rejection recorded for a duplicate of it.
+func TestProjectionTransactions(t *testing.T) {
+	p := NewProjection(baseline())
+	m := transfer(7, "e1", 1, ptr[int64](2), 12.34)
+	m.Partition = 2
+	m.OccurredAt = day.Add(1500 * time.Nanosecond)
+	m.Correlation.RequestID = "req-1"
+	m.Correlation.CallerSubject = "alice"
+	p.Apply(m)
+	m.Offset = 8
+	p.Apply(m)
+
+	want := entity.ReplayTransaction{
+		ID:              1,
+		TenantID:        "acme",
+		AccountID:       1,
+		RelatedAccount:  ptr[int64](2),
+		Amount:          12.34,
+		TransactionType: entity.TransactionTypeTransfer,
+		CreatedAt:       day.Add(time.Microsecond),
+		RequestID:       "req-1",
+		InitiatedBy:     "alice",
+		Topic:           "account-transfer",
+		Partition:       2,
+		Offset:          7,
+	}
+	got := p.Transactions()
+	if len(got) != 1 {
+		t.Fatalf("%d transactions applied, want 1", len(got))
+	}
+	if *got[0].RelatedAccount != *want.RelatedAccount {
+		t.Errorf("related account = %d, want %d", *got[0].RelatedAccount, *want.RelatedAccount)
+	}
+	got[0].RelatedAccount = want.RelatedAccount
+	if got[0] != want {
+		t.Errorf("transaction = %+v, want %+v", got[0], want)
+	}
+
+	wantRejection := entity.ReplayRejection{
+		Topic:           "account-transfer",
+		Partition:       2,
+		Offset:          8,
+		TenantID:        "acme",
+		AccountID:       1,
+		TransactionType: entity.TransactionTypeTransfer,
+		Amount:          12.34,
+		Reason:          "duplicate of event e1",
+	}
+	if rejected := p.Rejected(); len(rejected) != 1 || rejected[0] != wantRejection {
+		t.Errorf("rejections = %+v, want [%+v]", rejected, wantRejection)
+	}
+}
+
+func TestSortMessages(t *testing.T) {
+	at := func(d time.Duration, topic string, partition int, offset int64) kafka.ReplayMessage {
+		return kafka.ReplayMessage{Topic: topic, Partition: partition, Offset: offset, OccurredAt: day.Add(d)}
+	}
+	want := []kafka.ReplayMessage{
+		at(-time.Second, "account-withdrawal", 1, 9),
+		at(0, "account-deposit", 0, 5),
+		at(0, "account-deposit", 1, 2),
+		at(0, "account-deposit", 1, 3),
+		at(0, "account-transfer", 0, 0),
+		at(time.Nanosecond, "account-deposit", 0, 1),
+	}
+	messages := []kafka.ReplayMessage{want[5], want[3], want[4], want[1], want[0], want[2]}
+	// The same instant in another location sorts as equal.
+	messages[2].OccurredAt = messages[2].OccurredAt.In(time.FixedZone("TMT", 5*60*60))
+
+	SortMessages(messages)
+	for i := range want {
+		m := messages[i]
+		if m.Topic != want[i].Topic || m.Partition != want[i].Partition || m.Offset != want[i].Offset || !m.OccurredAt.Equal(want[i].OccurredAt) {
+			t.Errorf("message %d = %s/%d/%d at %s, want %s/%d/%d at %s", i,
+				m.Topic, m.Partition, m.Offset, m.OccurredAt, want[i].Topic, want[i].Partition, want[i].Offset, want[i].OccurredAt)
+		}
+	}
+}
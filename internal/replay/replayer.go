@@ -0,0 +1,192 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/kafka"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+var schemaPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+type Store interface {
+	Baseline(ctx context.Context, cutoff *time.Time) ([]entity.ReplayAccount, error)
+	LiveTransactions(ctx context.Context, from *time.Time, to time.Time) ([]entity.ReplayTransaction, error)
+	WriteProjection(ctx context.Context, schema string, accounts []entity.ReplayAccount, txns []entity.ReplayTransaction, rejected []entity.ReplayRejection) error
+}
+
+// ParseStart parses "earliest", a partition offset or an RFC 3339 time.
+func ParseStart(s string) (kafka.StartPosition, error) {
+	if s == "" || s == "earliest" {
+		return kafka.StartPosition{}, nil
+	}
+	if offset, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if offset < 0 {
+			return kafka.StartPosition{}, fmt.Errorf("offset must not be negative: %d", offset)
+		}
+		return kafka.StartPosition{Offset: offset}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return kafka.StartPosition{}, fmt.Errorf("start must be earliest, an offset or an RFC 3339 time: %q", s)
+	}
+	return kafka.StartPosition{Time: t}, nil
+}
+
+// ValidateSchema rejects names that are not plain lower case identifiers,
+// and the schema of the live tables.
+func ValidateSchema(schema string) error {
+	if !schemaPattern.MatchString(schema) || schema == "public" || strings.HasPrefix(schema, "pg_") {
+		return fmt.Errorf("invalid replay schema name %q", schema)
+	}
+	return nil
+}
+
+// Replayer projects the events read from Kafka into a schema and compares
+// the result with the live tables of every tenant.
+type Replayer struct {
+	store   Store
+	tenants []string
+	logger  *logrus.Entry
+}
+
+// NewReplayer creates a Replayer. tenants are compared even when no event of
+// theirs was read; tenants found in events are added.
+func NewReplayer(store Store, tenants []string, logger *logrus.Entry) *Replayer {
+	return &Replayer{
+		store:   store,
+		tenants: tenants,
+		logger:  logger.WithField("component", "replay"),
+	}
+}
+
+// Run replays rng into schema. The replay starts from the balances before
+// the cutoff: the opening balances when rng is read from the beginning,
+// otherwise the balances implied by the transactions logged before the
+// start time, or before the first event when starting at an offset.
+func (r *Replayer) Run(ctx context.Context, schema, startLabel string, start kafka.StartPosition, rng *kafka.ReplayRange) (*entity.ReplayReport, error) {
+	report := &entity.ReplayReport{
+		Schema:    schema,
+		Start:     startLabel,
+		Messages:  len(rng.Messages),
+		StartedAt: time.Now().UTC(),
+	}
+
+	messages := rng.Messages
+	SortMessages(messages)
+
+	cutoff, end := r.window(start, rng)
+	report.Cutoff = cutoff
+
+	tenants := r.tenantsOf(messages)
+	var live []entity.ReplayAccount
+	for _, tenantID := range tenants {
+		accounts, err := r.store.Baseline(tenant.WithID(ctx, tenantID), cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("error loading baseline of tenant %s: %w", tenantID, err)
+		}
+		live = append(live, accounts...)
+	}
+
+	projection := NewProjection(live)
+	for _, m := range messages {
+		projection.Apply(m)
+	}
+	report.Applied = len(projection.Transactions())
+	report.Rejected = len(projection.Rejected())
+
+	if err := r.store.WriteProjection(ctx, schema, projection.Accounts(), projection.Transactions(), projection.Rejected()); err != nil {
+		return nil, err
+	}
+
+	var liveTxns []entity.ReplayTransaction
+	for _, tenantID := range tenants {
+		txns, err := r.store.LiveTransactions(tenant.WithID(ctx, tenantID), cutoff, end)
+		if err != nil {
+			return nil, fmt.Errorf("error loading live transactions of tenant %s: %w", tenantID, err)
+		}
+		liveTxns = append(liveTxns, txns...)
+	}
+
+	report.AccountsCompared = len(live)
+	report.BalanceDiffs = diffBalances(live, projection.Accounts())
+	report.MissingInLive, report.UnexpectedInLive = diffTransactions(projection.Transactions(), liveTxns)
+	report.Consistent = len(report.BalanceDiffs) == 0 && len(report.MissingInLive) == 0 && len(report.UnexpectedInLive) == 0
+	report.FinishedAt = time.Now().UTC()
+
+	log := r.logger.WithFields(logrus.Fields{
+		"schema":             schema,
+		"messages":           report.Messages,
+		"applied":            report.Applied,
+		"rejected":           report.Rejected,
+		"balance_diffs":      len(report.BalanceDiffs),
+		"missing_in_live":    len(report.MissingInLive),
+		"unexpected_in_live": len(report.UnexpectedInLive),
+	})
+	if report.Consistent {
+		log.Info("Replay matches the live tables")
+	} else {
+		log.Warn("Replay differs from the live tables")
+	}
+	return report, nil
+}
+
+// window returns the cutoff before which transactions form the baseline, nil
+// when replaying from the beginning, and the time of the last event, after
+// which live transactions are not compared.
+func (r *Replayer) window(start kafka.StartPosition, rng *kafka.ReplayRange) (*time.Time, time.Time) {
+	var first, last time.Time
+	for _, m := range rng.Messages {
+		if m.Err != nil {
+			continue
+		}
+		at := m.OccurredAt.UTC().Truncate(time.Microsecond)
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+		if at.After(last) {
+			last = at
+		}
+	}
+	if last.IsZero() {
+		last = time.Now().UTC()
+	}
+
+	switch {
+	case rng.FromBeginning:
+		return nil, last
+	case !start.Time.IsZero():
+		cutoff := start.Time.UTC()
+		return &cutoff, last
+	case !first.IsZero():
+		return &first, last
+	default:
+		return &last, last
+	}
+}
+
+func (r *Replayer) tenantsOf(messages []kafka.ReplayMessage) []string {
+	seen := map[string]bool{}
+	for _, t := range r.tenants {
+		seen[t] = true
+	}
+	for _, m := range messages {
+		if m.Err == nil {
+			seen[m.Event.TenantID] = true
+		}
+	}
+	tenants := make([]string, 0, len(seen))
+	for t := range seen {
+		tenants = append(tenants, t)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
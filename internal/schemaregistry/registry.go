@@ -0,0 +1,194 @@
+// Package schemaregistry keeps the schemas of the event encodings in a JSON
+// file. Like the Confluent Schema Registry it assigns every distinct schema of
+// a subject a version and a global ID, which the binary encodings write in
+// front of each message, so a reader can always find the writer's schema.
+package schemaregistry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Schema types, named as in the Confluent Schema Registry.
+const (
+	TypeAvro     = "AVRO"
+	TypeProtobuf = "PROTOBUF"
+	TypeJSON     = "JSON"
+)
+
+var ErrNotFound = errors.New("schema not found")
+
+type Schema struct {
+	ID         int    `json:"id"`
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+// Registry is the file-backed registry. Several processes may share the
+// file: Register reads, updates and writes it under an exclusive lock on
+// the file next to it with the suffix .lock, so concurrent registrations
+// neither get lost nor share an ID, and a lookup of an unknown ID reloads
+// it before giving up.
+type Registry struct {
+	path string
+
+	mu      sync.RWMutex
+	schemas []Schema
+}
+
+// Open loads the registry stored at path. A missing file is an empty
+// registry; it is created by the first Register.
+func Open(path string) (*Registry, error) {
+	r := &Registry{path: path}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Register returns the schema of subject with the given text, adding it as
+// the next version of the subject if it is not registered yet.
+func (r *Registry) Register(subject, schemaType, schema string) (Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := lockFile(r.path + ".lock")
+	if err != nil {
+		return Schema{}, err
+	}
+	defer unlock()
+	if err := r.loadLocked(); err != nil {
+		return Schema{}, err
+	}
+
+	version, id := 0, 0
+	for _, s := range r.schemas {
+		if s.Subject == subject {
+			if s.SchemaType == schemaType && s.Schema == schema {
+				return s, nil
+			}
+			version = max(version, s.Version)
+		}
+		id = max(id, s.ID)
+	}
+
+	s := Schema{
+		ID:         id + 1,
+		Subject:    subject,
+		Version:    version + 1,
+		SchemaType: schemaType,
+		Schema:     schema,
+	}
+	r.schemas = append(r.schemas, s)
+	if err := r.save(); err != nil {
+		r.schemas = r.schemas[:len(r.schemas)-1]
+		return Schema{}, err
+	}
+	return s, nil
+}
+
+// ByID returns the schema with the global ID id.
+func (r *Registry) ByID(id int) (Schema, error) {
+	if s, ok := r.find(id); ok {
+		return s, nil
+	}
+	if err := r.load(); err != nil {
+		return Schema{}, err
+	}
+	if s, ok := r.find(id); ok {
+		return s, nil
+	}
+	return Schema{}, fmt.Errorf("%w: id %d", ErrNotFound, id)
+}
+
+// Latest returns the highest version registered under subject.
+func (r *Registry) Latest(subject string) (Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest Schema
+	for _, s := range r.schemas {
+		if s.Subject == subject && s.Version > latest.Version {
+			latest = s
+		}
+	}
+	if latest.ID == 0 {
+		return Schema{}, fmt.Errorf("%w: subject %s", ErrNotFound, subject)
+	}
+	return latest, nil
+}
+
+func (r *Registry) find(id int) (Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.schemas {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Schema{}, false
+}
+
+func (r *Registry) load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loadLocked()
+}
+
+func (r *Registry) loadLocked() error {
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema registry: %w", err)
+	}
+
+	var file struct {
+		Schemas []Schema `json:"schemas"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse schema registry %s: %w", r.path, err)
+	}
+	r.schemas = file.Schemas
+	return nil
+}
+
+// save replaces the file through a rename so that readers never see it half written.
+func (r *Registry) save() error {
+	data, err := json.MarshalIndent(struct {
+		Schemas []Schema `json:"schemas"`
+	}{r.schemas}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	return nil
+}
+
+// Subject names the subject of the values of topic, following the
+// TopicNameStrategy of the Confluent serializers.
+func Subject(topic string) string {
+	return topic + "-value"
+}
@@ -0,0 +1,99 @@
+package schemaregistry
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestRegisterAssignsVersionsAndIDs(t *testing.T) {
+	r, err := Open(filepath.Join(t.TempDir(), "registry.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	first, err := r.Register("deposit-value", TypeAvro, `"string"`)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	again, err := r.Register("deposit-value", TypeAvro, `"string"`)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if again != first {
+		t.Fatalf("registering the same schema again gave %+v, want %+v", again, first)
+	}
+	second, err := r.Register("deposit-value", TypeAvro, `"long"`)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	other, err := r.Register("transfer-value", TypeProtobuf, `syntax = "proto3";`)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if first.ID != 1 || first.Version != 1 || second.ID != 2 || second.Version != 2 || other.ID != 3 || other.Version != 1 {
+		t.Fatalf("got %+v, %+v, %+v; want IDs 1, 2, 3 and versions 1, 2, 1", first, second, other)
+	}
+	latest, err := r.Latest("deposit-value")
+	if err != nil || latest != second {
+		t.Fatalf("Latest = %+v, %v; want %+v", latest, err, second)
+	}
+	if _, err := r.ByID(4); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ByID(4) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRegistriesSharingAFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	registries := make([]*Registry, 4)
+	for i := range registries {
+		r, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		registries[i] = r
+	}
+
+	const perRegistry = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, len(registries)*perRegistry)
+	for i, r := range registries {
+		wg.Add(1)
+		go func(i int, r *Registry) {
+			defer wg.Done()
+			for j := 0; j < perRegistry; j++ {
+				if _, err := r.Register(fmt.Sprintf("topic-%d-%d-value", i, j), TypeAvro, `"string"`); err != nil {
+					errs <- err
+				}
+			}
+		}(i, r)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Register: %v", err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	want := len(registries) * perRegistry
+	if len(r.schemas) != want {
+		t.Fatalf("file holds %d schemas, want %d", len(r.schemas), want)
+	}
+	seen := make(map[int]bool)
+	for _, s := range r.schemas {
+		if seen[s.ID] {
+			t.Fatalf("ID %d assigned twice", s.ID)
+		}
+		seen[s.ID] = true
+	}
+	// A registry finds the schemas another one added.
+	if _, err := registries[0].ByID(want); err != nil {
+		t.Fatalf("ByID(%d): %v", want, err)
+	}
+}
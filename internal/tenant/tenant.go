@@ -0,0 +1,32 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// Default is the tenant assigned to callers and events that do not name one.
+const Default = "default"
+
+var ErrMissing = errors.New("tenant is not resolved")
+
+type tenantKey struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Require returns the tenant of the context or ErrMissing, so that no query
+// runs without tenant scope.
+func Require(ctx context.Context) (string, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return "", ErrMissing
+	}
+	return id, nil
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrAccountAccessDenied = fmt.Errorf("%w: account access denied", auth.ErrForbidden)
+
+type AccessRepo interface {
+	GetRelation(ctx context.Context, accountID int64, subject string) (entity.AccountRelation, error)
+	// Grant leaves an existing owner relation unchanged unless replaceOwner
+	// is set.
+	Grant(ctx context.Context, accountID int64, subject string, relation entity.AccountRelation, replaceOwner bool) (*entity.AccountAccess, error)
+}
+
+// AccessPolicy enforces account-level access for the caller stored in the context.
+type AccessPolicy struct {
+	repo   AccessRepo
+	logger *logrus.Entry
+}
+
+func NewAccessPolicy(repo AccessRepo, logger *logrus.Entry) *AccessPolicy {
+	return &AccessPolicy{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// CheckAccount returns ErrAccountAccessDenied unless the caller holds at least
+// the required relation to the account or its role works across accounts.
+func (p *AccessPolicy) CheckAccount(ctx context.Context, accountID int64, required entity.AccountRelation) error {
+	ctx, span := tracing.Tracer().Start(ctx, "AccessPolicy.CheckAccount")
+	defer span.End()
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return auth.ErrUnauthenticated
+	}
+	if principal.Role.SeesAllAccounts(required != entity.RelationViewer) {
+		return nil
+	}
+
+	relation, err := p.repo.GetRelation(ctx, accountID, principal.Subject)
+	if err != nil {
+		return fmt.Errorf("error checking account access: %w", err)
+	}
+	if !relation.Includes(required) {
+		p.logger.WithFields(logrus.Fields{
+			"account_id": accountID,
+			"subject":    principal.Subject,
+			"relation":   relation,
+			"required":   required,
+		}).Warn("Account access denied")
+		return fmt.Errorf("%w: %s relation to account %d required", ErrAccountAccessDenied, required, accountID)
+	}
+	return nil
+}
+
+// CheckShare returns the not-found error of a missing account unless the
+// caller may share the account, so that the response does not reveal
+// whether an account the caller cannot share exists.
+func (p *AccessPolicy) CheckShare(ctx context.Context, accountID int64) error {
+	err := p.CheckAccount(ctx, accountID, entity.RelationOwner)
+	if errors.Is(err, ErrAccountAccessDenied) {
+		return errs.NotFound("account with %d not found", accountID)
+	}
+	return err
+}
+
+// Grant gives subject a relation to an account the caller may share (see
+// CheckShare). Only roles with PermAccountGrantOwner, that is admins, may
+// grant the owner relation or change the relation of an owner, so that an
+// owner cannot demote another one.
+func (p *AccessPolicy) Grant(ctx context.Context, accountID int64, subject string, relation entity.AccountRelation) (*entity.AccountAccess, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccessPolicy.Grant")
+	defer span.End()
+
+	if subject == "" {
+		return nil, errs.Validation("subject is required")
+	}
+	if !relation.Valid() {
+		return nil, errs.Validation("invalid relation %q", relation)
+	}
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+	if err := p.CheckShare(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	grantsOwner := principal.Role.Can(auth.PermAccountGrantOwner)
+	if relation == entity.RelationOwner && !grantsOwner {
+		p.logger.WithFields(logrus.Fields{
+			"account_id": accountID,
+			"subject":    principal.Subject,
+			"role":       principal.Role,
+		}).Warn("Owner grant denied")
+		return nil, fmt.Errorf("%w: only admins may grant the %s relation", ErrAccountAccessDenied, entity.RelationOwner)
+	}
+	if !grantsOwner {
+		current, err := p.repo.GetRelation(ctx, accountID, subject)
+		if err != nil {
+			return nil, fmt.Errorf("error checking account access: %w", err)
+		}
+		if current == entity.RelationOwner {
+			p.logger.WithFields(logrus.Fields{
+				"account_id": accountID,
+				"subject":    principal.Subject,
+				"target":     subject,
+				"role":       principal.Role,
+			}).Warn("Owner change denied")
+			return nil, fmt.Errorf("%w: only admins may change the relation of an %s", ErrAccountAccessDenied, entity.RelationOwner)
+		}
+	}
+	return p.repo.Grant(ctx, accountID, subject, relation, grantsOwner)
+}
+
+// grantOwner records the caller as owner of a freshly created account.
+func (p *AccessPolicy) grantOwner(ctx context.Context, accountID int64) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	_, err := p.repo.Grant(ctx, accountID, principal.Subject, entity.RelationOwner, true)
+	return err
+}
@@ -2,11 +2,14 @@ package usecase
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"time"
 
+	"github.com/serikdev/CashFlow/internal/auth"
 	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
 	"github.com/sirupsen/logrus"
 )
 
@@ -15,42 +18,75 @@ type AccountRepo interface {
 	GetByID(ctx context.Context, id int64) (*entity.Account, error)
 	Delete(ctx context.Context, id int64) error
 	List(ctx context.Context, offset, limit int) ([]entity.Account, int, error)
+	ListBySubject(ctx context.Context, subject string, offset, limit int) ([]entity.Account, int, error)
+	SetLocked(ctx context.Context, id int64, locked bool) (*entity.Account, error)
 }
 
 type AccountService struct {
-	repo   AccountRepo
-	logger *logrus.Entry
+	repo     AccountRepo
+	access   *AccessPolicy
+	notifier EventNotifier
+	audit    Auditor
+	logger   *logrus.Entry
 }
 
-func NewAccountService(repo AccountRepo, logger *logrus.Entry) *AccountService {
+func NewAccountService(repo AccountRepo, access *AccessPolicy, notifier EventNotifier, audit Auditor, logger *logrus.Entry) *AccountService {
 	return &AccountService{
-		repo:   repo,
-		logger: logger,
+		repo:     repo,
+		access:   access,
+		notifier: notifier,
+		audit:    audit,
+		logger:   logger,
 	}
 }
 
 func (s *AccountService) Create(ctx context.Context, account *entity.Account) (*entity.Account, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.Create")
+	defer span.End()
+
+	if account.Balance < 0 {
+		return nil, errs.Validation("initial balance must not be negative")
+	}
+	if len(account.Currency) != 3 {
+		return nil, errs.Validation("currency must be a 3-letter ISO 4217 code")
+	}
+
 	now := time.Now()
 	account.CreatedAt = now
 
 	createAccount, err := s.repo.Create(ctx, account)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to create account")
+		s.log(ctx).WithError(err).Error("Failed to create account")
 		return nil, fmt.Errorf("error creating account: %w", err)
 	}
 
-	s.logger.WithField("account_id", createAccount.ID).Info("Successfully created account")
+	if err := s.access.grantOwner(ctx, int64(createAccount.ID)); err != nil {
+		s.log(ctx).WithError(err).WithField("account_id", createAccount.ID).Error("Failed to grant account owner, removing account")
+		if delErr := s.repo.Delete(ctx, int64(createAccount.ID)); delErr != nil {
+			s.log(ctx).WithError(delErr).WithField("account_id", createAccount.ID).Error("Failed to remove account without owner")
+		}
+		return nil, fmt.Errorf("error granting account owner: %w", err)
+	}
+
+	s.log(ctx).WithField("account_id", createAccount.ID).Info("Successfully created account")
+	s.audit.Record(ctx, entity.AuditAccountCreate, AuditTarget{AccountID: int64(createAccount.ID)}, nil, createAccount)
 	return createAccount, nil
 }
 
 func (s *AccountService) GetByID(ctx context.Context, id int64) (*entity.Account, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.GetByID")
+	defer span.End()
+
 	if id <= 0 {
-		return nil, errors.New("Invalid account ID")
+		return nil, errs.Validation("Invalid account ID")
+	}
+	if err := s.access.CheckAccount(ctx, id, entity.RelationViewer); err != nil {
+		return nil, err
 	}
 
 	existingAccount, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		s.log(ctx).WithFields(logrus.Fields{
 			"account_id": id,
 			"error":      err,
 		}).Error("Failed to fetch existing account ID")
@@ -60,13 +96,19 @@ func (s *AccountService) GetByID(ctx context.Context, id int64) (*entity.Account
 }
 
 func (s *AccountService) Delete(ctx context.Context, id int64) error {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.Delete")
+	defer span.End()
+
 	if id <= 0 {
-		return errors.New("Invalid ID news post")
+		return errs.Validation("Invalid account ID")
+	}
+	if err := s.access.CheckAccount(ctx, id, entity.RelationOwner); err != nil {
+		return err
 	}
 
-	_, err := s.repo.GetByID(ctx, id)
+	account, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		s.log(ctx).WithFields(logrus.Fields{
 			"account_id": id,
 			"error":      err,
 		}).Error("Failed to fetch account ID")
@@ -74,7 +116,7 @@ func (s *AccountService) Delete(ctx context.Context, id int64) error {
 	}
 
 	if err := s.repo.Delete(ctx, id); err != nil {
-		s.logger.WithFields(logrus.Fields{
+		s.log(ctx).WithFields(logrus.Fields{
 			"account_id": id,
 			"error":      err,
 		}).Error("Failed to remove account")
@@ -82,11 +124,72 @@ func (s *AccountService) Delete(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to remove account: %w", err)
 	}
 
-	s.logger.WithField("account_id", id).Info("Successfilly deleted")
+	s.log(ctx).WithField("account_id", id).Info("Successfilly deleted")
+	s.audit.Record(ctx, entity.AuditAccountDelete, AuditTarget{AccountID: id}, account, nil)
+	s.notify(ctx, entity.EventAccountClosed, account)
 	return nil
 }
 
+func (s *AccountService) Lock(ctx context.Context, id int64) (*entity.Account, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.Lock")
+	defer span.End()
+
+	return s.setLocked(ctx, id, true)
+}
+
+func (s *AccountService) Unlock(ctx context.Context, id int64) (*entity.Account, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.Unlock")
+	defer span.End()
+
+	return s.setLocked(ctx, id, false)
+}
+
+func (s *AccountService) setLocked(ctx context.Context, id int64, locked bool) (*entity.Account, error) {
+	if id <= 0 {
+		return nil, errs.Validation("Invalid account ID")
+	}
+
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account ID: %w", err)
+	}
+
+	account, err := s.repo.SetLocked(ctx, id, locked)
+	if err != nil {
+		s.log(ctx).WithFields(logrus.Fields{
+			"account_id": id,
+			"locked":     locked,
+			"error":      err,
+		}).Error("Failed to update account lock")
+		return nil, fmt.Errorf("failed to update account lock: %w", err)
+	}
+
+	s.log(ctx).WithFields(logrus.Fields{
+		"account_id": id,
+		"locked":     locked,
+	}).Info("Account lock updated")
+	action := entity.AuditAccountUnlock
+	if locked {
+		action = entity.AuditAccountLock
+	}
+	s.audit.Record(ctx, action, AuditTarget{AccountID: id}, before, account)
+	if locked {
+		s.notify(ctx, entity.EventAccountLocked, account)
+	}
+	return account, nil
+}
+
+// notify never fails the operation; subscribers are informed on a best effort basis.
+func (s *AccountService) notify(ctx context.Context, eventType string, account *entity.Account) {
+	if err := s.notifier.Notify(ctx, eventType, account); err != nil {
+		s.log(ctx).WithError(err).WithField("event_type", eventType).Error("Failed to enqueue account event")
+	}
+}
+
 func (s *AccountService) List(ctx context.Context, page, limit int) ([]entity.Account, int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.List")
+	defer span.End()
+
 	if page <= 0 {
 		page = 1
 	}
@@ -98,9 +201,23 @@ func (s *AccountService) List(ctx context.Context, page, limit int) ([]entity.Ac
 	}
 	offset := (page - 1) * limit
 
-	account, total, err := s.repo.List(ctx, offset, limit)
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, 0, auth.ErrUnauthenticated
+	}
+
+	var (
+		account []entity.Account
+		total   int
+		err     error
+	)
+	if principal.Role.SeesAllAccounts(false) {
+		account, total, err = s.repo.List(ctx, offset, limit)
+	} else {
+		account, total, err = s.repo.ListBySubject(ctx, principal.Subject, offset, limit)
+	}
 	if err != nil {
-		s.logger.WithFields(logrus.Fields{
+		s.log(ctx).WithFields(logrus.Fields{
 			"page":   page,
 			"limit":  limit,
 			"offset": offset,
@@ -109,7 +226,7 @@ func (s *AccountService) List(ctx context.Context, page, limit int) ([]entity.Ac
 		return nil, 0, fmt.Errorf("error to fetch account: %w", err)
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.log(ctx).WithFields(logrus.Fields{
 		"page":        page,
 		"limit":       limit,
 		"total_found": len(account),
@@ -117,3 +234,39 @@ func (s *AccountService) List(ctx context.Context, page, limit int) ([]entity.Ac
 	}).Debug("Account list fetched Successfully")
 	return account, total, nil
 }
+
+func (s *AccountService) GrantAccess(ctx context.Context, accountID int64, subject string, relation entity.AccountRelation) (*entity.AccountAccess, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AccountService.GrantAccess")
+	defer span.End()
+
+	if accountID <= 0 {
+		return nil, errs.Validation("Invalid account ID")
+	}
+	// Access comes first, although Grant checks it too: a caller who may not
+	// share the account gets the same not-found as for a missing one, so the
+	// error is returned as is.
+	if err := s.access.CheckShare(ctx, accountID); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByID(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	access, err := s.access.Grant(ctx, accountID, subject, relation)
+	if err != nil {
+		s.log(ctx).WithFields(logrus.Fields{
+			"account_id": accountID,
+			"subject":    subject,
+			"relation":   relation,
+			"error":      err,
+		}).Error("Failed to grant account access")
+		return nil, err
+	}
+	s.audit.Record(ctx, entity.AuditAccountShare, AuditTarget{AccountID: accountID}, nil, access)
+	return access, nil
+}
+
+// log returns the request-scoped logger of ctx, falling back to the service logger.
+func (s *AccountService) log(ctx context.Context) *logrus.Entry {
+	return logger.FromContext(ctx, s.logger)
+}
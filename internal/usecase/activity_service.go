@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	activityBufferSize = 64
+	replayPageSize     = 500
+)
+
+type ActivityRepo interface {
+	ListTransactionsAfter(ctx context.Context, accountID, afterID int64, limit int) ([]entity.Transaction, error)
+}
+
+type activityKey struct {
+	tenantID  string
+	accountID int64
+}
+
+// ActivityService broadcasts applied transactions to live subscribers of the
+// affected accounts. It is fed from the database (see
+// repository.ActivityListener), so subscribers see the transactions applied
+// by every instance.
+type ActivityService struct {
+	repo        ActivityRepo
+	accountRepo AccountRepository
+	access      *AccessPolicy
+	logger      *logrus.Entry
+
+	mu          sync.Mutex
+	subscribers map[activityKey]map[chan entity.AccountActivity]struct{}
+}
+
+func NewActivityService(repo ActivityRepo, accountRepo AccountRepository, access *AccessPolicy, logger *logrus.Entry) *ActivityService {
+	return &ActivityService{
+		repo:        repo,
+		accountRepo: accountRepo,
+		access:      access,
+		logger:      logger,
+		subscribers: make(map[activityKey]map[chan entity.AccountActivity]struct{}),
+	}
+}
+
+// Subscribe registers a live subscriber for the account. The returned channel
+// is closed when unsubscribe is called or when the subscriber falls behind,
+// in which case the client is expected to reconnect with Last-Event-ID.
+func (s *ActivityService) Subscribe(ctx context.Context, accountID int64) (<-chan entity.AccountActivity, func(), error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ActivityService.Subscribe")
+	defer span.End()
+
+	if err := s.access.CheckAccount(ctx, accountID, entity.RelationViewer); err != nil {
+		return nil, nil, err
+	}
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.accountRepo.GetByID(ctx, accountID); err != nil {
+		return nil, nil, fmt.Errorf("account not found: %w", err)
+	}
+
+	key := activityKey{tenantID: tenantID, accountID: accountID}
+	ch := make(chan entity.AccountActivity, activityBufferSize)
+
+	s.mu.Lock()
+	if s.subscribers[key] == nil {
+		s.subscribers[key] = make(map[chan entity.AccountActivity]struct{})
+	}
+	s.subscribers[key][ch] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.remove(key, ch)
+		})
+	}
+	return ch, unsubscribe, nil
+}
+
+// Replay passes the account's transactions after lastEventID from the
+// transaction log to send, oldest first, page by page until the log is
+// exhausted or send fails.
+func (s *ActivityService) Replay(ctx context.Context, accountID, lastEventID int64, send func(entity.AccountActivity) error) error {
+	ctx, span := tracing.Tracer().Start(ctx, "ActivityService.Replay")
+	defer span.End()
+
+	for {
+		txs, err := s.repo.ListTransactionsAfter(ctx, accountID, lastEventID, replayPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range txs {
+			activity := entity.AccountActivity{
+				AccountID:   accountID,
+				Transaction: &txs[i],
+				Replayed:    true,
+			}
+			if err := send(activity); err != nil {
+				return err
+			}
+			lastEventID = int64(txs[i].ID)
+		}
+		if len(txs) < replayPageSize {
+			return nil
+		}
+	}
+}
+
+// Snapshot returns the current balance of the account.
+func (s *ActivityService) Snapshot(ctx context.Context, accountID int64) (*entity.AccountActivity, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ActivityService.Snapshot")
+	defer span.End()
+
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.AccountActivity{AccountID: accountID, Balance: &account.Balance}, nil
+}
+
+// Applied publishes an applied transaction to the subscribers of both legs.
+func (s *ActivityService) Applied(tx *entity.Transaction) {
+	ctx, span := tracing.Tracer().Start(tenant.WithID(context.Background(), tx.TenantID), "ActivityService.Applied")
+	defer span.End()
+
+	accounts := []int64{int64(tx.AccountID)}
+	if tx.RelatedAccount != nil {
+		accounts = append(accounts, int64(*tx.RelatedAccount))
+	}
+
+	for _, accountID := range accounts {
+		key := activityKey{tenantID: tx.TenantID, accountID: accountID}
+		if !s.hasSubscribers(key) {
+			continue
+		}
+
+		activity := entity.AccountActivity{AccountID: accountID, Transaction: tx}
+		if account, err := s.accountRepo.GetByID(ctx, accountID); err == nil {
+			activity.Balance = &account.Balance
+		} else {
+			s.logger.WithError(err).WithField("account_id", accountID).Warn("Failed to load balance for activity")
+		}
+		s.broadcast(key, activity)
+	}
+}
+
+// Missed disconnects every live subscriber after transactions may have gone
+// unannounced; clients reconnect with Last-Event-ID and catch up from the
+// transaction log.
+func (s *ActivityService) Missed() {
+	s.CloseSubscribers()
+}
+
+// CloseSubscribers disconnects every live subscriber. It is called on
+// shutdown so that open streams do not hold the HTTP server until the
+// deadline; clients resume elsewhere with Last-Event-ID.
+func (s *ActivityService) CloseSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, subscribers := range s.subscribers {
+		for ch := range subscribers {
+			s.remove(key, ch)
+		}
+	}
+}
+
+func (s *ActivityService) hasSubscribers(key activityKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers[key]) > 0
+}
+
+func (s *ActivityService) broadcast(key activityKey, activity entity.AccountActivity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers[key] {
+		select {
+		case ch <- activity:
+		default:
+			s.logger.WithField("account_id", key.accountID).Warn("Activity subscriber is too slow, disconnecting")
+			s.remove(key, ch)
+		}
+	}
+}
+
+// remove must be called with s.mu held.
+func (s *ActivityService) remove(key activityKey, ch chan entity.AccountActivity) {
+	if _, ok := s.subscribers[key][ch]; !ok {
+		return
+	}
+	delete(s.subscribers[key], ch)
+	close(ch)
+	if len(s.subscribers[key]) == 0 {
+		delete(s.subscribers, key)
+	}
+}
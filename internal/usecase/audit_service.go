@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/clientip"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type AuditRepo interface {
+	Append(ctx context.Context, entry *entity.AuditEntry) error
+	List(ctx context.Context, filter entity.AuditFilter, offset, limit int) ([]entity.AuditEntry, int, error)
+}
+
+// Auditor records state-changing operations. Recording never fails the
+// operation, which has already been applied when it is called.
+type Auditor interface {
+	Record(ctx context.Context, action string, target AuditTarget, before, after interface{})
+}
+
+// AuditTarget names the account and transaction an operation touched.
+type AuditTarget struct {
+	AccountID     int64
+	TransactionID int64
+}
+
+type AuditService struct {
+	repo   AuditRepo
+	logger *logrus.Entry
+}
+
+func NewAuditService(repo AuditRepo, logger *logrus.Entry) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Record appends an entry with the actor, request ID and source IP of ctx.
+// before and after are stored as JSON snapshots; nil means no snapshot.
+func (s *AuditService) Record(ctx context.Context, action string, target AuditTarget, before, after interface{}) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuditService.Record")
+	defer span.End()
+
+	log := logger.FromContext(ctx, s.logger).WithField("action", action)
+
+	entry := entity.AuditEntry{
+		Action:    action,
+		RequestID: requestid.FromContext(ctx),
+		SourceIP:  clientip.FromContext(ctx),
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		entry.ActorSubject = p.Subject
+		entry.ActorRole = string(p.Role)
+	}
+	if target.AccountID > 0 {
+		entry.AccountID = &target.AccountID
+	}
+	if target.TransactionID > 0 {
+		entry.TransactionID = &target.TransactionID
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		log.WithError(err).Error("Failed to encode audit snapshot")
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		log.WithError(err).Error("Failed to encode audit snapshot")
+	}
+
+	if err := s.repo.Append(ctx, &entry); err != nil {
+		log.WithError(err).WithFields(logrus.Fields{
+			"actor":      entry.ActorSubject,
+			"account_id": target.AccountID,
+		}).Error("Failed to record audit entry")
+	}
+}
+
+// Notify records the outcome of transactions applied by the consumer.
+func (s *AuditService) Notify(ctx context.Context, eventType string, data interface{}) error {
+	switch eventType {
+	case entity.EventTransactionCompleted:
+		tx, ok := data.(*entity.Transaction)
+		if !ok {
+			return nil
+		}
+		s.Record(ctx, entity.AuditTransactionCompleted, AuditTarget{
+			AccountID:     int64(tx.AccountID),
+			TransactionID: int64(tx.ID),
+		}, nil, tx)
+	case entity.EventTransactionFailed:
+		var failed struct {
+			Event struct {
+				AccountID int64 `json:"account_id"`
+			} `json:"event"`
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("error encoding failed transaction: %w", err)
+		}
+		_ = json.Unmarshal(raw, &failed)
+		s.Record(ctx, entity.AuditTransactionFailed, AuditTarget{AccountID: failed.Event.AccountID}, nil, json.RawMessage(raw))
+	}
+	return nil
+}
+
+// List returns a page of the tenant's audit log, newest first.
+func (s *AuditService) List(ctx context.Context, filter entity.AuditFilter, page, limit int) ([]entity.AuditEntry, int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuditService.List")
+	defer span.End()
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, 0, errs.Validation("from must be before to")
+	}
+
+	entries, total, err := s.repo.List(ctx, filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing audit log: %w", err)
+	}
+	return entries, total, nil
+}
+
+func snapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(v)
+}
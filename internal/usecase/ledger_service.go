@@ -0,0 +1,238 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/ledger"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const chainPageSize = 1000
+
+type LedgerRepo interface {
+	ChainAccounts(ctx context.Context) ([]int64, error)
+	ListChain(ctx context.Context, accountID, afterID int64, limit int) ([]ledger.Link, error)
+	ChainHeads(ctx context.Context) ([]entity.ChainHead, error)
+	SaveCheckpoint(ctx context.Context, cp *entity.LedgerCheckpoint) error
+	ListCheckpoints(ctx context.Context, limit int) ([]entity.LedgerCheckpoint, error)
+	LatestCheckpoint(ctx context.Context) (*entity.LedgerCheckpoint, error)
+}
+
+// LedgerService verifies the transaction hash chains and seals their heads
+// in signed checkpoints.
+type LedgerService struct {
+	repo   LedgerRepo
+	signer *ledger.Signer
+	logger *logrus.Entry
+}
+
+// NewLedgerService creates the service. Without a signer, checkpoints cannot
+// be created and verification only walks the chains.
+func NewLedgerService(repo LedgerRepo, signer *ledger.Signer, logger *logrus.Entry) *LedgerService {
+	return &LedgerService{
+		repo:   repo,
+		signer: signer,
+		logger: logger,
+	}
+}
+
+// Verify walks the chain of one account, or of every account when accountID
+// is zero, and stops at the first broken link. The chains are also checked
+// against the heads sealed in the latest checkpoint, so that rewriting or
+// truncating a whole chain is detected.
+func (s *LedgerService) Verify(ctx context.Context, accountID int64) (*entity.ChainReport, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "LedgerService.Verify")
+	defer span.End()
+
+	report := &entity.ChainReport{Valid: true}
+
+	sealed := map[int64]entity.ChainHead{}
+	checkpoint, err := s.repo.LatestCheckpoint(ctx)
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("error loading ledger checkpoint: %w", err)
+	default:
+		report.CheckpointID = checkpoint.ID
+		if s.signer != nil {
+			if err := s.signer.Verify(*checkpoint); err != nil {
+				report.Valid = false
+				report.FirstBreak = &entity.ChainBreak{Reason: fmt.Sprintf("checkpoint %d: %v", checkpoint.ID, err)}
+				return report, nil
+			}
+		}
+		for _, h := range checkpoint.Heads {
+			sealed[h.AccountID] = h
+		}
+	}
+
+	accounts := []int64{accountID}
+	if accountID == 0 {
+		if accounts, err = s.repo.ChainAccounts(ctx); err != nil {
+			return nil, fmt.Errorf("error listing chain accounts: %w", err)
+		}
+		// Accounts sealed in the checkpoint must still have transactions.
+		present := make(map[int64]bool, len(accounts))
+		for _, id := range accounts {
+			present[id] = true
+		}
+		for id, head := range sealed {
+			if !present[id] {
+				report.Valid = false
+				report.FirstBreak = &entity.ChainBreak{
+					AccountID:     id,
+					TransactionID: head.TransactionID,
+					Reason:        "checkpointed chain is missing",
+					Expected:      head.Hash,
+				}
+				return report, nil
+			}
+		}
+	}
+
+	for _, id := range accounts {
+		head, hasHead := sealed[id]
+		brk, err := s.verifyChain(ctx, id, head, hasHead, report)
+		if err != nil {
+			return nil, err
+		}
+		report.AccountsChecked++
+		if brk != nil {
+			report.Valid = false
+			report.FirstBreak = brk
+			logger.FromContext(ctx, s.logger).WithFields(logrus.Fields{
+				"account_id":     brk.AccountID,
+				"transaction_id": brk.TransactionID,
+				"reason":         brk.Reason,
+			}).Warn("Transaction chain verification failed")
+			break
+		}
+	}
+	return report, nil
+}
+
+func (s *LedgerService) verifyChain(ctx context.Context, accountID int64, head entity.ChainHead, hasHead bool, report *entity.ChainReport) (*entity.ChainBreak, error) {
+	var (
+		prev     string
+		hashed   bool
+		headSeen bool
+		afterID  int64
+	)
+	for {
+		links, err := s.repo.ListChain(ctx, accountID, afterID, chainPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("error reading chain of account %d: %w", accountID, err)
+		}
+		for _, l := range links {
+			afterID = l.ID
+			report.TransactionsChecked++
+
+			if l.Hash == "" {
+				// Rows from before hashing may only precede the chain.
+				if hashed {
+					return &entity.ChainBreak{AccountID: accountID, TransactionID: l.ID, Reason: "hash is missing"}, nil
+				}
+				report.Unsealed++
+				continue
+			}
+			hashed = true
+
+			if l.PrevHash != prev {
+				return &entity.ChainBreak{
+					AccountID: accountID, TransactionID: l.ID,
+					Reason: "prev_hash does not match the previous transaction", Expected: prev, Actual: l.PrevHash,
+				}, nil
+			}
+			if computed := ledger.Hash(l.PrevHash, l.Record); computed != l.Hash {
+				return &entity.ChainBreak{
+					AccountID: accountID, TransactionID: l.ID,
+					Reason: "hash does not match transaction content", Expected: computed, Actual: l.Hash,
+				}, nil
+			}
+			if hasHead && l.ID == head.TransactionID {
+				headSeen = true
+				if l.Hash != head.Hash {
+					return &entity.ChainBreak{
+						AccountID: accountID, TransactionID: l.ID,
+						Reason: "hash differs from the checkpoint", Expected: head.Hash, Actual: l.Hash,
+					}, nil
+				}
+			}
+			prev = l.Hash
+		}
+		if len(links) < chainPageSize {
+			break
+		}
+	}
+
+	if hasHead && !headSeen {
+		return &entity.ChainBreak{
+			AccountID: accountID, TransactionID: head.TransactionID,
+			Reason: "checkpointed transaction is missing", Expected: head.Hash,
+		}, nil
+	}
+	return nil, nil
+}
+
+// Checkpoint seals the current chain heads of the context tenant.
+func (s *LedgerService) Checkpoint(ctx context.Context) (*entity.LedgerCheckpoint, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "LedgerService.Checkpoint")
+	defer span.End()
+
+	if s.signer == nil {
+		return nil, errs.Conflict("ledger signing key is not configured")
+	}
+
+	heads, err := s.repo.ChainHeads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading chain heads: %w", err)
+	}
+
+	// Postgres keeps microseconds; the signed time must survive the round trip.
+	cp := &entity.LedgerCheckpoint{
+		Heads:     heads,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if cp.TenantID, err = tenant.Require(ctx); err != nil {
+		return nil, err
+	}
+	s.signer.Seal(cp)
+
+	if err := s.repo.SaveCheckpoint(ctx, cp); err != nil {
+		return nil, fmt.Errorf("error saving ledger checkpoint: %w", err)
+	}
+	logger.FromContext(ctx, s.logger).WithFields(logrus.Fields{
+		"checkpoint_id": cp.ID,
+		"accounts":      len(heads),
+		"root":          cp.Root,
+	}).Info("Ledger checkpoint created")
+	return cp, nil
+}
+
+// ListCheckpoints returns the latest checkpoints and the public key that
+// verifies their signatures.
+func (s *LedgerService) ListCheckpoints(ctx context.Context, limit int) ([]entity.LedgerCheckpoint, string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "LedgerService.ListCheckpoints")
+	defer span.End()
+
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	checkpoints, err := s.repo.ListCheckpoints(ctx, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	publicKey := ""
+	if s.signer != nil {
+		publicKey = s.signer.PublicKey()
+	}
+	return checkpoints, publicKey, nil
+}
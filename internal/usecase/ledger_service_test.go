@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/ledger"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeLedgerRepo keeps the chains of one tenant in memory.
+type fakeLedgerRepo struct {
+	chains     map[int64][]ledger.Link
+	checkpoint *entity.LedgerCheckpoint
+}
+
+func (r *fakeLedgerRepo) ChainAccounts(context.Context) ([]int64, error) {
+	var ids []int64
+	for id, links := range r.chains {
+		if len(links) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (r *fakeLedgerRepo) ListChain(_ context.Context, accountID, afterID int64, limit int) ([]ledger.Link, error) {
+	var links []ledger.Link
+	for _, l := range r.chains[accountID] {
+		if l.ID > afterID && len(links) < limit {
+			links = append(links, l)
+		}
+	}
+	return links, nil
+}
+
+func (r *fakeLedgerRepo) ChainHeads(context.Context) ([]entity.ChainHead, error) {
+	var heads []entity.ChainHead
+	for id, links := range r.chains {
+		if len(links) > 0 {
+			last := links[len(links)-1]
+			heads = append(heads, entity.ChainHead{AccountID: id, TransactionID: last.ID, Hash: last.Hash})
+		}
+	}
+	return heads, nil
+}
+
+func (r *fakeLedgerRepo) SaveCheckpoint(_ context.Context, cp *entity.LedgerCheckpoint) error {
+	cp.ID = 1
+	saved := *cp
+	saved.Heads = slices.Clone(cp.Heads)
+	r.checkpoint = &saved
+	return nil
+}
+
+func (r *fakeLedgerRepo) ListCheckpoints(context.Context, int) ([]entity.LedgerCheckpoint, error) {
+	if r.checkpoint == nil {
+		return nil, nil
+	}
+	return []entity.LedgerCheckpoint{*r.checkpoint}, nil
+}
+
+func (r *fakeLedgerRepo) LatestCheckpoint(context.Context) (*entity.LedgerCheckpoint, error) {
+	if r.checkpoint == nil {
+		return nil, errs.NotFound("no ledger checkpoint")
+	}
+	return r.checkpoint, nil
+}
+
+// chain builds n hashed deposits of 10.00 on an account, with IDs from
+// firstID stepping by 10.
+func chain(accountID, firstID int64, n int) []ledger.Link {
+	links := make([]ledger.Link, 0, n)
+	prev := ""
+	for i := 0; i < n; i++ {
+		r := ledger.Record{
+			TenantID:        "acme",
+			ID:              firstID + int64(10*i),
+			AccountID:       accountID,
+			Amount:          "10.00",
+			TransactionType: entity.TransactionTypeDeposit,
+			CreatedAt:       time.Date(2026, 10, 18, 12, 0, i, 0, time.UTC),
+			RequestID:       "req-" + strconv.Itoa(i),
+		}
+		h := ledger.Hash(prev, r)
+		links = append(links, ledger.Link{Record: r, PrevHash: prev, Hash: h})
+		prev = h
+	}
+	return links
+}
+
+// rehash recomputes the hashes of links from the first one, as someone
+// rewriting a chain without the checkpoint key would.
+func rehash(links []ledger.Link) {
+	prev := ""
+	for i := range links {
+		links[i].PrevHash = prev
+		links[i].Hash = ledger.Hash(prev, links[i].Record)
+		prev = links[i].Hash
+	}
+}
+
+func newLedgerTest(t *testing.T) (*LedgerService, *fakeLedgerRepo, context.Context) {
+	t.Helper()
+	signer, err := ledger.NewSigner(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")))
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	l := logrus.New()
+	l.SetLevel(logrus.PanicLevel)
+	repo := &fakeLedgerRepo{chains: map[int64][]ledger.Link{1: chain(1, 1, 3), 2: chain(2, 2, 3)}}
+	ctx := tenant.WithID(context.Background(), "acme")
+	return NewLedgerService(repo, signer, logrus.NewEntry(l)), repo, ctx
+}
+
+func TestLedgerVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		// checkpoint seals the chains before edit runs.
+		checkpoint bool
+		edit       func(t *testing.T, repo *fakeLedgerRepo)
+		accountID  int64
+		// reason is the prefix of the reason of the first break, or "" when
+		// the chains are valid.
+		reason       string
+		breakAt      [2]int64
+		accounts     int
+		transactions int
+		unsealed     int
+	}{
+		{name: "intact", checkpoint: true, accounts: 2, transactions: 6},
+		{name: "intact without checkpoint", accounts: 2, transactions: 6},
+		{
+			name:       "edited amount",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[1][1].Amount = "1000.00"
+			},
+			reason:  "hash does not match transaction content",
+			breakAt: [2]int64{1, 11},
+		},
+		{
+			name: "edited amount without checkpoint",
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[2][0].Amount = "10.01"
+			},
+			reason:  "hash does not match transaction content",
+			breakAt: [2]int64{2, 2},
+		},
+		{
+			name:       "deleted row",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[2] = slices.Delete(repo.chains[2], 1, 2)
+			},
+			reason:  "prev_hash does not match the previous transaction",
+			breakAt: [2]int64{2, 22},
+		},
+		{
+			name:       "truncated chain",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[1] = repo.chains[1][:2]
+			},
+			reason:  "checkpointed transaction is missing",
+			breakAt: [2]int64{1, 21},
+		},
+		{
+			name:       "rewritten chain",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[1][0].Amount = "1000.00"
+				rehash(repo.chains[1])
+			},
+			reason:  "hash differs from the checkpoint",
+			breakAt: [2]int64{1, 21},
+		},
+		{
+			name:       "deleted chain",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				delete(repo.chains, 2)
+			},
+			reason:  "checkpointed chain is missing",
+			breakAt: [2]int64{2, 22},
+		},
+		{
+			name:       "deleted chain of another account",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				delete(repo.chains, 2)
+			},
+			accountID:    1,
+			accounts:     1,
+			transactions: 3,
+		},
+		{
+			name:       "forged checkpoint",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[1] = repo.chains[1][:2]
+				repo.checkpoint.Heads[0] = entity.ChainHead{AccountID: 1, TransactionID: 11, Hash: repo.chains[1][1].Hash}
+			},
+			reason: "checkpoint 1: checkpoint root",
+		},
+		{
+			name:       "checkpoint of another key",
+			checkpoint: true,
+			edit: func(t *testing.T, repo *fakeLedgerRepo) {
+				forger, err := ledger.NewSigner(base64.StdEncoding.EncodeToString(make([]byte, 32)))
+				if err != nil {
+					t.Fatalf("NewSigner: %v", err)
+				}
+				repo.chains[1] = repo.chains[1][:2]
+				repo.checkpoint.Heads[0] = entity.ChainHead{AccountID: 1, TransactionID: 11, Hash: repo.chains[1][1].Hash}
+				forger.Seal(repo.checkpoint)
+			},
+			reason: "checkpoint 1: checkpoint signed with key",
+		},
+		{
+			name:       "missing hash",
+			checkpoint: true,
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[2][1].Hash = ""
+			},
+			reason:  "hash is missing",
+			breakAt: [2]int64{2, 12},
+		},
+		{
+			name: "rows from before hashing",
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				unsealed := chain(1, 1, 2)
+				for i := range unsealed {
+					unsealed[i].PrevHash, unsealed[i].Hash = "", ""
+				}
+				repo.chains[1] = append(unsealed, chain(1, 21, 3)...)
+			},
+			accounts:     2,
+			transactions: 8,
+			unsealed:     2,
+		},
+		{
+			name: "break after the first page",
+			edit: func(_ *testing.T, repo *fakeLedgerRepo) {
+				repo.chains[1] = chain(1, 1, chainPageSize+500)
+				repo.chains[1][chainPageSize+100].Amount = "0.00"
+			},
+			reason:  "hash does not match transaction content",
+			breakAt: [2]int64{1, 1 + 10*(chainPageSize+100)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, ctx := newLedgerTest(t)
+			if tt.checkpoint {
+				if _, err := s.Checkpoint(ctx); err != nil {
+					t.Fatalf("Checkpoint: %v", err)
+				}
+			}
+			if tt.edit != nil {
+				tt.edit(t, repo)
+			}
+
+			report, err := s.Verify(ctx, tt.accountID)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if tt.reason == "" {
+				if !report.Valid || report.FirstBreak != nil {
+					t.Fatalf("Verify = %+v, first break %+v, want valid", report, report.FirstBreak)
+				}
+				if report.AccountsChecked != tt.accounts || report.TransactionsChecked != tt.transactions || report.Unsealed != tt.unsealed {
+					t.Errorf("checked %d accounts and %d transactions with %d unsealed, want %d, %d and %d",
+						report.AccountsChecked, report.TransactionsChecked, report.Unsealed, tt.accounts, tt.transactions, tt.unsealed)
+				}
+				return
+			}
+
+			brk := report.FirstBreak
+			if report.Valid || brk == nil {
+				t.Fatalf("Verify = %+v, want a break: %s", report, tt.reason)
+			}
+			if !strings.HasPrefix(brk.Reason, tt.reason) {
+				t.Errorf("break reason = %q, want %q", brk.Reason, tt.reason)
+			}
+			if got := [2]int64{brk.AccountID, brk.TransactionID}; got != tt.breakAt {
+				t.Errorf("break at account and transaction %v, want %v", got, tt.breakAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+)
+
+// Notifiers fans an event out to every notifier and joins their errors.
+type Notifiers []EventNotifier
+
+func (n Notifiers) Notify(ctx context.Context, eventType string, data interface{}) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, eventType, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/auth"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/requestid"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ReconciliationRepo interface {
+	ListMismatches(ctx context.Context, accountID int64) (int, []entity.BalanceMismatch, error)
+	OpenCorrection(ctx context.Context, c *entity.BalanceCorrection) (*entity.BalanceCorrection, bool, error)
+	GetCorrection(ctx context.Context, id int64) (*entity.BalanceCorrection, error)
+	ListCorrections(ctx context.Context, status string, limit int) ([]entity.BalanceCorrection, error)
+	DecideCorrection(ctx context.Context, id int64, approve bool, txn *entity.Transaction) (*entity.BalanceCorrection, error)
+}
+
+// ReconciliationService recomputes account balances from the transaction
+// log and manages the corrections that explain the differences. A
+// correction never changes a balance: it logs the missing amount as a
+// 'correction' transaction once a second person approves it.
+type ReconciliationService struct {
+	repo   ReconciliationRepo
+	audit  Auditor
+	logger *logrus.Entry
+}
+
+func NewReconciliationService(repo ReconciliationRepo, audit Auditor, logger *logrus.Entry) *ReconciliationService {
+	return &ReconciliationService{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// Reconcile checks one account, or every account when accountID is zero.
+// With openCorrections a pending correction is opened for each mismatch
+// that does not have one yet.
+func (s *ReconciliationService) Reconcile(ctx context.Context, accountID int64, openCorrections bool) (*entity.ReconciliationReport, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReconciliationService.Reconcile")
+	defer span.End()
+
+	log := logger.FromContext(ctx, s.logger)
+	report := &entity.ReconciliationReport{StartedAt: time.Now().UTC()}
+
+	checked, mismatches, err := s.repo.ListMismatches(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("error reconciling balances: %w", err)
+	}
+	if accountID != 0 && checked == 0 {
+		return nil, errs.NotFound("account %d not found", accountID)
+	}
+	report.AccountsChecked = checked
+	report.Mismatches = mismatches
+
+	for i := range report.Mismatches {
+		m := &report.Mismatches[i]
+		log.WithFields(logrus.Fields{
+			"account_id": m.AccountID,
+			"balance":    m.Balance,
+			"expected":   m.Expected,
+			"difference": m.Difference,
+		}).Warn("Account balance does not match its transaction log")
+
+		if !openCorrections {
+			continue
+		}
+		correction, created, err := s.repo.OpenCorrection(ctx, &entity.BalanceCorrection{
+			AccountID:       m.AccountID,
+			Amount:          m.Difference,
+			Balance:         m.Balance,
+			ExpectedBalance: m.Expected,
+			RequestedBy:     actorSubject(ctx),
+		})
+		if err != nil {
+			return nil, err
+		}
+		m.CorrectionID = &correction.ID
+		if created {
+			report.CorrectionsOpened++
+			s.audit.Record(ctx, entity.AuditCorrectionOpen, AuditTarget{AccountID: m.AccountID}, nil, correction)
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	log.WithFields(logrus.Fields{
+		"accounts_checked":   report.AccountsChecked,
+		"mismatches":         len(report.Mismatches),
+		"corrections_opened": report.CorrectionsOpened,
+	}).Info("Balance reconciliation finished")
+	return report, nil
+}
+
+func (s *ReconciliationService) ListCorrections(ctx context.Context, status string, limit int) ([]entity.BalanceCorrection, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReconciliationService.ListCorrections")
+	defer span.End()
+
+	switch status {
+	case "", entity.CorrectionPending, entity.CorrectionApproved, entity.CorrectionRejected:
+	default:
+		return nil, errs.Validation("invalid status %q", status)
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.repo.ListCorrections(ctx, status, limit)
+}
+
+// ApproveCorrection logs the correction amount as a 'correction' transaction.
+// It fails with a conflict when the account has changed since the
+// correction was opened, so that a stale amount is never applied.
+func (s *ReconciliationService) ApproveCorrection(ctx context.Context, id int64) (*entity.BalanceCorrection, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReconciliationService.ApproveCorrection")
+	defer span.End()
+
+	return s.decide(ctx, id, true)
+}
+
+func (s *ReconciliationService) RejectCorrection(ctx context.Context, id int64) (*entity.BalanceCorrection, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "ReconciliationService.RejectCorrection")
+	defer span.End()
+
+	return s.decide(ctx, id, false)
+}
+
+func (s *ReconciliationService) decide(ctx context.Context, id int64, approve bool) (*entity.BalanceCorrection, error) {
+	before, err := s.repo.GetCorrection(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Four eyes: whoever opened the correction cannot decide it.
+	subject := actorSubject(ctx)
+	if subject == before.RequestedBy {
+		return nil, fmt.Errorf("%w: correction %d must be decided by someone other than its requester", auth.ErrForbidden, id)
+	}
+
+	txn := &entity.Transaction{
+		CreatedAt:   time.Now().UTC(),
+		RequestID:   requestid.FromContext(ctx),
+		TraceID:     trace.SpanContextFromContext(ctx).TraceID().String(),
+		InitiatedBy: subject,
+	}
+	after, err := s.repo.DecideCorrection(ctx, id, approve, txn)
+	if err != nil {
+		return nil, err
+	}
+
+	action := entity.AuditCorrectionReject
+	target := AuditTarget{AccountID: after.AccountID}
+	if approve {
+		action = entity.AuditCorrectionApprove
+		target.TransactionID = int64(txn.ID)
+	}
+	s.audit.Record(ctx, action, target, before, after)
+
+	logger.FromContext(ctx, s.logger).WithFields(logrus.Fields{
+		"correction_id": id,
+		"account_id":    after.AccountID,
+		"status":        after.Status,
+	}).Info("Balance correction decided")
+	return after, nil
+}
+
+func actorSubject(ctx context.Context) string {
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		return p.Subject
+	}
+	return "system"
+}
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type TransactionApplyRepo interface {
+	ApplyTransaction(ctx context.Context, txn *entity.Transaction) (*entity.TransactionResult, error)
+	RecordFailure(ctx context.Context, res *entity.TransactionResult) (*entity.TransactionResult, error)
+}
+
+// TransactionApplier applies transaction events to the balances and the
+// transaction log. The consumer runs it for delivered messages and the
+// synchronous mode of TransactionService runs it in the request, so both
+// produce the same transactions and notifications.
+type TransactionApplier struct {
+	repo     TransactionApplyRepo
+	notifier EventNotifier
+	logger   *logrus.Entry
+}
+
+func NewTransactionApplier(repo TransactionApplyRepo, notifier EventNotifier, logger *logrus.Entry) *TransactionApplier {
+	return &TransactionApplier{
+		repo:     repo,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// Apply moves the balances of payload and records it, in one database
+// transaction, as occurring at env.OccurredAt on behalf of the request in
+// correlation. ctx must carry the tenant of payload. A rejection by the
+// business rules is stored as the result of env.EventID and reported to the
+// notifier; a transient failure, such as a lost connection, is not, so that
+// a redelivery can still apply the event. An event that already has a
+// result is not applied again and gives its stored outcome.
+func (a *TransactionApplier) Apply(ctx context.Context, env *event.Envelope, payload entity.TransactionEvent, correlation bus.Correlation, traceID string) (*entity.Transaction, error) {
+	tx := &entity.Transaction{
+		AccountID:       int(payload.AccountID),
+		RelatedAccount:  relatedAccount(payload.RelatedAccount),
+		Amount:          payload.Amount,
+		TransactionType: payload.TransactionType,
+		CreatedAt:       env.OccurredAt,
+		RequestID:       correlation.RequestID,
+		TraceID:         traceID,
+		InitiatedBy:     correlation.CallerSubject,
+		EventID:         env.EventID,
+	}
+	stored, err := a.repo.ApplyTransaction(ctx, tx)
+	if err != nil {
+		log := logger.FromContext(ctx, a.logger)
+		log.WithError(err).Error("Failed to process transaction")
+		if errs.Code(err) == errs.CodeInternal {
+			return nil, err
+		}
+		failure := &entity.TransactionResult{
+			EventID:   env.EventID,
+			AccountID: payload.AccountID,
+			ErrorCode: errs.Code(err),
+			Error:     err.Error(),
+		}
+		stored, recErr := a.repo.RecordFailure(ctx, failure)
+		if recErr != nil {
+			// Without a stored result the event must be delivered again.
+			log.WithError(recErr).Error("Failed to record transaction result")
+			return nil, recErr
+		}
+		if stored != nil {
+			return storedOutcome(stored)
+		}
+		a.notify(ctx, entity.EventTransactionFailed, map[string]interface{}{
+			"event_id": env.EventID,
+			"event":    payload,
+			"reason":   err.Error(),
+		})
+		return nil, err
+	}
+	if stored != nil {
+		return storedOutcome(stored)
+	}
+
+	metrics.ObserveTransaction(payload.TransactionType, payload.Currency, payload.Amount)
+	a.notify(ctx, entity.EventTransactionCompleted, tx)
+	return tx, nil
+}
+
+// storedOutcome gives the outcome of an event that already had a result when
+// it was delivered. Metrics and notifications went out when the result was
+// stored.
+func storedOutcome(stored *entity.TransactionResult) (*entity.Transaction, error) {
+	if stored.Status == entity.ResultFailed {
+		return nil, errs.FromCode(stored.ErrorCode, stored.Error)
+	}
+	return stored.Transaction, nil
+}
+
+func relatedAccount(id *int64) *int {
+	if id == nil {
+		return nil
+	}
+	related := int(*id)
+	return &related
+}
+
+func (a *TransactionApplier) notify(ctx context.Context, eventType string, data interface{}) {
+	if err := a.notifier.Notify(ctx, eventType, data); err != nil {
+		logger.FromContext(ctx, a.logger).WithError(err).WithField("event_type", eventType).Error("Failed to enqueue transaction event")
+	}
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/serikdev/CashFlow/internal/bus"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/metrics"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TransactionConsumer applies the transaction events delivered by the
+// message bus, whichever bus carried them.
+type TransactionConsumer struct {
+	applier *TransactionApplier
+	logger  *logrus.Entry
+}
+
+func NewTransactionConsumer(applier *TransactionApplier, logger *logrus.Entry) *TransactionConsumer {
+	return &TransactionConsumer{
+		applier: applier,
+		logger:  logger,
+	}
+}
+
+// Register routes the deposit, withdrawal and transfer events to Handle.
+func (c *TransactionConsumer) Register(registry *bus.Registry) {
+	registry.Register(event.TypeDeposit, c.Handle)
+	registry.Register(event.TypeWithdrawal, c.Handle)
+	registry.Register(event.TypeTransfer, c.Handle)
+}
+
+// Handle applies one transaction event. Failures are logged and counted. A
+// rejected event is final; a transient failure is returned as retryable, so
+// the bus delivers the message again.
+func (c *TransactionConsumer) Handle(ctx context.Context, m bus.Message, env *event.Envelope) error {
+	correlation := bus.CorrelationFromHeaders(m.Headers)
+	traceID := trace.SpanContextFromContext(ctx).TraceID().String()
+	log := c.logger.WithField("topic", m.Topic).WithFields(correlation.Fields()).WithField("trace_id", traceID)
+
+	payload, err := env.Transaction()
+	if err != nil {
+		metrics.ConsumeError(m.Topic, "decode")
+		log.WithError(err).Error("Failed to decode event")
+		return err
+	}
+	log = log.WithFields(logrus.Fields{
+		"event_id":       env.EventID,
+		"schema_version": env.SchemaVersion,
+		"tenant_id":      payload.TenantID,
+	})
+	msgCtx := correlation.WithContext(ctx, payload.TenantID)
+	msgCtx = logger.WithContext(msgCtx, log)
+
+	log.WithFields(logrus.Fields{
+		"key":        m.Key,
+		"event_type": env.EventType,
+	}).Info("Message received")
+
+	if _, err := c.applier.Apply(msgCtx, env, payload, correlation, traceID); err != nil {
+		metrics.ConsumeError(m.Topic, "apply")
+		if errs.Code(err) == errs.CodeInternal {
+			return bus.Retryable(err)
+		}
+		return err
+	}
+	return nil
+}
@@ -2,37 +2,87 @@ package usecase
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/serikdev/CashFlow/internal/bus"
 	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/event"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TransactionRepo interface {
-	ListTransactions(accountID int64) ([]entity.Transaction, error)
+	ListTransactions(ctx context.Context, accountID int64) ([]entity.Transaction, error)
+	ListTransactionsAfter(ctx context.Context, accountID, afterID int64, limit int) ([]entity.Transaction, error)
+	GetResult(ctx context.Context, eventID string) (*entity.TransactionResult, error)
 }
+
+// ResultSubscriber announces stored transaction results.
+type ResultSubscriber interface {
+	// Subscribe returns a channel that receives when the result of eventID
+	// may have been stored, and a func to unsubscribe.
+	Subscribe(eventID string) (<-chan struct{}, func())
+}
+
+// ErrTransactionPending is returned by Await when the transaction has no
+// result yet.
+var ErrTransactionPending = errors.New("transaction is still pending")
+
 type AccountRepository interface {
 	GetByID(ctx context.Context, accountID int64) (*entity.Account, error)
 }
 
 type Producer interface {
-	Publish(topic, key string, value []byte) error
+	Publish(ctx context.Context, topic, key string, env *event.Envelope) error
+}
+
+// Transaction processing modes.
+const (
+	// ModeAsync publishes transaction events for the consumers to apply.
+	ModeAsync = "async"
+	// ModeSync applies transactions within the request.
+	ModeSync = "sync"
+)
+
+// TransactionTopics are the full names of the topics transaction events
+// are published on.
+type TransactionTopics struct {
+	Deposit  string
+	Withdraw string
+	Transfer string
 }
 
 type TransactionServiceDeps struct {
 	TransactionRepo TransactionRepo
 	AccountRepo     AccountRepo
 	Producer        Producer
-	Logger          *logrus.Entry
+	Topics          TransactionTopics
+	// Applier applies transactions in ModeSync.
+	Applier *TransactionApplier
+	Mode    string
+	// Results wakes Await when a result is stored.
+	Results ResultSubscriber
+	Access  *AccessPolicy
+	Audit   Auditor
+	Logger  *logrus.Entry
 }
 
 type TransactionService struct {
 	transacRepo TransactionRepo
 	accountRepo AccountRepo
 	producer    Producer
+	topics      TransactionTopics
+	applier     *TransactionApplier
+	sync        bool
+	results     ResultSubscriber
+	access      *AccessPolicy
+	audit       Auditor
 	logger      *logrus.Entry
 }
 
@@ -41,85 +91,92 @@ func NewTransactionService(deps TransactionServiceDeps) *TransactionService {
 		transacRepo: deps.TransactionRepo,
 		accountRepo: deps.AccountRepo,
 		producer:    deps.Producer,
+		topics:      deps.Topics,
+		applier:     deps.Applier,
+		sync:        deps.Mode == ModeSync,
+		results:     deps.Results,
+		access:      deps.Access,
+		audit:       deps.Audit,
 		logger:      deps.Logger,
 	}
 }
 
 func (s *TransactionService) Deposit(ctx context.Context, accountID int64, amount float64) (*entity.Transaction, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.Deposit")
+	defer span.End()
+
 	if amount <= 0 {
-		return nil, errors.New("deposit amount must be greater than zero")
+		return nil, errs.Validation("deposit amount must be greater than zero")
 	}
-	_, err := s.checkAccountActive(ctx, accountID)
+	if err := s.access.CheckAccount(ctx, accountID, entity.RelationSignatory); err != nil {
+		return nil, err
+	}
+	account, err := s.checkAccountActive(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := tenant.Require(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	event := entity.TransactionEvent{
+	payload := entity.TransactionEvent{
+		TenantID:        tenantID,
 		AccountID:       accountID,
 		Amount:          amount,
-		TransactionType: "deposit",
-		CreatedAt:       time.Now(),
+		Currency:        account.Currency,
+		TransactionType: entity.TransactionTypeDeposit,
 	}
 
-	data, err := json.Marshal(event)
-	if err != nil {
-		return nil, fmt.Errorf("error to marshal deposit event: %w", err)
-	}
-
-	if err := s.producer.Publish("account-deposit", fmt.Sprintf("%d", accountID), data); err != nil {
-		return nil, fmt.Errorf("error to publish deposit event: %w", err)
-	}
-	return &entity.Transaction{
-		AccountID:       int(accountID),
-		Amount:          amount,
-		TransactionType: "deposit",
-		CreatedAt:       event.CreatedAt,
-	}, nil
+	return s.submit(ctx, s.topics.Deposit, payload, entity.AuditTransactionDeposit)
 }
 
 func (s *TransactionService) Withdraw(ctx context.Context, accountID int64, amount float64) (*entity.Transaction, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.Withdraw")
+	defer span.End()
+
 	if amount <= 0 {
-		return nil, errors.New("withdraw amount must be greater than zero")
+		return nil, errs.Validation("withdraw amount must be greater than zero")
 	}
-
-	_, err := s.checkAccountActive(ctx, accountID)
-	if err != nil {
+	if err := s.access.CheckAccount(ctx, accountID, entity.RelationSignatory); err != nil {
 		return nil, err
 	}
 
-	event := entity.TransactionEvent{
-		AccountID:       accountID,
-		Amount:          amount,
-		TransactionType: "withdraw",
-		CreatedAt:       time.Now(),
+	account, err := s.checkAccountActive(ctx, accountID)
+	if err != nil {
+		return nil, err
 	}
-
-	data, err := json.Marshal(event)
+	tenantID, err := tenant.Require(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal withdraw event: %w", err)
+		return nil, err
 	}
 
-	if err := s.producer.Publish("account-withdraw", fmt.Sprintf("%d", accountID), data); err != nil {
-		return nil, fmt.Errorf("failed to publish withdraw event: %w", err)
+	payload := entity.TransactionEvent{
+		TenantID:        tenantID,
+		AccountID:       accountID,
+		Amount:          amount,
+		Currency:        account.Currency,
+		TransactionType: entity.TransactionTypeWithdrawal,
 	}
 
-	return &entity.Transaction{
-		AccountID:       int(accountID),
-		Amount:          amount,
-		TransactionType: "withdraw",
-		CreatedAt:       event.CreatedAt,
-	}, nil
+	return s.submit(ctx, s.topics.Withdraw, payload, entity.AuditTransactionWithdraw)
 }
 
 func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAccountID int64, amount float64) (*entity.Transaction, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.Transfer")
+	defer span.End()
+
 	if amount <= 0 {
-		return nil, errors.New("transfer amount must be greater than zero")
+		return nil, errs.Validation("transfer amount must be greater than zero")
 	}
 	if fromAccountID == toAccountID {
-		return nil, errors.New("cannot transfer to the same account")
+		return nil, errs.Validation("cannot transfer to the same account")
+	}
+	if err := s.access.CheckAccount(ctx, fromAccountID, entity.RelationSignatory); err != nil {
+		return nil, err
 	}
 
-	_, err := s.checkAccountActive(ctx, fromAccountID)
+	from, err := s.checkAccountActive(ctx, fromAccountID)
 	if err != nil {
 		return nil, err
 	}
@@ -127,40 +184,159 @@ func (s *TransactionService) Transfer(ctx context.Context, fromAccountID, toAcco
 	if err != nil {
 		return nil, err
 	}
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	event := entity.TransactionEvent{
+	payload := entity.TransactionEvent{
+		TenantID:        tenantID,
 		AccountID:       fromAccountID,
 		RelatedAccount:  &toAccountID,
 		Amount:          amount,
-		TransactionType: "transfer",
-		CreatedAt:       time.Now(),
+		Currency:        from.Currency,
+		TransactionType: entity.TransactionTypeTransfer,
 	}
 
-	data, err := json.Marshal(event)
+	// Keyed by the source account, the event also waits for the messages of
+	// the target account delivered before it, on any transaction topic.
+	ctx = bus.WithOrderingKeys(ctx, eventKey(tenantID, toAccountID))
+	return s.submit(ctx, s.topics.Transfer, payload, entity.AuditTransactionTransfer)
+}
+
+// submit hands a transaction over for processing. In ModeAsync its event is
+// published on topic and the returned transaction only echoes the request;
+// in ModeSync it is applied by the same TransactionApplier the consumers use
+// and the stored transaction is returned.
+func (s *TransactionService) submit(ctx context.Context, topic string, payload entity.TransactionEvent, action string) (*entity.Transaction, error) {
+	env, err := event.NewTransaction(payload, time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal transfer event: %w", err)
+		return nil, fmt.Errorf("failed to encode %s event: %w", payload.TransactionType, err)
+	}
+	log := logger.FromContext(ctx, s.logger).WithFields(logrus.Fields{
+		"account_id":       payload.AccountID,
+		"transaction_type": payload.TransactionType,
+		"event_id":         env.EventID,
+	})
+	target := AuditTarget{AccountID: payload.AccountID}
+
+	if s.sync {
+		traceID := trace.SpanContextFromContext(ctx).TraceID().String()
+		txn, err := s.applier.Apply(ctx, env, payload, bus.CorrelationFromContext(ctx), traceID)
+		if err != nil {
+			return nil, err
+		}
+		log.WithField("transaction_id", txn.ID).Info("Transaction applied")
+		s.audit.Record(ctx, action, target, nil, env)
+		return txn, nil
 	}
 
-	if err := s.producer.Publish("account-transfer", fmt.Sprintf("%d", fromAccountID), data); err != nil {
-		return nil, fmt.Errorf("failed to publish transfer event: %w", err)
+	if err := s.producer.Publish(ctx, topic, eventKey(payload.TenantID, payload.AccountID), env); err != nil {
+		return nil, fmt.Errorf("failed to publish %s event: %w", payload.TransactionType, err)
 	}
+	log.WithField("topic", topic).Info("Transaction event published")
+	s.audit.Record(ctx, action, target, nil, env)
 
 	return &entity.Transaction{
-		AccountID:       int(fromAccountID),
-		Amount:          amount,
-		TransactionType: "transfer",
-		CreatedAt:       event.CreatedAt,
+		TenantID:        payload.TenantID,
+		AccountID:       int(payload.AccountID),
+		RelatedAccount:  relatedAccount(payload.RelatedAccount),
+		Amount:          payload.Amount,
+		TransactionType: payload.TransactionType,
+		CreatedAt:       env.OccurredAt,
+		EventID:         env.EventID,
 	}, nil
 }
 
-func (s *TransactionService) ListTransactions(accountID int64) ([]entity.Transaction, error) {
-	return s.transacRepo.ListTransactions(accountID)
+// Await waits up to timeout for the result of the transaction submitted as
+// eventID. It returns the applied transaction, the error the transaction
+// was rejected with, or ErrTransactionPending when it has no result by then.
+func (s *TransactionService) Await(ctx context.Context, eventID string, timeout time.Duration) (*entity.Transaction, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.Await")
+	defer span.End()
+
+	// Subscribing before the first lookup means a result stored in between
+	// still wakes us.
+	stored, unsubscribe := s.results.Subscribe(eventID)
+	defer unsubscribe()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		res, err := s.Result(ctx, eventID)
+		if err != nil {
+			return nil, err
+		}
+		switch res.Status {
+		case entity.ResultCompleted:
+			return res.Transaction, nil
+		case entity.ResultFailed:
+			return nil, errs.FromCode(res.ErrorCode, res.Error)
+		}
+
+		select {
+		case <-stored:
+		case <-timer.C:
+			return nil, ErrTransactionPending
+		case <-ctx.Done():
+			return nil, ErrTransactionPending
+		}
+	}
+}
+
+// Result returns the result of the transaction submitted as eventID, with
+// status pending while it has none. Unknown event IDs are pending as well.
+func (s *TransactionService) Result(ctx context.Context, eventID string) (*entity.TransactionResult, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.Result")
+	defer span.End()
+
+	res, err := s.transacRepo.GetResult(ctx, eventID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return &entity.TransactionResult{EventID: eventID, Status: entity.ResultPending}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.access.CheckAccount(ctx, res.AccountID, entity.RelationViewer); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *TransactionService) ListTransactions(ctx context.Context, accountID int64) ([]entity.Transaction, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.ListTransactions")
+	defer span.End()
+
+	if err := s.access.CheckAccount(ctx, accountID, entity.RelationViewer); err != nil {
+		return nil, err
+	}
+	return s.transacRepo.ListTransactions(ctx, accountID)
+}
+
+// History returns up to limit transactions touching the account, including inbound
+// transfers, with an ID greater than afterID, oldest first.
+func (s *TransactionService) History(ctx context.Context, accountID, afterID int64, limit int) ([]entity.Transaction, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "TransactionService.History")
+	defer span.End()
+
+	if err := s.access.CheckAccount(ctx, accountID, entity.RelationViewer); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	return s.transacRepo.ListTransactionsAfter(ctx, accountID, afterID, limit)
 }
 
 func (s *TransactionService) SetRepo(TransacRepo TransactionRepo) {
 	s.transacRepo = TransacRepo
 }
 
+// eventKey partitions events by tenant and account so each account's events stay ordered.
+func eventKey(tenantID string, accountID int64) string {
+	return fmt.Sprintf("%s:%d", tenantID, accountID)
+}
+
 func (s *TransactionService) checkAccountActive(ctx context.Context, accountID int64) (*entity.Account, error) {
 	account, err := s.accountRepo.GetByID(ctx, accountID)
 	if err != nil {
@@ -168,11 +344,11 @@ func (s *TransactionService) checkAccountActive(ctx context.Context, accountID i
 	}
 
 	if account.IsLocked {
-		return nil, errors.New("account is locked")
+		return nil, errs.Locked("account %d is locked", accountID)
 	}
 
 	if account.DeletedAt != nil {
-		return nil, errors.New("account is deleted")
+		return nil, errs.NotFound("account %d is deleted", accountID)
 	}
 	return account, nil
 
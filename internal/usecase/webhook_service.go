@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/serikdev/CashFlow/internal/errs"
+	"github.com/serikdev/CashFlow/internal/tenant"
+	"github.com/serikdev/CashFlow/internal/tracing"
+	"github.com/serikdev/CashFlow/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+type WebhookRepo interface {
+	CreateSubscription(ctx context.Context, sub *entity.WebhookSubscription) (*entity.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	MatchingSubscriptions(ctx context.Context, eventType string) ([]entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	CreateDelivery(ctx context.Context, subscriptionID int, eventType string, payload []byte) error
+	ListDeliveries(ctx context.Context, subscriptionID int64) ([]entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64) (*entity.WebhookDelivery, error)
+}
+
+// EventNotifier receives domain events that subscribers may be interested in.
+type EventNotifier interface {
+	Notify(ctx context.Context, eventType string, data interface{}) error
+}
+
+type WebhookService struct {
+	repo   WebhookRepo
+	audit  Auditor
+	logger *logrus.Entry
+}
+
+func NewWebhookService(repo WebhookRepo, audit Auditor, logger *logrus.Entry) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+func (s *WebhookService) Subscribe(ctx context.Context, rawURL string, eventTypes []string) (*entity.WebhookSubscription, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.Subscribe")
+	defer span.End()
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errs.Validation("webhook url must be an absolute http(s) url")
+	}
+	if len(eventTypes) == 0 {
+		return nil, errs.Validation("at least one event type is required")
+	}
+	for _, t := range eventTypes {
+		if !slices.Contains(entity.WebhookEventTypes, t) {
+			return nil, errs.Validation("unknown event type %q", t)
+		}
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("error generating webhook secret: %w", err)
+	}
+
+	sub, err := s.repo.CreateSubscription(ctx, &entity.WebhookSubscription{
+		URL:        rawURL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating webhook subscription: %w", err)
+	}
+
+	// The secret must not end up in the audit log.
+	redacted := *sub
+	redacted.Secret = ""
+	s.audit.Record(ctx, entity.AuditWebhookSubscribe, AuditTarget{}, nil, redacted)
+	return sub, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.ListSubscriptions")
+	defer span.End()
+
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) Unsubscribe(ctx context.Context, id int64) error {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.Unsubscribe")
+	defer span.End()
+
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, entity.AuditWebhookUnsubscribe, AuditTarget{}, map[string]int64{"subscription_id": id}, nil)
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID int64) ([]entity.WebhookDelivery, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	return s.repo.ListDeliveries(ctx, subscriptionID)
+}
+
+// Redeliver schedules a delivery for an immediate new attempt regardless of its status.
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID int64) (*entity.WebhookDelivery, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.Redeliver")
+	defer span.End()
+
+	delivery, err := s.repo.Redeliver(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx, s.logger).WithField("delivery_id", deliveryID).Info("Webhook redelivery scheduled")
+	s.audit.Record(ctx, entity.AuditWebhookRedeliver, AuditTarget{}, nil, delivery)
+	return delivery, nil
+}
+
+// Notify enqueues a delivery for every subscription of the context tenant that
+// listens to eventType. The dispatcher sends them asynchronously.
+func (s *WebhookService) Notify(ctx context.Context, eventType string, data interface{}) error {
+	ctx, span := tracing.Tracer().Start(ctx, "WebhookService.Notify")
+	defer span.End()
+
+	tenantID, err := tenant.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	subs, err := s.repo.MatchingSubscriptions(ctx, eventType)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	eventID, err := randomHex(16)
+	if err != nil {
+		return fmt.Errorf("error generating event id: %w", err)
+	}
+	payload, err := json.Marshal(entity.WebhookEvent{
+		ID:        eventID,
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("error to marshal webhook event: %w", err)
+	}
+
+	for _, sub := range subs {
+		if err := s.repo.CreateDelivery(ctx, sub.ID, eventType, payload); err != nil {
+			return err
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"event_type":    eventType,
+		"subscriptions": len(subs),
+	}).Debug("Webhook deliveries enqueued")
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/entity"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderEvent     = "X-CashFlow-Event"
+	HeaderDelivery  = "X-CashFlow-Delivery"
+	HeaderTimestamp = "X-CashFlow-Timestamp"
+	HeaderSignature = "X-CashFlow-Signature"
+
+	maxBackoff = time.Hour
+)
+
+type DeliveryRepository interface {
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+	RecordAttempt(ctx context.Context, id int, status string, responseStatus *int, lastError *string, nextAttemptAt time.Time) error
+}
+
+// Dispatcher sends pending webhook deliveries and retries failures with
+// exponential backoff until MaxAttempts is reached.
+type Dispatcher struct {
+	repo   DeliveryRepository
+	client *http.Client
+	cfg    config.WebhookConfig
+	logger *logrus.Entry
+}
+
+func NewDispatcher(repo DeliveryRepository, client *http.Client, cfg config.WebhookConfig, logger *logrus.Entry) *Dispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+	return &Dispatcher{
+		repo:   repo,
+		client: client,
+		cfg:    cfg,
+		logger: logger.WithField("component", "webhook-dispatcher"),
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.logger.Info("Webhook dispatcher started")
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher stopped")
+			return nil
+		case <-ticker.C:
+			d.dispatchDue(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	// The lease covers the HTTP timeout so a crashed dispatcher's claims become due again.
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, d.cfg.BatchSize, d.cfg.Timeout*2)
+	if err != nil {
+		d.logger.WithError(err).Error("Failed to claim webhook deliveries")
+		return
+	}
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery entity.WebhookDelivery) {
+	log := d.logger.WithFields(logrus.Fields{
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+		"event_type":      delivery.EventType,
+		"attempt":         delivery.Attempts + 1,
+	})
+
+	statusCode, err := d.send(ctx, delivery)
+	var responseStatus *int
+	if statusCode != 0 {
+		responseStatus = &statusCode
+	}
+
+	if err == nil {
+		if err := d.repo.RecordAttempt(ctx, delivery.ID, entity.DeliverySucceeded, responseStatus, nil, time.Now()); err != nil {
+			log.WithError(err).Error("Failed to record webhook success")
+		}
+		log.Info("Webhook delivered")
+		return
+	}
+
+	lastError := err.Error()
+	status := entity.DeliveryPending
+	nextAttempt := time.Now().Add(d.backoff(delivery.Attempts + 1))
+	if delivery.Attempts+1 >= d.cfg.MaxAttempts {
+		status = entity.DeliveryFailed
+	}
+	if err := d.repo.RecordAttempt(ctx, delivery.ID, status, responseStatus, &lastError, nextAttempt); err != nil {
+		log.WithError(err).Error("Failed to record webhook failure")
+	}
+	log.WithError(err).WithField("status", status).Warn("Webhook delivery failed")
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery entity.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.InitialBackoff << (attempt - 1)
+	if wait <= 0 || wait > maxBackoff {
+		return maxBackoff
+	}
+	return wait
+}
+
+// Sign computes the hex HMAC-SHA256 of "<timestamp>.<body>" with the subscription
+// secret. Receivers recompute it to verify X-CashFlow-Signature.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a X-CashFlow-Signature header value against the body.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	expected := "sha256=" + Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
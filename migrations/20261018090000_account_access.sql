@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE account_access (
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    subject VARCHAR(255) NOT NULL,
+    relation VARCHAR(20) NOT NULL CHECK (
+        relation IN ('viewer', 'signatory', 'owner')
+    ),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, subject)
+);
+
+CREATE INDEX idx_account_access_subject ON account_access(subject);
+
+-- +goose Down
+DROP TABLE account_access;
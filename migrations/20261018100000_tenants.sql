@@ -0,0 +1,51 @@
+-- +goose Up
+ALTER TABLE accounts ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE transactions ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE account_access ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- Existing rows belong to the default tenant; new rows must name their tenant.
+ALTER TABLE accounts ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE account_access ALTER COLUMN tenant_id DROP DEFAULT;
+
+CREATE INDEX idx_accounts_tenant_id ON accounts(tenant_id, created_at);
+CREATE INDEX idx_transactions_tenant_account ON transactions(tenant_id, account_id);
+CREATE INDEX idx_account_access_tenant_subject ON account_access(tenant_id, subject);
+
+-- Row-level security: the application sets app.tenant_id on every pooled connection.
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON accounts
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE transactions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transactions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON transactions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+ALTER TABLE account_access ENABLE ROW LEVEL SECURITY;
+ALTER TABLE account_access FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON account_access
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- +goose Down
+DROP POLICY tenant_isolation ON account_access;
+DROP POLICY tenant_isolation ON transactions;
+DROP POLICY tenant_isolation ON accounts;
+ALTER TABLE account_access DISABLE ROW LEVEL SECURITY;
+ALTER TABLE transactions DISABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;
+ALTER TABLE account_access NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transactions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+
+DROP INDEX idx_account_access_tenant_subject;
+DROP INDEX idx_transactions_tenant_account;
+DROP INDEX idx_accounts_tenant_id;
+
+ALTER TABLE account_access DROP COLUMN tenant_id;
+ALTER TABLE transactions DROP COLUMN tenant_id;
+ALTER TABLE accounts DROP COLUMN tenant_id;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE transactions ADD COLUMN related_account_id INTEGER NULL REFERENCES accounts(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_transactions_related_account_id ON transactions(related_account_id) WHERE related_account_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_transactions_related_account_id;
+ALTER TABLE transactions DROP COLUMN related_account_id;
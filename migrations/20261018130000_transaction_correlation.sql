@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE transactions
+    ADD COLUMN request_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN trace_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN initiated_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_transactions_request_id ON transactions(request_id) WHERE request_id <> '';
+
+-- +goose Down
+DROP INDEX idx_transactions_request_id;
+ALTER TABLE transactions
+    DROP COLUMN initiated_by,
+    DROP COLUMN trace_id,
+    DROP COLUMN request_id;
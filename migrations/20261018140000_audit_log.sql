@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    actor_subject TEXT NOT NULL DEFAULT '',
+    actor_role VARCHAR(20) NOT NULL DEFAULT '',
+    action VARCHAR(64) NOT NULL,
+    account_id INTEGER NULL,
+    transaction_id INTEGER NULL,
+    before JSONB NULL,
+    after JSONB NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    source_ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_tenant_created ON audit_log(tenant_id, created_at DESC);
+CREATE INDEX idx_audit_log_account ON audit_log(tenant_id, account_id) WHERE account_id IS NOT NULL;
+CREATE INDEX idx_audit_log_transaction ON audit_log(tenant_id, transaction_id) WHERE transaction_id IS NOT NULL;
+CREATE INDEX idx_audit_log_actor ON audit_log(tenant_id, actor_subject);
+CREATE INDEX idx_audit_log_request_id ON audit_log(request_id) WHERE request_id <> '';
+
+-- The audit log is append-only: rows can be inserted but never changed or removed.
+-- +goose StatementBegin
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+ALTER TABLE audit_log ENABLE ROW LEVEL SECURITY;
+ALTER TABLE audit_log FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON audit_log
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- +goose Down
+DROP POLICY tenant_isolation ON audit_log;
+DROP TRIGGER audit_log_append_only ON audit_log;
+DROP FUNCTION audit_log_append_only();
+DROP TABLE audit_log;
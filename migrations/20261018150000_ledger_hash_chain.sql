@@ -0,0 +1,47 @@
+-- +goose Up
+-- Transactions persisted before this migration keep empty hashes and are
+-- reported as unsealed; each account's chain starts at its first hashed row.
+ALTER TABLE transactions ADD COLUMN prev_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE transactions ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_transactions_chain ON transactions(tenant_id, account_id, id) WHERE hash <> '';
+
+CREATE TABLE ledger_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    heads JSONB NOT NULL,
+    root TEXT NOT NULL,
+    key_id TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_ledger_checkpoints_tenant ON ledger_checkpoints(tenant_id, id DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION ledger_checkpoints_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger_checkpoints is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER ledger_checkpoints_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON ledger_checkpoints
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_checkpoints_append_only();
+
+ALTER TABLE ledger_checkpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ledger_checkpoints FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON ledger_checkpoints
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- +goose Down
+DROP POLICY tenant_isolation ON ledger_checkpoints;
+DROP TRIGGER ledger_checkpoints_append_only ON ledger_checkpoints;
+DROP FUNCTION ledger_checkpoints_append_only();
+DROP TABLE ledger_checkpoints;
+
+DROP INDEX idx_transactions_chain;
+ALTER TABLE transactions DROP COLUMN hash;
+ALTER TABLE transactions DROP COLUMN prev_hash;
@@ -0,0 +1,69 @@
+-- +goose Up
+-- Reconciliation recomputes balances as opening_balance plus the transaction
+-- log. Existing accounts are baselined at their current balance minus the
+-- logged transactions, so only drift after this migration is reported.
+ALTER TABLE accounts ADD COLUMN opening_balance NUMERIC(15, 2) NOT NULL DEFAULT 0;
+
+-- The owner bypasses row-level security only while it is not forced.
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE transactions NO FORCE ROW LEVEL SECURITY;
+UPDATE accounts a SET opening_balance = a.balance - COALESCE((
+    SELECT SUM(CASE
+        WHEN t.transaction_type = 'deposit' THEN t.amount
+        WHEN t.transaction_type IN ('withdraw', 'withdrawal') THEN -t.amount
+        WHEN t.transaction_type = 'transfer' AND t.account_id = a.id THEN -t.amount
+        WHEN t.transaction_type = 'transfer' THEN t.amount
+        ELSE 0
+    END)
+    FROM transactions t
+    WHERE t.tenant_id = a.tenant_id AND (t.account_id = a.id OR t.related_account_id = a.id) AND t.deleted_at IS NULL
+), 0);
+ALTER TABLE transactions FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts FORCE ROW LEVEL SECURITY;
+
+-- Approved corrections are logged as signed 'correction' transactions.
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check CHECK (
+    transaction_type IN ('deposit', 'withdrawal', 'transfer', 'correction')
+);
+
+CREATE TABLE balance_corrections (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount != 0),
+    balance NUMERIC(15, 2) NOT NULL,
+    expected_balance NUMERIC(15, 2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (
+        status IN ('pending', 'approved', 'rejected')
+    ),
+    requested_by TEXT NOT NULL DEFAULT '',
+    decided_by TEXT NULL,
+    decided_at TIMESTAMPTZ NULL,
+    transaction_id INTEGER NULL REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- At most one open correction per account.
+CREATE UNIQUE INDEX idx_balance_corrections_pending ON balance_corrections(tenant_id, account_id) WHERE status = 'pending';
+CREATE INDEX idx_balance_corrections_tenant ON balance_corrections(tenant_id, status, id DESC);
+
+ALTER TABLE balance_corrections ENABLE ROW LEVEL SECURITY;
+ALTER TABLE balance_corrections FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON balance_corrections
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- +goose Down
+DROP POLICY tenant_isolation ON balance_corrections;
+DROP TABLE balance_corrections;
+
+ALTER TABLE transactions NO FORCE ROW LEVEL SECURITY;
+DELETE FROM transactions WHERE transaction_type = 'correction';
+ALTER TABLE transactions FORCE ROW LEVEL SECURITY;
+ALTER TABLE transactions DROP CONSTRAINT transactions_transaction_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_transaction_type_check CHECK (
+    transaction_type IN ('deposit', 'withdrawal', 'transfer')
+);
+
+ALTER TABLE accounts DROP COLUMN opening_balance;
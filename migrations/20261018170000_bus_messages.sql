@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE bus_messages (
+    id BIGSERIAL PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    key TEXT NOT NULL,
+    value BYTEA NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_bus_messages_topic ON bus_messages(topic, id);
+CREATE INDEX idx_bus_messages_key ON bus_messages(topic, key, id);
+
+/*
+    NOTE: the queue of the Postgres message bus. It is not under row-level
+    security because consumers claim messages for all tenants; the tenant
+    travels in the message value. Acknowledged messages are deleted.
+*/
+
+-- +goose Down
+DROP TABLE bus_messages;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE transaction_results (
+    event_id VARCHAR(64) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    account_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('completed', 'failed')),
+    transaction_id INTEGER NULL REFERENCES transactions(id),
+    error_code VARCHAR(64) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_transaction_results_tenant ON transaction_results(tenant_id, created_at);
+
+ALTER TABLE transaction_results ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transaction_results FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON transaction_results
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+/*
+    NOTE: the outcome of every applied or rejected transaction event, keyed
+    by the event ID returned to the client. Each write is followed by a
+    NOTIFY on the transaction_results channel carrying the event ID, so
+    that requests waiting on ?wait= learn of it on any instance.
+*/
+
+-- +goose Down
+DROP POLICY tenant_isolation ON transaction_results;
+DROP TABLE transaction_results;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE bus_messages ADD COLUMN ordering_keys TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE bus_messages
+SET ordering_keys = ARRAY[key] || string_to_array(headers->>'X-Ordering-Keys', ',');
+
+CREATE INDEX idx_bus_messages_ordering_keys ON bus_messages USING GIN (ordering_keys);
+
+/*
+    NOTE: ordering_keys holds the key of a message and the keys of its
+    X-Ordering-Keys header. A message is claimed only when no earlier message
+    of any topic shares one of them, so a transfer waits for the messages of
+    its target account too.
+*/
+
+-- +goose Down
+DROP INDEX idx_bus_messages_ordering_keys;
+ALTER TABLE bus_messages DROP COLUMN ordering_keys;
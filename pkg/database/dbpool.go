@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/serikdev/CashFlow/internal/config"
+	"github.com/serikdev/CashFlow/internal/tenant"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,6 +36,18 @@ func NewPool(ctx context.Context, cfg config.DBConfig, appConfig *config.Config,
 		return nil, fmt.Errorf("error to parse pool config: %w", err)
 	}
 
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+
+	// Every acquired connection is scoped to the tenant of the caller's context,
+	// which the row-level security policies read from app.tenant_id.
+	poolConfig.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		tenantID, _ := tenant.FromContext(ctx)
+		if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false)", tenantID); err != nil {
+			return false, fmt.Errorf("error to set tenant on connection: %w", err)
+		}
+		return true, nil
+	}
+
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		logger.WithError(err).Error("Failed to pool DB")
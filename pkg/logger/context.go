@@ -0,0 +1,18 @@
+package logger
+
+import "context"
+
+type loggerKey struct{}
+
+// WithContext stores a request-scoped logger in ctx.
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the request-scoped logger of ctx, or fallback when there is none.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(loggerKey{}).(Logger); ok && l != nil {
+		return l
+	}
+	return fallback
+}
@@ -0,0 +1,166 @@
+// Package validator checks structs against declarative `validate` struct tags.
+//
+// Rules are comma separated and applied in order; the first failing rule of a
+// field is reported. Supported rules:
+//
+//	required      non-zero value (non-empty string or slice)
+//	gt=N, gte=N   numeric lower bound
+//	lt=N, lte=N   numeric upper bound
+//	max=N         maximum string length or slice size
+//	oneof=a b c   string must equal one of the space separated values
+//	currency      ISO 4217 style code: three upper-case letters
+//	url           absolute http or https URL
+//
+// Field names in errors are taken from the `json` tag.
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a single failed rule.
+type FieldError struct {
+	Field   string `json:"field" example:"amount"`
+	Rule    string `json:"rule" example:"gt"`
+	Message string `json:"message" example:"must be greater than 0"`
+}
+
+// Errors is returned by Struct when one or more fields are invalid.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, f := range e {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Struct validates the exported fields of v, which must be a struct or a pointer to one.
+// It returns nil or Errors.
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validator: expected struct, got %s", rv.Kind())
+	}
+
+	var errs Errors
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+		if fe, ok := checkField(fieldName(sf), rv.Field(i), tag); !ok {
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func fieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+func checkField(name string, v reflect.Value, tag string) (FieldError, bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if msg := apply(rule, param, v); msg != "" {
+			return FieldError{Field: name, Rule: rule, Message: msg}, false
+		}
+	}
+	return FieldError{}, true
+}
+
+// apply returns a message describing the failure, or "" when the rule holds.
+func apply(rule, param string, v reflect.Value) string {
+	switch rule {
+	case "required":
+		if v.IsZero() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			return "is required"
+		}
+	case "gt", "gte", "lt", "lte":
+		n, ok := number(v)
+		if !ok {
+			return "must be a number"
+		}
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validator: bad %s parameter %q", rule, param))
+		}
+		switch {
+		case rule == "gt" && n <= limit:
+			return "must be greater than " + param
+		case rule == "gte" && n < limit:
+			return "must be greater than or equal to " + param
+		case rule == "lt" && n >= limit:
+			return "must be less than " + param
+		case rule == "lte" && n > limit:
+			return "must be less than or equal to " + param
+		}
+	case "max":
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			panic(fmt.Sprintf("validator: bad max parameter %q", param))
+		}
+		if (v.Kind() == reflect.String || v.Kind() == reflect.Slice) && v.Len() > limit {
+			return "must be at most " + param + " long"
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		for _, o := range options {
+			if v.String() == o {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "currency":
+		if !isCurrency(v.String()) {
+			return "must be a 3-letter ISO 4217 currency code"
+		}
+	case "url":
+		u, err := url.Parse(v.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an absolute http(s) URL"
+		}
+	default:
+		panic(fmt.Sprintf("validator: unknown rule %q", rule))
+	}
+	return ""
+}
+
+func number(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func isCurrency(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
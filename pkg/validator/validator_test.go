@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		tag   string
+		// message is the failure message, or "" when the rule holds.
+		message string
+	}{
+		{name: "required string", value: "a", tag: "required"},
+		{name: "required empty string", value: "", tag: "required", message: "is required"},
+		{name: "required zero number", value: int64(0), tag: "required", message: "is required"},
+		{name: "required slice", value: []string{"a"}, tag: "required"},
+		{name: "required empty slice", value: []string{}, tag: "required", message: "is required"},
+		{name: "required nil slice", value: []string(nil), tag: "required", message: "is required"},
+
+		{name: "gt above", value: 0.01, tag: "gt=0"},
+		{name: "gt equal", value: 0.0, tag: "gt=0", message: "must be greater than 0"},
+		{name: "gte equal", value: int64(0), tag: "gte=0"},
+		{name: "gte below", value: -1, tag: "gte=0", message: "must be greater than or equal to 0"},
+		{name: "lt below", value: uint(9), tag: "lt=10"},
+		{name: "lt equal", value: uint(10), tag: "lt=10", message: "must be less than 10"},
+		{name: "lte equal", value: float32(1.5), tag: "lte=1.5"},
+		{name: "lte above", value: 2, tag: "lte=1.5", message: "must be less than or equal to 1.5"},
+		{name: "bound of a string", value: "5", tag: "gt=0", message: "must be a number"},
+
+		{name: "max string", value: "abc", tag: "max=3"},
+		{name: "max long string", value: "abcd", tag: "max=3", message: "must be at most 3 long"},
+		{name: "max string in bytes", value: "äb", tag: "max=2", message: "must be at most 2 long"},
+		{name: "max slice", value: []int{1, 2}, tag: "max=2"},
+		{name: "max long slice", value: []int{1, 2, 3}, tag: "max=2", message: "must be at most 2 long"},
+		{name: "max of a number", value: 1000, tag: "max=2"},
+
+		{name: "oneof", value: "signatory", tag: "oneof=viewer signatory owner"},
+		{name: "oneof other", value: "admin", tag: "oneof=viewer signatory owner", message: "must be one of: viewer, signatory, owner"},
+		{name: "oneof empty", value: "", tag: "oneof=viewer signatory", message: "must be one of: viewer, signatory"},
+
+		{name: "currency", value: "TMT", tag: "currency"},
+		{name: "currency lower case", value: "tmt", tag: "currency", message: "must be a 3-letter ISO 4217 currency code"},
+		{name: "currency too long", value: "TMTX", tag: "currency", message: "must be a 3-letter ISO 4217 currency code"},
+		{name: "currency not a letter", value: "TM1", tag: "currency", message: "must be a 3-letter ISO 4217 currency code"},
+
+		{name: "url https", value: "https://example.com/hooks", tag: "url"},
+		{name: "url http", value: "http://localhost:8080", tag: "url"},
+		{name: "url other scheme", value: "ftp://example.com", tag: "url", message: "must be an absolute http(s) URL"},
+		{name: "url relative", value: "/hooks", tag: "url", message: "must be an absolute http(s) URL"},
+		{name: "url without host", value: "https://", tag: "url", message: "must be an absolute http(s) URL"},
+		{name: "url invalid", value: "http://[::1", tag: "url", message: "must be an absolute http(s) URL"},
+
+		{name: "first failing rule", value: "", tag: "required,currency", message: "is required"},
+		{name: "rules in order", value: "tmt", tag: "required, currency", message: "must be a 3-letter ISO 4217 currency code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe, ok := checkField("f", reflect.ValueOf(tt.value), tt.tag)
+			switch {
+			case tt.message == "" && !ok:
+				t.Errorf("%v against %q failed with %q, want it to hold", tt.value, tt.tag, fe.Message)
+			case tt.message != "" && ok:
+				t.Errorf("%v against %q holds, want %q", tt.value, tt.tag, tt.message)
+			case tt.message != "" && fe.Message != tt.message:
+				t.Errorf("%v against %q failed with %q, want %q", tt.value, tt.tag, fe.Message, tt.message)
+			}
+		})
+	}
+}
+
+// TestBadTags checks that a tag the validator cannot apply panics, since it
+// is a programming error rather than invalid input.
+func TestBadTags(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "requird", want: `validator: unknown rule "requird"`},
+		{tag: "omitempty", want: `validator: unknown rule "omitempty"`},
+		{tag: "gt=zero", want: `validator: bad gt parameter "zero"`},
+		{tag: "lte", want: `validator: bad lte parameter ""`},
+		{tag: "max=1.5", want: `validator: bad max parameter "1.5"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			defer func() {
+				if got := recover(); got != tt.want {
+					t.Errorf("tag %q panicked with %v, want %q", tt.tag, got, tt.want)
+				}
+			}()
+			checkField("f", reflect.ValueOf(1), tt.tag)
+		})
+	}
+}
+
+func TestStruct(t *testing.T) {
+	type request struct {
+		Subject  string `json:"subject" validate:"required,max=5"`
+		Relation string `json:"relation,omitempty" validate:"oneof=viewer owner"`
+		Amount   float64
+		Currency string `validate:"currency"`
+		internal string `validate:"required"`
+	}
+
+	if err := Struct(&request{Subject: "alice", Relation: "owner", Currency: "TMT"}); err != nil {
+		t.Fatalf("Struct of a valid request = %v, want nil", err)
+	}
+
+	err := Struct(request{Subject: "", Relation: "admin", Currency: "TMT"})
+	var fieldErrs Errors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("Struct = %v, want Errors", err)
+	}
+	want := Errors{
+		{Field: "subject", Rule: "required", Message: "is required"},
+		{Field: "relation", Rule: "oneof", Message: "must be one of: viewer, owner"},
+	}
+	if !reflect.DeepEqual(fieldErrs, want) {
+		t.Errorf("Struct = %+v, want %+v", fieldErrs, want)
+	}
+	if msg := err.Error(); msg != "validation failed: subject: is required; relation: must be one of: viewer, owner" {
+		t.Errorf("Error() = %q", msg)
+	}
+
+	if err := Struct(request{Subject: "alice", Relation: "owner", Currency: "usd"}); err == nil ||
+		!strings.Contains(err.Error(), "Currency: must be a 3-letter") {
+		t.Errorf("Struct = %v, want the Go field name without a json tag", err)
+	}
+
+	if err := Struct("not a struct"); err == nil || errors.As(err, &fieldErrs) {
+		t.Errorf("Struct of a string = %v, want a non-validation error", err)
+	}
+}